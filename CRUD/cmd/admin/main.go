@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"log/slog"
+
+	"github.com/apk471/go-crud-api/internal/config"
+	"github.com/apk471/go-crud-api/internal/storage/sqlite"
+	"github.com/apk471/go-crud-api/internal/tenant"
+)
+
+// admin grants or revokes admin rights for one user of one tenant. Admins
+// export and erase users of their own tenant; backups are left to operators,
+// see cmd/operator.
+//
+//	go run ./cmd/admin -config config/local.yaml -tenant default -user 1
+//	go run ./cmd/admin -config config/local.yaml -tenant default -user 1 -revoke
+func main() {
+	tenantID := flag.String("tenant", "", "tenant the user belongs to")
+	userID := flag.Int64("user", 0, "id of the user")
+	revoke := flag.Bool("revoke", false, "revoke admin rights instead of granting them")
+
+	cfg := config.MustLoad()
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	if err := tenant.Validate(*tenantID); err != nil {
+		log.Fatalf("invalid -tenant: %v", err)
+	}
+	if *userID <= 0 {
+		log.Fatal("-user is required")
+	}
+
+	storage, err := sqlite.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := storage.ForTenant(*tenantID).SetAdmin(*userID, !*revoke); err != nil {
+		log.Fatalf("failed to update user: %v", err)
+	}
+
+	slog.Info("admin rights updated",
+		slog.String("tenant", *tenantID),
+		slog.Int64("userId", *userID),
+		slog.Bool("admin", !*revoke),
+	)
+}
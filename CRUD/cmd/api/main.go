@@ -11,8 +11,10 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/apk471/go-crud-api/internal/backup"
 	"github.com/apk471/go-crud-api/internal/config"
 	"github.com/apk471/go-crud-api/internal/http/handlers/api"
+	"github.com/apk471/go-crud-api/internal/http/middleware"
 	"github.com/apk471/go-crud-api/internal/storage/sqlite"
 )
 
@@ -20,11 +22,6 @@ func main() {
 	fmt.Println("Welcome to CRUD API")
 	cfg := config.MustLoad()
 
-	router := http.NewServeMux()
-	// router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-	// 	w.Write([]byte("Welcome to CRUD API"))
-	// })
-
 	storage, err := sqlite.New(cfg)
 
 	if err != nil{
@@ -33,14 +30,24 @@ func main() {
 
 	slog.Info("storage initialized", slog.String("env", cfg.Env), slog.String("version", "1.0.0"))
 
-	router.HandleFunc("POST /api/users" , api.New(storage))
-	router.HandleFunc("GET /api/users/{id}", api.GetById(storage))
-	router.HandleFunc("GET /api/users" , api.GetList(storage))
+	if resumed, err := storage.ResumeErasures(); err != nil {
+		log.Fatal(err)
+	} else if resumed > 0 {
+		slog.Info("resumed interrupted erasures", slog.Int("count", resumed))
+	}
+
+	backups := backup.NewManager(storage, cfg.Backup.Dir, cfg.Backup.Retention)
+
+	backupCtx, stopBackups := context.WithCancel(context.Background())
+	defer stopBackups()
+	if cfg.Backup.Interval > 0 {
+		go backups.Run(backupCtx, cfg.Backup.Interval)
+	}
 
 
 	server := http.Server{
 		Addr: cfg.HttpServer.Addr,
-		Handler: router,
+		Handler: newRouter(cfg, storage, backups),
 	}
 	// Start the server
 	slog.Info("server is running", "address", cfg.HttpServer.Addr)
@@ -58,6 +65,7 @@ func main() {
 	<- done
 
 	slog.Info("server is shutting down")
+	stopBackups()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -67,4 +75,40 @@ func main() {
 	}
 
 	slog.Info("server is shutdown")
-}
\ No newline at end of file
+}
+
+// newRouter wires every route. Routes that read or change users need a
+// session, so their tenant is always the session's and a tenant header can
+// only narrow it down to itself. Registration and login are the only
+// routes whose tenant comes from the request. Operator routes have no
+// tenant at all.
+func newRouter(cfg *config.Config, storage *sqlite.Sqlite, backups *backup.Manager) http.Handler {
+	router := http.NewServeMux()
+	authenticate := middleware.Authenticate(storage)
+
+	router.Handle("POST /api/users", authenticate(api.New(storage)))
+	router.Handle("GET /api/users/{id}", authenticate(api.GetById(storage)))
+	router.Handle("GET /api/users", authenticate(api.GetList(storage)))
+	router.Handle("POST /api/batch", authenticate(api.Batch(storage)))
+
+	router.HandleFunc("POST /api/auth/register", api.Register(storage))
+	router.HandleFunc("POST /api/auth/login", api.Login(storage, cfg.Auth.SessionTTL))
+	router.HandleFunc("POST /api/auth/logout", api.Logout(storage))
+	router.Handle("GET /api/users/me", authenticate(api.Me()))
+
+	requireAdmin := middleware.RequireAdmin()
+	router.Handle("GET /api/users/me/export", authenticate(api.ExportUser(storage)))
+	router.Handle("POST /api/users/me/erasure", authenticate(api.EraseUser(storage)))
+	router.Handle("GET /api/admin/users/{id}/export", authenticate(requireAdmin(api.ExportUser(storage))))
+	router.Handle("POST /api/admin/users/{id}/erasure", authenticate(requireAdmin(api.EraseUser(storage))))
+
+	// Backups span every tenant, so only operators reach them and no tenant
+	// is resolved for them.
+	root := http.NewServeMux()
+	requireOperator := middleware.RequireOperator(storage)
+	root.Handle("POST /api/operator/backups", requireOperator(api.CreateBackup(backups)))
+	root.Handle("GET /api/operator/backups", requireOperator(api.ListBackups(backups)))
+	root.Handle("/", middleware.ResolveTenant(cfg.Tenancy, storage)(router))
+
+	return root
+}
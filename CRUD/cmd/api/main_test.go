@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apk471/go-crud-api/internal/auth"
+	"github.com/apk471/go-crud-api/internal/backup"
+	"github.com/apk471/go-crud-api/internal/config"
+	"github.com/apk471/go-crud-api/internal/storage/sqlite"
+)
+
+type testServer struct {
+	t       *testing.T
+	handler http.Handler
+	storage *sqlite.Sqlite
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		StoragePath: filepath.Join(dir, "storage.db"),
+		Auth:        config.Auth{SessionTTL: time.Hour},
+		Backup:      config.Backup{Dir: filepath.Join(dir, "backups")},
+		Tenancy:     config.Tenancy{DefaultTenant: "default", Header: "X-Tenant-ID"},
+	}
+
+	storage, err := sqlite.New(cfg)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	t.Cleanup(func() { storage.Db.Close() })
+
+	backups := backup.NewManager(storage, cfg.Backup.Dir, cfg.Backup.Retention)
+	return &testServer{t: t, handler: newRouter(cfg, storage, backups), storage: storage}
+}
+
+// do sends body as JSON with the given tenant header and bearer token, both
+// optional, and decodes the answer into out when it is not nil.
+func (s *testServer) do(method string, path string, tenantID string, token string, body any, out any) int {
+	s.t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			s.t.Fatalf("encode body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if tenantID != "" {
+		req.Header.Set("X-Tenant-ID", tenantID)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: decode %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+// signUp registers a user in the tenant and logs them in, returning the
+// user's id and session token.
+func (s *testServer) signUp(tenantID string, email string) (int64, string) {
+	s.t.Helper()
+
+	var registered struct {
+		ID int64 `json:"id"`
+	}
+	code := s.do(http.MethodPost, "/api/auth/register", tenantID, "", map[string]any{
+		"name": "Test User", "email": email, "age": 30, "password": "Password123",
+	}, &registered)
+	if code != http.StatusCreated {
+		s.t.Fatalf("register %s in %s: status %d", email, tenantID, code)
+	}
+
+	var session struct {
+		Token string `json:"token"`
+	}
+	code = s.do(http.MethodPost, "/api/auth/login", tenantID, "", map[string]any{
+		"email": email, "password": "Password123",
+	}, &session)
+	if code != http.StatusOK {
+		s.t.Fatalf("login %s in %s: status %d", email, tenantID, code)
+	}
+
+	return registered.ID, session.Token
+}
+
+func TestTenantIsolation(t *testing.T) {
+	s := newTestServer(t)
+	aliceID, aliceToken := s.signUp("tenant-a", "alice@example.com")
+	_, bobToken := s.signUp("tenant-b", "bob@example.com")
+	alice := fmt.Sprintf("/api/users/%d", aliceID)
+
+	t.Run("read", func(t *testing.T) {
+		var users []map[string]any
+		if code := s.do(http.MethodGet, "/api/users", "", bobToken, nil, &users); code != http.StatusOK {
+			t.Fatalf("list: status %d", code)
+		}
+		if len(users) != 1 || users[0]["email"] != "bob@example.com" {
+			t.Fatalf("tenant B lists %v, want only its own user", users)
+		}
+
+		if code := s.do(http.MethodGet, alice, "", bobToken, nil, nil); code != http.StatusNotFound {
+			t.Fatalf("tenant B gets tenant A's user: status %d, want 404", code)
+		}
+	})
+
+	t.Run("tenant header", func(t *testing.T) {
+		if code := s.do(http.MethodGet, "/api/users", "tenant-a", bobToken, nil, nil); code != http.StatusForbidden {
+			t.Fatalf("tenant B's session naming tenant A: status %d, want 403", code)
+		}
+		if code := s.do(http.MethodGet, "/api/users", "tenant-a", "", nil, nil); code != http.StatusUnauthorized {
+			t.Fatalf("naming tenant A without a session: status %d, want 401", code)
+		}
+	})
+
+	t.Run("update and delete", func(t *testing.T) {
+		var res struct {
+			Committed bool `json:"committed"`
+		}
+		code := s.do(http.MethodPost, "/api/batch", "", bobToken, map[string]any{
+			"operations": []map[string]any{{
+				"op": "update", "id": aliceID,
+				"user": map[string]any{"name": "Mallory", "email": "mallory@example.com", "age": 40},
+			}},
+		}, &res)
+		if code != http.StatusNotFound || res.Committed {
+			t.Fatalf("tenant B updates tenant A's user: status %d, committed %v, want 404", code, res.Committed)
+		}
+
+		code = s.do(http.MethodPost, "/api/batch", "", bobToken, map[string]any{
+			"operations": []map[string]any{{"op": "delete", "id": aliceID}},
+		}, nil)
+		if code != http.StatusNotFound {
+			t.Fatalf("tenant B deletes tenant A's user: status %d, want 404", code)
+		}
+
+		code = s.do(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/erasure", aliceID), "", bobToken, nil, nil)
+		if code != http.StatusForbidden {
+			t.Fatalf("tenant B erases tenant A's user: status %d, want 403", code)
+		}
+
+		var user map[string]any
+		if code := s.do(http.MethodGet, alice, "", aliceToken, nil, &user); code != http.StatusOK {
+			t.Fatalf("tenant A gets its user: status %d", code)
+		}
+		if user["name"] != "Test User" || user["email"] != "alice@example.com" {
+			t.Fatalf("tenant A's user = %v, want it untouched", user)
+		}
+	})
+}
+
+func TestUserRoutesNeedASession(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, route := range []struct {
+		method string
+		path   string
+		body   any
+	}{
+		{http.MethodGet, "/api/users", nil},
+		{http.MethodGet, "/api/users/1", nil},
+		{http.MethodPost, "/api/users", map[string]any{"name": "Eve", "email": "eve@example.com", "age": 30}},
+		{http.MethodPost, "/api/batch", map[string]any{"operations": []map[string]any{{"op": "delete", "id": 1}}}},
+	} {
+		if code := s.do(route.method, route.path, "default", "", route.body, nil); code != http.StatusUnauthorized {
+			t.Errorf("%s %s without a session: status %d, want 401", route.method, route.path, code)
+		}
+	}
+}
+
+func TestAdminRights(t *testing.T) {
+	s := newTestServer(t)
+	adminID, adminToken := s.signUp("default", "ops@example.com")
+	export := fmt.Sprintf("/api/admin/users/%d/export", adminID)
+
+	// The same email in a tenant of one's own grants nothing
+	_, impostorToken := s.signUp("impostor", "ops@example.com")
+
+	if code := s.do(http.MethodGet, export, "", adminToken, nil, nil); code != http.StatusForbidden {
+		t.Fatalf("export before being appointed: status %d, want 403", code)
+	}
+
+	if err := s.storage.ForTenant("default").SetAdmin(adminID, true); err != nil {
+		t.Fatalf("appoint admin: %v", err)
+	}
+
+	if code := s.do(http.MethodGet, export, "", adminToken, nil, nil); code != http.StatusOK {
+		t.Fatalf("export as admin: status %d, want 200", code)
+	}
+	if code := s.do(http.MethodGet, export, "", impostorToken, nil, nil); code != http.StatusForbidden {
+		t.Fatalf("export as the admin's email in another tenant: status %d, want 403", code)
+	}
+}
+
+func TestBackupsNeedAnOperator(t *testing.T) {
+	s := newTestServer(t)
+	adminID, adminToken := s.signUp("default", "ops@example.com")
+	if err := s.storage.ForTenant("default").SetAdmin(adminID, true); err != nil {
+		t.Fatalf("appoint admin: %v", err)
+	}
+
+	// An admin of one tenant must not copy every tenant's data
+	if code := s.do(http.MethodPost, "/api/operator/backups", "", adminToken, nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("backup as a tenant admin: status %d, want 401", code)
+	}
+	if code := s.do(http.MethodGet, "/api/admin/backups", "", adminToken, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("old admin backup route: status %d, want 404", code)
+	}
+
+	token, err := auth.NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.storage.SetOperator("ops", auth.HashToken(token)); err != nil {
+		t.Fatalf("issue operator token: %v", err)
+	}
+
+	// Operators need no tenant, not even a default one
+	var created backup.Info
+	if code := s.do(http.MethodPost, "/api/operator/backups", "", token, nil, &created); code != http.StatusCreated {
+		t.Fatalf("backup as operator: status %d, want 201", code)
+	}
+	var listed []backup.Info
+	if code := s.do(http.MethodGet, "/api/operator/backups", "no-such-tenant", token, nil, &listed); code != http.StatusOK {
+		t.Fatalf("list as operator: status %d, want 200", code)
+	}
+	if len(listed) != 1 || listed[0].Name != created.Name {
+		t.Fatalf("listed %v, want only %s", listed, created.Name)
+	}
+
+	if err := s.storage.DeleteOperator("ops"); err != nil {
+		t.Fatalf("revoke operator: %v", err)
+	}
+	if code := s.do(http.MethodGet, "/api/operator/backups", "", token, nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("list with a revoked token: status %d, want 401", code)
+	}
+}
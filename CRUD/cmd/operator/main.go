@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+
+	"github.com/apk471/go-crud-api/internal/auth"
+	"github.com/apk471/go-crud-api/internal/config"
+	"github.com/apk471/go-crud-api/internal/storage/sqlite"
+)
+
+// operator issues or revokes the bearer token of an operator. Operators take
+// and list backups of the whole database and belong to no tenant. Issuing a
+// token again replaces the previous one; the token is printed once and only
+// its hash is stored.
+//
+//	go run ./cmd/operator -config config/local.yaml -name ops
+//	go run ./cmd/operator -config config/local.yaml -name ops -revoke
+func main() {
+	name := flag.String("name", "", "name of the operator")
+	revoke := flag.Bool("revoke", false, "revoke the operator's token instead of issuing one")
+
+	cfg := config.MustLoad()
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	if *name == "" {
+		log.Fatal("-name is required")
+	}
+
+	storage, err := sqlite.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *revoke {
+		if err := storage.DeleteOperator(*name); err != nil {
+			log.Fatalf("failed to revoke operator: %v", err)
+		}
+		slog.Info("operator revoked", slog.String("operator", *name))
+		return
+	}
+
+	token, err := auth.NewToken()
+	if err != nil {
+		log.Fatalf("failed to create token: %v", err)
+	}
+	if err := storage.SetOperator(*name, auth.HashToken(token)); err != nil {
+		log.Fatalf("failed to issue operator token: %v", err)
+	}
+
+	slog.Info("operator token issued", slog.String("operator", *name))
+	fmt.Println(token)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/apk471/go-crud-api/internal/backup"
+	"github.com/apk471/go-crud-api/internal/config"
+)
+
+// restore replaces the database at storage_path with a backup. Stop the API
+// server before running it.
+//
+//	go run ./cmd/restore -config config/local.yaml -from storage/backups/storage-20250102T150405.000Z.db
+//	go run ./cmd/restore -config config/local.yaml -at 2025-01-02T15:00:00Z
+func main() {
+	from := flag.String("from", "", "backup file to restore")
+	at := flag.String("at", "", "restore the newest backup taken at or before this RFC 3339 time")
+
+	cfg := config.MustLoad()
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	src := *from
+	if src == "" {
+		target := time.Now()
+		if *at != "" {
+			t, err := time.Parse(time.RFC3339, *at)
+			if err != nil {
+				log.Fatalf("invalid -at time: %v", err)
+			}
+			target = t
+		}
+
+		info, err := backup.At(cfg.Backup.Dir, target)
+		if err != nil {
+			log.Fatal(err)
+		}
+		src = info.Path
+	}
+
+	slog.Info("restoring database", slog.String("from", src), slog.String("to", cfg.StoragePath))
+
+	previous, err := backup.Restore(src, cfg.StoragePath)
+	if err != nil {
+		log.Fatalf("restore failed: %v", err)
+	}
+
+	if previous != "" {
+		slog.Info("previous database kept", slog.String("path", previous))
+	}
+	slog.Info("restore complete")
+}
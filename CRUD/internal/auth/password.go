@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// bcrypt only looks at the first 72 bytes of a password, anything longer
+	// would silently be truncated so we reject it instead.
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ValidatePassword enforces the password policy for registered users.
+func ValidatePassword(password string) error {
+	var problems []string
+
+	if len(password) < MinPasswordLength {
+		problems = append(problems, fmt.Sprintf("password must be at least %d characters", MinPasswordLength))
+	}
+	if len(password) > MaxPasswordLength {
+		problems = append(problems, fmt.Sprintf("password must be at most %d bytes", MaxPasswordLength))
+	}
+
+	var upper, lower, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+
+	if !upper {
+		problems = append(problems, "password must contain an uppercase letter")
+	}
+	if !lower {
+		problems = append(problems, "password must contain a lowercase letter")
+	}
+	if !digit {
+		problems = append(problems, "password must contain a digit")
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// dummyHash stands in for the hash of an unknown user, so checking a
+// password costs one bcrypt comparison whether or not the account exists.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// CheckPassword compares password with hash. An empty hash, meaning no such
+// user, still goes through bcrypt so the answer takes as long as a wrong
+// password and does not reveal which accounts exist.
+func CheckPassword(hash string, password string) error {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
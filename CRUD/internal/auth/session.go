@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/apk471/go-crud-api/internal/types"
+)
+
+type contextKey string
+
+const userKey contextKey = "user"
+
+// NewToken returns a random session token. Only its hash is persisted so a
+// leaked database does not hand out live sessions.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func WithUser(ctx context.Context, user types.User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+func UserFromContext(ctx context.Context) (types.User, bool) {
+	user, ok := ctx.Value(userKey).(types.User)
+	return user, ok
+}
@@ -0,0 +1,194 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	filePrefix = "storage-"
+	fileSuffix = ".db"
+	timeLayout = "20060102T150405.000Z"
+)
+
+// Snapshotter writes a consistent copy of a live database to path.
+type Snapshotter interface {
+	Snapshot(path string) error
+}
+
+type Info struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
+// Manager takes snapshots into a directory and keeps the newest
+// `retention` of them. A retention of zero keeps everything.
+type Manager struct {
+	source    Snapshotter
+	dir       string
+	retention int
+
+	mu sync.Mutex
+	// last is when the previous backup was taken. Names only carry
+	// milliseconds, so a backup in the same millisecond is moved past it.
+	last time.Time
+}
+
+func NewManager(source Snapshotter, dir string, retention int) *Manager {
+	return &Manager{
+		source:    source,
+		dir:       dir,
+		retention: retention,
+	}
+}
+
+func (m *Manager) Create() (Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return Info{}, err
+	}
+
+	createdAt := time.Now().UTC().Truncate(time.Millisecond)
+	if !createdAt.After(m.last) {
+		createdAt = m.last.Add(time.Millisecond)
+	}
+	name := filePrefix + createdAt.Format(timeLayout) + fileSuffix
+	path := filepath.Join(m.dir, name)
+
+	// Another process may have written the same name, never overwrite or
+	// remove its backup.
+	if _, err := os.Stat(path); err == nil {
+		return Info{}, fmt.Errorf("backup %s already exists", path)
+	} else if !os.IsNotExist(err) {
+		return Info{}, err
+	}
+
+	if err := m.source.Snapshot(path); err != nil {
+		os.Remove(path)
+		return Info{}, fmt.Errorf("snapshot failed: %w", err)
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return Info{}, err
+	}
+	m.last = createdAt
+
+	if err := m.prune(); err != nil {
+		slog.Error("failed to prune old backups", slog.String("error", err.Error()))
+	}
+
+	return Info{Name: name, Path: path, CreatedAt: createdAt, Size: stat.Size()}, nil
+}
+
+func (m *Manager) List() ([]Info, error) {
+	return List(m.dir)
+}
+
+// Run takes a backup every interval until ctx is cancelled.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := m.Create()
+			if err != nil {
+				slog.Error("scheduled backup failed", slog.String("error", err.Error()))
+				continue
+			}
+			slog.Info("scheduled backup created", slog.String("path", info.Path))
+		}
+	}
+}
+
+func (m *Manager) prune() error {
+	if m.retention <= 0 {
+		return nil
+	}
+
+	backups, err := List(m.dir)
+	if err != nil {
+		return err
+	}
+
+	for len(backups) > m.retention {
+		if err := os.Remove(backups[0].Path); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// List returns the backups in dir ordered from oldest to newest.
+func List(dir string) ([]Info, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var backups []Info
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+
+		createdAt, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+
+		stat, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		backups = append(backups, Info{
+			Name:      name,
+			Path:      filepath.Join(dir, name),
+			CreatedAt: createdAt,
+			Size:      stat.Size(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.Before(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// At returns the newest backup taken at or before t.
+func At(dir string, t time.Time) (Info, error) {
+	backups, err := List(dir)
+	if err != nil {
+		return Info{}, err
+	}
+
+	for i := len(backups) - 1; i >= 0; i-- {
+		if !backups[i].CreatedAt.After(t) {
+			return backups[i], nil
+		}
+	}
+
+	return Info{}, fmt.Errorf("no backup in %s taken at or before %s", dir, t.Format(time.RFC3339))
+}
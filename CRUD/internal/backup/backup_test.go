@@ -0,0 +1,87 @@
+package backup_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apk471/go-crud-api/internal/backup"
+)
+
+// fileSnapshotter writes a small file, failing like VACUUM INTO when the
+// file already exists.
+type fileSnapshotter struct{}
+
+func (fileSnapshotter) Snapshot(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString("backup")
+	return err
+}
+
+func TestBackupsInTheSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	manager := backup.NewManager(fileSnapshotter{}, dir, 0)
+
+	names := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		info, err := manager.Create()
+		if err != nil {
+			t.Fatalf("backup %d: %v", i, err)
+		}
+		if names[info.Name] {
+			t.Fatalf("backup %d reuses the name %s", i, info.Name)
+		}
+		names[info.Name] = true
+	}
+
+	backups, err := manager.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(backups) != len(names) {
+		t.Fatalf("listed %d backups, want %d", len(backups), len(names))
+	}
+	for i := 1; i < len(backups); i++ {
+		if !backups[i].CreatedAt.After(backups[i-1].CreatedAt) {
+			t.Fatalf("backups %s and %s are not ordered", backups[i-1].Name, backups[i].Name)
+		}
+	}
+}
+
+func TestBackupNeverOverwritesAFile(t *testing.T) {
+	dir := t.TempDir()
+
+	// Occupy the names of the next second, as another process would
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for ms := 0; ms < 1000; ms++ {
+		name := "storage-" + now.Add(time.Duration(ms)*time.Millisecond).Format("20060102T150405.000Z") + ".db"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("other"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := backup.NewManager(fileSnapshotter{}, dir, 0).Create()
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("backup over an existing file: %v, want an already exists error", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "other" {
+			t.Fatalf("%s was overwritten", entry.Name())
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// CheckIntegrity runs PRAGMA integrity_check against the database at path
+// without modifying it.
+func CheckIntegrity(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return err
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed for %s: %v", path, problems)
+	}
+	return nil
+}
+
+// Restore verifies src and then swaps it in for the database at dst. The
+// current database is kept next to it with a ".pre-restore-<time>" suffix.
+// The server must not be running while this happens.
+func Restore(src string, dst string) (string, error) {
+	if err := CheckIntegrity(src); err != nil {
+		return "", err
+	}
+
+	tmp := dst + ".restore-tmp"
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	if err := CheckIntegrity(tmp); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	var previous string
+	if _, err := os.Stat(dst); err == nil {
+		previous = dst + ".pre-restore-" + time.Now().UTC().Format(timeLayout)
+		if err := os.Rename(dst, previous); err != nil {
+			os.Remove(tmp)
+			return "", err
+		}
+	}
+
+	// Journal files from the old database must not be replayed on top of
+	// the restored one.
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		os.Remove(dst + suffix)
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		return previous, err
+	}
+
+	return previous, nil
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
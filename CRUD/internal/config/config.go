@@ -4,6 +4,7 @@ import (
 	"flag"
 	"log"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -13,10 +14,37 @@ type HttpServer struct{
 	Addr string `yaml:"address" env-default:"localhost:8082" env-required:"true"`
 }
 
+type Auth struct{
+	SessionTTL time.Duration `yaml:"session_ttl" env-default:"24h"`
+}
+
+type Backup struct{
+	Dir string `yaml:"dir" env-default:"storage/backups"`
+	Interval time.Duration `yaml:"interval" env-default:"24h"`
+	Retention int `yaml:"retention" env-default:"7"`
+}
+
+type Tenancy struct{
+	// DefaultTenant is used when a request names no tenant. Leave it empty to
+	// make a tenant mandatory.
+	DefaultTenant string `yaml:"default_tenant" env-default:"default"`
+	Header string `yaml:"header" env-default:"X-Tenant-ID"`
+	// BaseDomain enables subdomain resolution, "acme.<base_domain>" maps to
+	// tenant "acme".
+	BaseDomain string `yaml:"base_domain"`
+	// DefaultMaxUsers caps the users per tenant, 0 means unlimited. MaxUsers
+	// overrides it for individual tenants.
+	DefaultMaxUsers int `yaml:"default_max_users" env-default:"0"`
+	MaxUsers map[string]int `yaml:"max_users"`
+}
+
 type Config struct{
 	Env string `yaml:"env" env-required:"true"`
 	StoragePath string `yaml:"storage_path" env-required:"true"`
 	HttpServer  `yaml:"http_server"`
+	Auth Auth `yaml:"auth"`
+	Backup Backup `yaml:"backup"`
+	Tenancy Tenancy `yaml:"tenancy"`
 }
 
 
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apk471/go-crud-api/internal/auth"
+	"github.com/apk471/go-crud-api/internal/http/middleware"
+	"github.com/apk471/go-crud-api/internal/storage"
+	"github.com/apk471/go-crud-api/internal/types"
+	"github.com/apk471/go-crud-api/internal/utils/response"
+	"github.com/go-playground/validator/v10"
+)
+
+func Register(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		slog.Info("register request", "method", r.Method, "url", r.URL.Path)
+
+		var req types.RegisterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.ValidationError(err.(validator.ValidationErrors)))
+			return
+		}
+
+		if err := auth.ValidatePassword(req.Password); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		hash, err := auth.HashPassword(req.Password)
+		if err != nil {
+			slog.Error("failed to hash password", "error", err)
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		id, err := tenantStorage(store, r).RegisterUser(req.Name, strings.ToLower(req.Email), req.Age, hash)
+		if err != nil {
+			status := statusFor(err)
+			if status == http.StatusInternalServerError {
+				slog.Error("failed to register user", "error", err)
+			}
+			response.WriteJson(w, status, response.GeneralError(err))
+			return
+		}
+
+		slog.Info("user registered", slog.Int64("userId", id))
+		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": id})
+	}
+}
+
+func Login(store storage.Storage, sessionTTL time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.LoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.ValidationError(err.(validator.ValidationErrors)))
+			return
+		}
+
+		store := tenantStorage(store, r)
+
+		user, hash, err := store.GetCredentials(strings.ToLower(req.Email))
+		if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+			slog.Error("failed to load credentials", "error", err)
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		// Unknown emails fall through with an empty hash so both failure
+		// modes return the same error.
+		if err := auth.CheckPassword(hash, req.Password); err != nil {
+			response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(err))
+			return
+		}
+
+		token, err := auth.NewToken()
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		expiresAt := time.Now().Add(sessionTTL)
+		err = store.CreateSession(types.Session{
+			TokenHash: auth.HashToken(token),
+			UserID:    user.ID,
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			slog.Error("failed to create session", "error", err)
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		slog.Info("user logged in", slog.Int64("userId", user.ID))
+		response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"token":      token,
+			"token_type": "Bearer",
+			"expires_at": expiresAt.UTC(),
+		})
+	}
+}
+
+func Logout(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := middleware.BearerToken(r)
+		if token != "" {
+			if err := store.DeleteSession(auth.HashToken(token)); err != nil {
+				response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func Me() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(errors.New("authentication required")))
+			return
+		}
+
+		response.WriteJson(w, http.StatusOK, user)
+	}
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/apk471/go-crud-api/internal/backup"
+	"github.com/apk471/go-crud-api/internal/utils/response"
+)
+
+func CreateBackup(manager *backup.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		info, err := manager.Create()
+		if err != nil {
+			slog.Error("on-demand backup failed", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		slog.Info("on-demand backup created", slog.String("path", info.Path))
+		response.WriteJson(w, http.StatusCreated, info)
+	}
+}
+
+func ListBackups(manager *backup.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		backups, err := manager.List()
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		if backups == nil {
+			backups = []backup.Info{}
+		}
+		response.WriteJson(w, http.StatusOK, backups)
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/apk471/go-crud-api/internal/storage"
+	"github.com/apk471/go-crud-api/internal/types"
+	"github.com/apk471/go-crud-api/internal/utils/response"
+	"github.com/go-playground/validator/v10"
+)
+
+// errBatchAborted is returned from the transaction callback to force a
+// rollback once an operation has failed. The failure itself is recorded in
+// the per-operation results.
+var errBatchAborted = errors.New("batch aborted")
+
+// Batch applies an ordered list of user operations in one transaction. If any
+// operation fails nothing is committed, the failing operation carries its own
+// status and error, and every other operation is reported as 424. It needs
+// a session and only touches users of the caller's tenant.
+func Batch(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.BatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.ValidationError(err.(validator.ValidationErrors)))
+			return
+		}
+
+		results := make([]types.BatchResult, len(req.Operations))
+		for i, op := range req.Operations {
+			results[i] = types.BatchResult{Index: i, Op: op.Op}
+		}
+
+		for i, op := range req.Operations {
+			if err := checkBatchOperation(validate, op); err != nil {
+				results[i].Status = http.StatusBadRequest
+				results[i].Error = err.Error()
+				failBatch(w, results, i, false)
+				return
+			}
+		}
+
+		failed := -1
+		err := tenantStorage(store, r).WithTx(func(tx storage.Storage) error {
+			for i, op := range req.Operations {
+				status, id, err := applyBatchOperation(tx, op)
+				results[i].Status = status
+				results[i].ID = id
+				if err != nil {
+					results[i].Error = err.Error()
+					failed = i
+					return errBatchAborted
+				}
+			}
+			return nil
+		})
+
+		if failed >= 0 {
+			slog.Info("batch rolled back", slog.Int("failedIndex", failed))
+			failBatch(w, results, failed, true)
+			return
+		}
+		if err != nil {
+			slog.Error("batch transaction failed", "error", err)
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		slog.Info("batch committed", slog.Int("operations", len(results)))
+		response.WriteJson(w, http.StatusOK, types.BatchResponse{Committed: true, Results: results})
+	}
+}
+
+func checkBatchOperation(validate *validator.Validate, op types.BatchOperation) error {
+	if op.Op != types.BatchCreate && op.ID <= 0 {
+		return fmt.Errorf("id is required for %s", op.Op)
+	}
+
+	if op.Op == types.BatchDelete {
+		return nil
+	}
+
+	if op.User == nil {
+		return fmt.Errorf("user is required for %s", op.Op)
+	}
+	if err := validate.Struct(op.User); err != nil {
+		return errors.New(response.ValidationError(err.(validator.ValidationErrors)).Error)
+	}
+	return nil
+}
+
+func applyBatchOperation(tx storage.Storage, op types.BatchOperation) (int, int64, error) {
+	var err error
+
+	switch op.Op {
+	case types.BatchCreate:
+		id, err := tx.CreateUser(op.User.Name, op.User.Email, op.User.Age)
+		if err != nil {
+			return statusFor(err), 0, err
+		}
+		return http.StatusCreated, id, nil
+	case types.BatchUpdate:
+		err = tx.UpdateUser(op.ID, op.User.Name, op.User.Email, op.User.Age)
+	case types.BatchDelete:
+		err = tx.DeleteUser(op.ID)
+	}
+
+	if err != nil {
+		return statusFor(err), op.ID, err
+	}
+	return http.StatusOK, op.ID, nil
+}
+
+func failBatch(w http.ResponseWriter, results []types.BatchResult, failed int, executed bool) {
+	for i := range results {
+		if i == failed {
+			continue
+		}
+		results[i].Status = http.StatusFailedDependency
+		if results[i].Op == types.BatchCreate {
+			// The row was never committed, so its id is meaningless.
+			results[i].ID = 0
+		}
+		if executed && i < failed {
+			results[i].Error = "rolled back"
+		} else {
+			results[i].Error = "not executed"
+		}
+	}
+
+	response.WriteJson(w, results[failed].Status, types.BatchResponse{Committed: false, Results: results})
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -9,11 +10,33 @@ import (
 
 	// "github.com/apk471/go-api/internal/types/"
 	"github.com/apk471/go-crud-api/internal/storage"
+	"github.com/apk471/go-crud-api/internal/tenant"
 	"github.com/apk471/go-crud-api/internal/types"
 	"github.com/apk471/go-crud-api/internal/utils/response"
 	"github.com/go-playground/validator/v10"
 )
 
+// tenantStorage scopes store to the tenant that middleware.ResolveTenant put
+// in the request context. Without one the returned storage rejects every
+// user query with storage.ErrNoTenant.
+func tenantStorage(store storage.Storage, r *http.Request) storage.Storage {
+	tenantID, _ := tenant.FromContext(r.Context())
+	return store.ForTenant(tenantID)
+}
+
+// statusFor maps storage errors to the HTTP status handlers answer with.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrEmailTaken):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
 
 func New(storage storage.Storage) http.HandlerFunc{
 	return func (w http.ResponseWriter, r *http.Request) {
@@ -34,19 +57,19 @@ func New(storage storage.Storage) http.HandlerFunc{
 	
 		slog.Info("User validated", "user", user)
 		// response.WriteJson(w, http.StatusCreated, map[string]string{"success" : "Ok"})
-		lastId, err := storage.CreateUser(
+		lastId, err := tenantStorage(storage, r).CreateUser(
 			user.Name,
 			user.Email,
 			user.Age,
 		)
 
-		slog.Info("user created successfully", slog.String("userId", fmt.Sprint(lastId)))
-
 		if err != nil {
-			response.WriteJson(w, http.StatusInternalServerError, err)
+			response.WriteJson(w, statusFor(err), response.GeneralError(err))
 			return
 		}
 
+		slog.Info("user created successfully", slog.String("userId", fmt.Sprint(lastId)))
+
 		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": lastId})
 	}
 }
@@ -63,11 +86,11 @@ func GetById(storage storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		user, err := storage.GetUserById(intId)
+		user, err := tenantStorage(storage, r).GetUserById(intId)
 
 		if err != nil {
 			slog.Error("error getting user", slog.String("id", id))
-			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			response.WriteJson(w, statusFor(err), response.GeneralError(err))
 			return
 		}
 
@@ -79,7 +102,7 @@ func GetList(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		slog.Info("getting all users")
 
-		users, err := storage.GetUser()
+		users, err := tenantStorage(storage, r).GetUser()
 		if err != nil {
 			response.WriteJson(w, http.StatusInternalServerError, err)
 			return
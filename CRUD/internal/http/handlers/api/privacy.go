@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/apk471/go-crud-api/internal/auth"
+	"github.com/apk471/go-crud-api/internal/storage"
+	"github.com/apk471/go-crud-api/internal/utils/response"
+)
+
+// subjectID returns the user a data subject request is about, the {id} path
+// value on admin routes and the caller on /api/users/me routes, along with
+// the caller who makes the request.
+func subjectID(r *http.Request) (int64, int64, error) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return 0, 0, errors.New("authentication required")
+	}
+
+	if id := r.PathValue("id"); id != "" {
+		subject, err := strconv.ParseInt(id, 10, 64)
+		return subject, user.ID, err
+	}
+	return user.ID, user.ID, nil
+}
+
+// ExportUser answers with everything held about the subject as a JSON file
+// download.
+func ExportUser(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, actorID, err := subjectID(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		export, err := tenantStorage(store, r).ExportUser(id, actorID)
+		if err != nil {
+			response.WriteJson(w, statusFor(err), response.GeneralError(err))
+			return
+		}
+
+		slog.Info("user data exported", slog.Int64("userId", id))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-export.json"`, id))
+		response.WriteJson(w, http.StatusOK, export)
+	}
+}
+
+// EraseUser anonymizes the subject's personal data. Repeating the request is
+// safe and returns the same tombstone.
+func EraseUser(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, actorID, err := subjectID(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		erasure, err := tenantStorage(store, r).EraseUser(id, actorID)
+		if err != nil {
+			slog.Error("user erasure failed", slog.Int64("userId", id), slog.String("error", err.Error()))
+			response.WriteJson(w, statusFor(err), response.GeneralError(err))
+			return
+		}
+
+		slog.Info("user data erased", slog.Int64("userId", id))
+		response.WriteJson(w, http.StatusOK, erasure)
+	}
+}
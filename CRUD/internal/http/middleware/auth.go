@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apk471/go-crud-api/internal/auth"
+	"github.com/apk471/go-crud-api/internal/storage"
+	"github.com/apk471/go-crud-api/internal/tenant"
+	"github.com/apk471/go-crud-api/internal/utils/response"
+)
+
+var errUnauthorized = errors.New("authentication required")
+
+// BearerToken extracts the session token from an "Authorization: Bearer"
+// header. It returns an empty string when the header is missing or malformed.
+func BearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// Authenticate resolves the session token to a user and stores it in the
+// request context. Requests without a valid, unexpired session get a 401.
+// It must run after ResolveTenant.
+func Authenticate(storage storage.Storage) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := BearerToken(r)
+			if token == "" {
+				response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(errUnauthorized))
+				return
+			}
+
+			session, err := storage.GetSession(auth.HashToken(token))
+			if err != nil || time.Now().After(session.ExpiresAt) {
+				response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(errUnauthorized))
+				return
+			}
+
+			tenantID, ok := tenant.FromContext(r.Context())
+			if !ok || tenantID != session.TenantID {
+				response.WriteJson(w, http.StatusForbidden, response.GeneralError(errTenantMismatch))
+				return
+			}
+
+			user, err := storage.ForTenant(tenantID).GetUserById(session.UserID)
+			if err != nil {
+				slog.Error("session user lookup failed", slog.Int64("userId", session.UserID), slog.String("error", err.Error()))
+				response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(errUnauthorized))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+// RequireAdmin only lets through users appointed admin with cmd/admin. It
+// must run after Authenticate.
+func RequireAdmin() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := auth.UserFromContext(r.Context())
+			if !ok {
+				response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(errUnauthorized))
+				return
+			}
+
+			if !user.IsAdmin {
+				response.WriteJson(w, http.StatusForbidden, response.GeneralError(errors.New("admin access required")))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireOperator only lets through bearer tokens issued with cmd/operator.
+// Operators belong to no tenant, so it runs without ResolveTenant and
+// Authenticate, and user sessions, admins included, never pass.
+func RequireOperator(store storage.Storage) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := BearerToken(r)
+			if token == "" {
+				response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(errUnauthorized))
+				return
+			}
+
+			name, err := store.GetOperator(auth.HashToken(token))
+			if err != nil {
+				if !errors.Is(err, storage.ErrOperatorNotFound) {
+					slog.Error("operator lookup failed", slog.String("error", err.Error()))
+				}
+				response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(errUnauthorized))
+				return
+			}
+
+			slog.Info("operator request", slog.String("operator", name), slog.String("method", r.Method), slog.String("path", r.URL.Path))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/apk471/go-crud-api/internal/auth"
+	"github.com/apk471/go-crud-api/internal/config"
+	"github.com/apk471/go-crud-api/internal/storage"
+	"github.com/apk471/go-crud-api/internal/tenant"
+	"github.com/apk471/go-crud-api/internal/utils/response"
+)
+
+var errTenantMismatch = errors.New("request names a different tenant than the session")
+
+// ResolveTenant stores the request's tenant in the context. A valid session
+// token wins: its tenant is used, and a header or subdomain naming another
+// tenant is rejected. Without a session the tenant comes from the configured
+// header, then the subdomain, then the default tenant.
+func ResolveTenant(cfg config.Tenancy, store storage.Storage) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fromHeader := r.Header.Get(cfg.Header)
+			fromHost := tenant.FromHost(r.Host, cfg.BaseDomain)
+
+			if fromHeader != "" && fromHost != "" && fromHeader != fromHost {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(errors.New("tenant header does not match the subdomain")))
+				return
+			}
+
+			tenantID := fromHeader
+			if tenantID == "" {
+				tenantID = fromHost
+			}
+
+			// Invalid or expired tokens are ignored here, Authenticate rejects
+			// them on the routes that need a session.
+			if token := BearerToken(r); token != "" {
+				session, err := store.GetSession(auth.HashToken(token))
+				if err == nil && time.Now().Before(session.ExpiresAt) {
+					if tenantID != "" && tenantID != session.TenantID {
+						response.WriteJson(w, http.StatusForbidden, response.GeneralError(errTenantMismatch))
+						return
+					}
+					tenantID = session.TenantID
+				}
+			}
+
+			if tenantID == "" {
+				tenantID = cfg.DefaultTenant
+			}
+			if tenantID == "" {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(tenant.ErrMissing))
+				return
+			}
+			if err := tenant.Validate(tenantID); err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(tenant.WithID(r.Context(), tenantID)))
+		})
+	}
+}
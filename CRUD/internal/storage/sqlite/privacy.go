@@ -0,0 +1,275 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/apk471/go-crud-api/internal/storage"
+	"github.com/apk471/go-crud-api/internal/types"
+)
+
+// ExportUser records the export and reads everything in one transaction, so
+// the export lists itself in its audit trail.
+func (s *Sqlite) ExportUser(id int64, actorID int64) (types.UserExport, error) {
+	tenantID, err := s.tenant()
+	if err != nil {
+		return types.UserExport{}, err
+	}
+
+	var export types.UserExport
+	err = s.withTx(func(tx *Sqlite) error {
+		var err error
+		export, err = tx.exportUser(tenantID, id, actorID)
+		return err
+	})
+	return export, err
+}
+
+func (s *Sqlite) exportUser(tenantID string, id int64, actorID int64) (types.UserExport, error) {
+	export := types.UserExport{
+		ExportedAt: time.Now().UTC(),
+		Sessions:   []types.SessionExport{},
+	}
+
+	var passwordHash sql.NullString
+	var erasedAt sql.NullTime
+
+	err := s.conn().QueryRow(
+		"SELECT id, name, email, age, tenant_id, password_hash, erased_at FROM users WHERE id = ? AND tenant_id = ? LIMIT 1",
+		id, tenantID,
+	).Scan(&export.User.ID, &export.User.Name, &export.User.Email, &export.User.Age, &export.User.TenantID, &passwordHash, &erasedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.UserExport{}, storage.ErrUserNotFound
+		}
+		return types.UserExport{}, fmt.Errorf("query error: %w", err)
+	}
+
+	if err := s.audit(tenantID, id, types.AuditExport, &actorID, export.ExportedAt); err != nil {
+		return types.UserExport{}, err
+	}
+
+	export.HasPassword = passwordHash.Valid
+	if erasedAt.Valid {
+		export.ErasedAt = &erasedAt.Time
+	}
+
+	rows, err := s.conn().Query("SELECT expires_at FROM sessions WHERE user_id = ? AND tenant_id = ?", id, tenantID)
+	if err != nil {
+		return types.UserExport{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session types.SessionExport
+		if err := rows.Scan(&session.ExpiresAt); err != nil {
+			return types.UserExport{}, err
+		}
+		export.Sessions = append(export.Sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return types.UserExport{}, err
+	}
+
+	erasure, err := s.getErasure(tenantID, id)
+	if err != nil && err != sql.ErrNoRows {
+		return types.UserExport{}, err
+	}
+	if err == nil {
+		export.Erasure = &erasure
+	}
+
+	export.Audit, err = s.auditTrail(tenantID, id)
+	if err != nil {
+		return types.UserExport{}, err
+	}
+
+	return export, nil
+}
+
+// EraseUser runs in two steps. The pending tombstone is committed first, so
+// a crash after it leaves a record that ResumeErasures or the next EraseUser
+// call finishes.
+func (s *Sqlite) EraseUser(id int64, actorID int64) (types.Erasure, error) {
+	tenantID, err := s.tenant()
+	if err != nil {
+		return types.Erasure{}, err
+	}
+
+	err = s.withTx(func(tx *Sqlite) error {
+		_, err := tx.getErasure(tenantID, id)
+		if err == nil {
+			return nil
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		var exists bool
+		err = tx.conn().QueryRow(
+			"SELECT EXISTS (SELECT 1 FROM users WHERE id = ? AND tenant_id = ?)",
+			id, tenantID,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return storage.ErrUserNotFound
+		}
+
+		now := time.Now().UTC()
+		_, err = tx.conn().Exec(
+			"INSERT INTO erasures (tenant_id, user_id, status, requested_at) VALUES (?, ?, ?, ?)",
+			tenantID, id, types.ErasurePending, now,
+		)
+		if err != nil {
+			return err
+		}
+		return tx.audit(tenantID, id, types.AuditErasureRequested, &actorID, now)
+	})
+	if err != nil {
+		return types.Erasure{}, err
+	}
+
+	return s.completeErasure(tenantID, id, &actorID)
+}
+
+// ResumeErasures finishes erasures that were interrupted before they
+// completed. It works across all tenants and is meant to run at startup.
+func (s *Sqlite) ResumeErasures() (int, error) {
+	rows, err := s.Db.Query("SELECT tenant_id, user_id FROM erasures WHERE status = ?", types.ErasurePending)
+	if err != nil {
+		return 0, err
+	}
+
+	type pending struct {
+		tenantID string
+		userID   int64
+	}
+	var erasures []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.tenantID, &p.userID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		erasures = append(erasures, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, p := range erasures {
+		if _, err := s.completeErasure(p.tenantID, p.userID, nil); err != nil {
+			return i, err
+		}
+		slog.Info("resumed user erasure", slog.String("tenant", p.tenantID), slog.Int64("userId", p.userID))
+	}
+
+	return len(erasures), nil
+}
+
+// completeErasure removes the user's sessions and overwrites the personal
+// columns in place. The row itself stays so that ids referenced elsewhere
+// keep resolving. actorID is nil when the server resumes the erasure.
+func (s *Sqlite) completeErasure(tenantID string, id int64, actorID *int64) (types.Erasure, error) {
+	var erasure types.Erasure
+
+	err := s.withTx(func(tx *Sqlite) error {
+		var err error
+		erasure, err = tx.getErasure(tenantID, id)
+		if err != nil {
+			return err
+		}
+		if erasure.Status == types.ErasureCompleted {
+			return nil
+		}
+
+		now := time.Now().UTC()
+
+		if _, err := tx.conn().Exec("DELETE FROM sessions WHERE user_id = ?", id); err != nil {
+			return err
+		}
+
+		_, err = tx.conn().Exec(
+			`UPDATE users SET name = ?, email = ?, age = 0, password_hash = NULL, is_admin = 0, erased_at = ?
+			WHERE id = ? AND tenant_id = ?`,
+			"erased user", fmt.Sprintf("erased-%d@erased.invalid", id), now, id, tenantID,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.conn().Exec(
+			"UPDATE erasures SET status = ?, completed_at = ? WHERE tenant_id = ? AND user_id = ?",
+			types.ErasureCompleted, now, tenantID, id,
+		)
+		if err != nil {
+			return err
+		}
+		if err := tx.audit(tenantID, id, types.AuditErasureCompleted, actorID, now); err != nil {
+			return err
+		}
+
+		erasure.Status = types.ErasureCompleted
+		erasure.CompletedAt = &now
+		return nil
+	})
+
+	return erasure, err
+}
+
+func (s *Sqlite) getErasure(tenantID string, id int64) (types.Erasure, error) {
+	var erasure types.Erasure
+	var completedAt sql.NullTime
+
+	err := s.conn().QueryRow(
+		"SELECT tenant_id, user_id, status, requested_at, completed_at FROM erasures WHERE tenant_id = ? AND user_id = ?",
+		tenantID, id,
+	).Scan(&erasure.TenantID, &erasure.UserID, &erasure.Status, &erasure.RequestedAt, &completedAt)
+	if err != nil {
+		return types.Erasure{}, err
+	}
+
+	if completedAt.Valid {
+		erasure.CompletedAt = &completedAt.Time
+	}
+	return erasure, nil
+}
+
+func (s *Sqlite) audit(tenantID string, id int64, action string, actorID *int64, at time.Time) error {
+	_, err := s.conn().Exec(
+		"INSERT INTO audit_log (tenant_id, user_id, action, actor_id, at) VALUES (?, ?, ?, ?, ?)",
+		tenantID, id, action, actorID, at,
+	)
+	return err
+}
+
+// auditTrail lists the user's audit entries, oldest first.
+func (s *Sqlite) auditTrail(tenantID string, id int64) ([]types.AuditEntry, error) {
+	rows, err := s.conn().Query(
+		"SELECT action, actor_id, at FROM audit_log WHERE tenant_id = ? AND user_id = ? ORDER BY id",
+		tenantID, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []types.AuditEntry{}
+	for rows.Next() {
+		var entry types.AuditEntry
+		var actorID sql.NullInt64
+		if err := rows.Scan(&entry.Action, &actorID, &entry.At); err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			entry.ActorID = &actorID.Int64
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
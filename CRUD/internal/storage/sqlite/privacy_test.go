@@ -0,0 +1,54 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/apk471/go-crud-api/internal/types"
+)
+
+func TestAuditTrail(t *testing.T) {
+	store := newStorage(t).ForTenant("tenant-a")
+
+	id, err := store.RegisterUser("Alice", "alice@example.com", 30, "hash")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	admin, err := store.RegisterUser("Ops", "ops@example.com", 30, "hash")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	export, err := store.ExportUser(id, id)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if len(export.Audit) != 1 || export.Audit[0].Action != types.AuditExport {
+		t.Fatalf("audit of the first export = %+v, want the export itself", export.Audit)
+	}
+
+	if _, err := store.EraseUser(id, admin); err != nil {
+		t.Fatalf("erase: %v", err)
+	}
+	export, err = store.ExportUser(id, admin)
+	if err != nil {
+		t.Fatalf("export after erasure: %v", err)
+	}
+
+	want := []struct {
+		action  string
+		actorID int64
+	}{
+		{types.AuditExport, id},
+		{types.AuditErasureRequested, admin},
+		{types.AuditErasureCompleted, admin},
+		{types.AuditExport, admin},
+	}
+	if len(export.Audit) != len(want) {
+		t.Fatalf("audit = %+v, want %d entries", export.Audit, len(want))
+	}
+	for i, entry := range export.Audit {
+		if entry.Action != want[i].action || entry.ActorID == nil || *entry.ActorID != want[i].actorID {
+			t.Fatalf("audit entry %d = %+v, want %s by %d", i, entry, want[i].action, want[i].actorID)
+		}
+	}
+}
@@ -2,16 +2,43 @@ package sqlite
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/apk471/go-crud-api/internal/storage"
 	"github.com/apk471/go-crud-api/internal/types"
 
 	"github.com/apk471/go-crud-api/internal/config"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 type Sqlite struct {
 	Db *sql.DB
+
+	// tx is set on the copies handed to WithTx callbacks so that every
+	// query they run joins the same transaction.
+	tx *sql.Tx
+
+	// tenantID is set by ForTenant. User queries on an unscoped Sqlite fail
+	// with storage.ErrNoTenant.
+	tenantID string
+	quotas   config.Tenancy
+}
+
+// querier is the subset of *sql.DB and *sql.Tx used by the storage methods.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Prepare(query string) (*sql.Stmt, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func (s *Sqlite) conn() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.Db
 }
 
 func New(cfg *config.Config) (*Sqlite, error) {
@@ -31,34 +58,87 @@ func New(cfg *config.Config) (*Sqlite, error) {
 		return nil, err
 	}
 
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+
 	return &Sqlite{
-		Db: db,
+		Db:     db,
+		quotas: cfg.Tenancy,
 	}, nil
 }
 
-func (s *Sqlite) CreateUser(name string, email string, age int) (int64, error) {
+func (s *Sqlite) ForTenant(tenantID string) storage.Storage {
+	scoped := *s
+	scoped.tenantID = tenantID
+	return &scoped
+}
 
-	stmt, err := s.Db.Prepare("INSERT INTO users (name, email, age) VALUES (?, ?, ?)")
+func (s *Sqlite) tenant() (string, error) {
+	if s.tenantID == "" {
+		return "", storage.ErrNoTenant
+	}
+	return s.tenantID, nil
+}
+
+// normalizeEmail is applied to every email written or looked up, so
+// uniqueness and login do not depend on how a client cased or padded it.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (s *Sqlite) maxUsers() int {
+	if limit, ok := s.quotas.MaxUsers[s.tenantID]; ok {
+		return limit
+	}
+	return s.quotas.DefaultMaxUsers
+}
+
+// insertUser adds a user to the current tenant unless that would exceed the
+// tenant's quota. The count and the insert run as one statement so
+// concurrent requests cannot both slip under the limit.
+func (s *Sqlite) insertUser(name string, email string, age int, passwordHash *string) (int64, error) {
+	tenantID, err := s.tenant()
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
 
-	result, err := stmt.Exec(name, email, age)
+	limit := s.maxUsers()
+	result, err := s.conn().Exec(`INSERT INTO users (tenant_id, name, email, age, password_hash)
+	SELECT ?, ?, ?, ?, ?
+	WHERE ? <= 0 OR (SELECT COUNT(*) FROM users WHERE tenant_id = ? AND erased_at IS NULL) < ?`,
+		tenantID, name, normalizeEmail(email), age, passwordHash,
+		limit, tenantID, limit,
+	)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, storage.ErrEmailTaken
+		}
 		return 0, err
 	}
 
-	lastId, err := result.LastInsertId()
+	affected, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
+	if affected == 0 {
+		return 0, storage.ErrQuotaExceeded
+	}
 
-	return lastId, nil
+	return result.LastInsertId()
+}
+
+func (s *Sqlite) CreateUser(name string, email string, age int) (int64, error) {
+	return s.insertUser(name, email, age, nil)
 }
 
 func (s *Sqlite) GetUserById(id int64) (types.User, error) {
-	stmt, err := s.Db.Prepare("SELECT id, name, email, age FROM students WHERE id = ? LIMIT 1")
+	tenantID, err := s.tenant()
+	if err != nil {
+		return types.User{}, err
+	}
+
+	stmt, err := s.conn().Prepare("SELECT id, name, email, age, tenant_id, is_admin FROM users WHERE id = ? AND tenant_id = ? AND erased_at IS NULL LIMIT 1")
 	if err != nil {
 		return types.User{}, err
 	}
@@ -67,10 +147,10 @@ func (s *Sqlite) GetUserById(id int64) (types.User, error) {
 
 	var user types.User
 
-	err = stmt.QueryRow(id).Scan(&user.ID, &user.Name, &user.Email, &user.Age)
+	err = stmt.QueryRow(id, tenantID).Scan(&user.ID, &user.Name, &user.Email, &user.Age, &user.TenantID, &user.IsAdmin)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return types.User{}, fmt.Errorf("no user found with id %s", fmt.Sprint(id))
+			return types.User{}, storage.ErrUserNotFound
 		}
 		return types.User{}, fmt.Errorf("query error: %w", err)
 	}
@@ -79,14 +159,19 @@ func (s *Sqlite) GetUserById(id int64) (types.User, error) {
 }
 
 func (s *Sqlite) GetUser() ([]types.User, error) {
-	stmt, err := s.Db.Prepare("SELECT id, name, email, age FROM users")
+	tenantID, err := s.tenant()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := s.conn().Prepare("SELECT id, name, email, age, tenant_id, is_admin FROM users WHERE tenant_id = ? AND erased_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
 
 	defer stmt.Close()
 
-	rows, err := stmt.Query()
+	rows, err := stmt.Query(tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +183,7 @@ func (s *Sqlite) GetUser() ([]types.User, error) {
 	for rows.Next() {
 		var user types.User
 
-		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Age)
+		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Age, &user.TenantID, &user.IsAdmin)
 		if err != nil {
 			return nil, err
 		}
@@ -107,4 +192,344 @@ func (s *Sqlite) GetUser() ([]types.User, error) {
 	}
 
 	return users, nil
-}
\ No newline at end of file
+}
+
+// migrate brings databases created by older versions up to the current
+// schema. Every step has to be safe to run on each startup.
+func migrate(db *sql.DB) error {
+	if err := addColumnIfMissing(db, "users", "password_hash", "TEXT"); err != nil {
+		return err
+	}
+
+	// Rows written before multi-tenancy belong to the "default" tenant.
+	if err := addColumnIfMissing(db, "users", "tenant_id", "TEXT NOT NULL DEFAULT 'default'"); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_users_tenant ON users (tenant_id)`)
+	if err != nil {
+		return err
+	}
+
+	// Only registered users need a unique email, rows created through
+	// POST /api/users before registration existed may share one. Emails are
+	// unique per tenant, not globally.
+	_, err = db.Exec(`DROP INDEX IF EXISTS idx_users_email_registered`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_email_registered
+	ON users (tenant_id, email) WHERE password_hash IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS sessions (
+	token_hash TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id),
+	expires_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "sessions", "tenant_id", "TEXT NOT NULL DEFAULT 'default'"); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "users", "erased_at", "DATETIME"); err != nil {
+		return err
+	}
+
+	// Admin rights belong to one user of one tenant and are granted out of
+	// band, never derived from a self-registered email.
+	if err := addColumnIfMissing(db, "users", "is_admin", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Operators take backups of every tenant and are not users of any.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS operators (
+	name TEXT PRIMARY KEY,
+	token_hash TEXT NOT NULL UNIQUE
+	)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS erasures (
+	tenant_id TEXT NOT NULL,
+	user_id INTEGER NOT NULL REFERENCES users (id),
+	status TEXT NOT NULL,
+	requested_at DATETIME NOT NULL,
+	completed_at DATETIME,
+	PRIMARY KEY (tenant_id, user_id)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// The audit trail of data subject requests. actor_id is NULL for
+	// erasures the server resumed on its own.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	tenant_id TEXT NOT NULL,
+	user_id INTEGER NOT NULL REFERENCES users (id),
+	action TEXT NOT NULL,
+	actor_id INTEGER,
+	at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_audit_log_user ON audit_log (tenant_id, user_id)`)
+	return err
+}
+
+func addColumnIfMissing(db *sql.DB, table string, column string, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+func (s *Sqlite) RegisterUser(name string, email string, age int, passwordHash string) (int64, error) {
+	return s.insertUser(name, email, age, &passwordHash)
+}
+
+func (s *Sqlite) GetCredentials(email string) (types.User, string, error) {
+	tenantID, err := s.tenant()
+	if err != nil {
+		return types.User{}, "", err
+	}
+
+	stmt, err := s.conn().Prepare("SELECT id, name, email, age, tenant_id, password_hash FROM users WHERE email = ? AND tenant_id = ? AND password_hash IS NOT NULL LIMIT 1")
+	if err != nil {
+		return types.User{}, "", err
+	}
+	defer stmt.Close()
+
+	var user types.User
+	var passwordHash string
+
+	err = stmt.QueryRow(normalizeEmail(email), tenantID).Scan(&user.ID, &user.Name, &user.Email, &user.Age, &user.TenantID, &passwordHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.User{}, "", storage.ErrUserNotFound
+		}
+		return types.User{}, "", fmt.Errorf("query error: %w", err)
+	}
+
+	return user, passwordHash, nil
+}
+
+// CreateSession always records the storage's own tenant so a session can
+// never grant access to another tenant.
+func (s *Sqlite) CreateSession(session types.Session) error {
+	tenantID, err := s.tenant()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn().Exec(
+		"INSERT INTO sessions (token_hash, user_id, tenant_id, expires_at) VALUES (?, ?, ?, ?)",
+		session.TokenHash, session.UserID, tenantID, session.ExpiresAt.UTC(),
+	)
+	return err
+}
+
+func (s *Sqlite) GetSession(tokenHash string) (types.Session, error) {
+	var session types.Session
+
+	err := s.conn().QueryRow(
+		"SELECT token_hash, user_id, tenant_id, expires_at FROM sessions WHERE token_hash = ? LIMIT 1",
+		tokenHash,
+	).Scan(&session.TokenHash, &session.UserID, &session.TenantID, &session.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Session{}, storage.ErrSessionNotFound
+		}
+		return types.Session{}, fmt.Errorf("query error: %w", err)
+	}
+
+	return session, nil
+}
+
+func (s *Sqlite) DeleteSession(tokenHash string) error {
+	_, err := s.conn().Exec("DELETE FROM sessions WHERE token_hash = ?", tokenHash)
+	return err
+}
+
+func (s *Sqlite) SetAdmin(id int64, admin bool) error {
+	tenantID, err := s.tenant()
+	if err != nil {
+		return err
+	}
+
+	result, err := s.conn().Exec("UPDATE users SET is_admin = ? WHERE id = ? AND tenant_id = ? AND erased_at IS NULL", admin, id, tenantID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *Sqlite) SetOperator(name string, tokenHash string) error {
+	_, err := s.conn().Exec(
+		"INSERT INTO operators (name, token_hash) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET token_hash = excluded.token_hash",
+		name, tokenHash,
+	)
+	return err
+}
+
+func (s *Sqlite) GetOperator(tokenHash string) (string, error) {
+	var name string
+
+	err := s.conn().QueryRow("SELECT name FROM operators WHERE token_hash = ? LIMIT 1", tokenHash).Scan(&name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", storage.ErrOperatorNotFound
+		}
+		return "", fmt.Errorf("query error: %w", err)
+	}
+
+	return name, nil
+}
+
+func (s *Sqlite) DeleteOperator(name string) error {
+	result, err := s.conn().Exec("DELETE FROM operators WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrOperatorNotFound
+	}
+	return nil
+}
+
+// Snapshot writes a consistent copy of the live database to path using
+// VACUUM INTO, which is safe to run while other connections are writing.
+func (s *Sqlite) Snapshot(path string) error {
+	_, err := s.Db.Exec("VACUUM INTO ?", path)
+	return err
+}
+
+// WithTx runs fn in a single transaction. It commits when fn returns nil and
+// rolls back otherwise. Calls made on an already transactional Sqlite reuse
+// the outer transaction.
+func (s *Sqlite) WithTx(fn func(tx storage.Storage) error) error {
+	return s.withTx(func(tx *Sqlite) error {
+		return fn(tx)
+	})
+}
+
+func (s *Sqlite) withTx(fn func(tx *Sqlite) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	scoped := *s
+	scoped.tx = tx
+	if err := fn(&scoped); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Sqlite) UpdateUser(id int64, name string, email string, age int) error {
+	tenantID, err := s.tenant()
+	if err != nil {
+		return err
+	}
+
+	result, err := s.conn().Exec("UPDATE users SET name = ?, email = ?, age = ? WHERE id = ? AND tenant_id = ? AND erased_at IS NULL", name, normalizeEmail(email), age, id, tenantID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrEmailTaken
+		}
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *Sqlite) DeleteUser(id int64) error {
+	tenantID, err := s.tenant()
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *Sqlite) error {
+		result, err := tx.conn().Exec("DELETE FROM users WHERE id = ? AND tenant_id = ?", id, tenantID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return storage.ErrUserNotFound
+		}
+
+		_, err = tx.conn().Exec("DELETE FROM sessions WHERE user_id = ?", id)
+		return err
+	})
+}
@@ -0,0 +1,104 @@
+package sqlite_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/apk471/go-crud-api/internal/config"
+	"github.com/apk471/go-crud-api/internal/storage"
+	"github.com/apk471/go-crud-api/internal/storage/sqlite"
+)
+
+func newStorage(t *testing.T) *sqlite.Sqlite {
+	t.Helper()
+
+	store, err := sqlite.New(&config.Config{StoragePath: filepath.Join(t.TempDir(), "storage.db")})
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	t.Cleanup(func() { store.Db.Close() })
+	return store
+}
+
+func TestTenantIsolation(t *testing.T) {
+	store := newStorage(t)
+	tenantA := store.ForTenant("tenant-a")
+	tenantB := store.ForTenant("tenant-b")
+
+	id, err := tenantA.RegisterUser("Alice", "alice@example.com", 30, "hash")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	if _, err := tenantB.GetUserById(id); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Fatalf("tenant B reads tenant A's user: err = %v, want ErrUserNotFound", err)
+	}
+	users, err := tenantB.GetUser()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(users) != 0 {
+		t.Fatalf("tenant B lists %v, want none", users)
+	}
+	if _, _, err := tenantB.GetCredentials("alice@example.com"); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Fatalf("tenant B loads tenant A's credentials: err = %v, want ErrUserNotFound", err)
+	}
+	if err := tenantB.UpdateUser(id, "Mallory", "mallory@example.com", 40); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Fatalf("tenant B updates tenant A's user: err = %v, want ErrUserNotFound", err)
+	}
+	if err := tenantB.DeleteUser(id); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Fatalf("tenant B deletes tenant A's user: err = %v, want ErrUserNotFound", err)
+	}
+	if _, err := tenantB.ExportUser(id, id); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Fatalf("tenant B exports tenant A's user: err = %v, want ErrUserNotFound", err)
+	}
+	if _, err := tenantB.EraseUser(id, id); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Fatalf("tenant B erases tenant A's user: err = %v, want ErrUserNotFound", err)
+	}
+	if err := tenantB.SetAdmin(id, true); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Fatalf("tenant B makes tenant A's user admin: err = %v, want ErrUserNotFound", err)
+	}
+
+	user, err := tenantA.GetUserById(id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if user.Name != "Alice" || user.Email != "alice@example.com" || user.IsAdmin {
+		t.Fatalf("tenant A's user = %+v, want it untouched", user)
+	}
+}
+
+func TestUnscopedStorage(t *testing.T) {
+	store := newStorage(t)
+
+	if _, err := store.GetUser(); !errors.Is(err, storage.ErrNoTenant) {
+		t.Fatalf("unscoped list: err = %v, want ErrNoTenant", err)
+	}
+	if _, err := store.CreateUser("Alice", "alice@example.com", 30); !errors.Is(err, storage.ErrNoTenant) {
+		t.Fatalf("unscoped create: err = %v, want ErrNoTenant", err)
+	}
+}
+
+func TestEmailsAreNormalized(t *testing.T) {
+	store := newStorage(t).ForTenant("tenant-a")
+
+	id, err := store.RegisterUser("Alice", "alice@example.com", 30, "hash")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	other, err := store.RegisterUser("Bob", "bob@example.com", 30, "hash")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	if err := store.UpdateUser(id, "Alice", "  Alice.New@Example.COM ", 31); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, _, err := store.GetCredentials("alice.new@example.com"); err != nil {
+		t.Fatalf("credentials after a mixed-case update: %v", err)
+	}
+	if err := store.UpdateUser(other, "Bob", "ALICE.NEW@example.com", 30); !errors.Is(err, storage.ErrEmailTaken) {
+		t.Fatalf("update to a taken email in another case: err = %v, want ErrEmailTaken", err)
+	}
+}
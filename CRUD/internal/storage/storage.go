@@ -1,9 +1,62 @@
 package storage
 
-import "github.com/apk471/go-crud-api/internal/types"
+import (
+	"errors"
 
+	"github.com/apk471/go-crud-api/internal/types"
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrEmailTaken       = errors.New("email is already registered")
+	ErrSessionNotFound  = errors.New("session not found")
+	ErrNoTenant         = errors.New("storage is not scoped to a tenant")
+	ErrQuotaExceeded    = errors.New("user quota exceeded for tenant")
+	ErrOperatorNotFound = errors.New("operator not found")
+)
+
+// Storage is scoped to a single tenant. The value returned by the backend
+// constructor is unscoped and refuses user queries with ErrNoTenant until
+// ForTenant is called, so a handler cannot read across tenants by accident.
+// Sessions are looked up by token hash and are not tenant scoped, and neither
+// are operators.
 type Storage interface{
+	ForTenant(tenantID string) Storage
+
 	CreateUser(name string, email string, age int) (int64, error)
 	GetUserById(id int64) (types.User, error)
 	GetUser() ([]types.User, error)
-}
\ No newline at end of file
+	UpdateUser(id int64, name string, email string, age int) error
+	DeleteUser(id int64) error
+
+	// WithTx runs fn against a Storage bound to one transaction. Everything
+	// fn does is committed if it returns nil and rolled back otherwise.
+	WithTx(fn func(tx Storage) error) error
+
+	RegisterUser(name string, email string, age int, passwordHash string) (int64, error)
+	GetCredentials(email string) (types.User, string, error)
+	CreateSession(session types.Session) error
+	GetSession(tokenHash string) (types.Session, error)
+	DeleteSession(tokenHash string) error
+
+	// SetAdmin grants or revokes admin rights. Nothing in the API calls it,
+	// admins are appointed with cmd/admin.
+	SetAdmin(id int64, admin bool) error
+
+	// Operators back up the whole database, so they belong to no tenant and
+	// are not users. Nothing in the API creates them, they are issued with
+	// cmd/operator. SetOperator replaces the operator's previous token.
+	SetOperator(name string, tokenHash string) error
+	GetOperator(tokenHash string) (string, error)
+	DeleteOperator(name string) error
+
+	// ExportUser collects every record held about a user, including users
+	// that have already been erased. The export is recorded in the user's
+	// audit trail as made by actorID, and listed in the export itself.
+	ExportUser(id int64, actorID int64) (types.UserExport, error)
+	// EraseUser anonymizes a user's personal data and leaves a tombstone.
+	// Calling it again returns the existing tombstone, and an erasure that
+	// was interrupted is finished by the next call. The request and its
+	// completion are recorded in the user's audit trail.
+	EraseUser(id int64, actorID int64) (types.Erasure, error)
+}
@@ -0,0 +1,57 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+)
+
+type contextKey string
+
+const tenantKey contextKey = "tenant"
+
+var (
+	ErrMissing = errors.New("tenant could not be resolved")
+	ErrInvalid = errors.New("tenant id must be 1-63 lowercase letters, digits or dashes")
+)
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+func Validate(id string) error {
+	if !idPattern.MatchString(id) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey, id)
+}
+
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey).(string)
+	return id, ok && id != ""
+}
+
+// FromHost returns the left-most label of host when it is a subdomain of
+// baseDomain, e.g. "acme" for "acme.example.com".
+func FromHost(host string, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.ToLower(host)
+	suffix := "." + strings.ToLower(strings.TrimPrefix(baseDomain, "."))
+
+	sub, ok := strings.CutSuffix(host, suffix)
+	if !ok || sub == "" || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
@@ -1,8 +1,113 @@
 package types
 
+import "time"
+
 type User struct {
 	ID int64 `json:"id" validate:"required"`
 	Name string `json:"name" validate:"required,min=2,max=100"`
 	Email string `json:"email" validate:"required"`
 	Age int `json:"age" validate:"required,min=18,max=100"`
-}
\ No newline at end of file
+	TenantID string `json:"tenant_id,omitempty"`
+	// IsAdmin is only ever set out of band with cmd/admin, never through
+	// the API.
+	IsAdmin bool `json:"is_admin,omitempty"`
+}
+
+type RegisterRequest struct {
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email"`
+	Age      int    `json:"age" validate:"required,min=18,max=100"`
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type Session struct {
+	TokenHash string
+	UserID    int64
+	TenantID  string
+	ExpiresAt time.Time
+}
+
+type UserInput struct {
+	Name  string `json:"name" validate:"required,min=2,max=100"`
+	Email string `json:"email" validate:"required,email"`
+	Age   int    `json:"age" validate:"required,min=18,max=100"`
+}
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+type BatchOperation struct {
+	Op   string     `json:"op" validate:"required,oneof=create update delete"`
+	ID   int64      `json:"id,omitempty"`
+	User *UserInput `json:"user,omitempty"`
+}
+
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Status int    `json:"status"`
+	ID     int64  `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
+
+const (
+	ErasurePending   = "pending"
+	ErasureCompleted = "completed"
+)
+
+// Erasure is the tombstone left behind when a user's personal data is
+// erased. It holds no personal data itself.
+type Erasure struct {
+	UserID      int64      `json:"user_id"`
+	TenantID    string     `json:"tenant_id"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+const (
+	AuditExport           = "export"
+	AuditErasureRequested = "erasure_requested"
+	AuditErasureCompleted = "erasure_completed"
+)
+
+// AuditEntry records one data subject request about a user. ActorID is the
+// user who made it, nil when the server finished an interrupted erasure on
+// its own. Entries hold no personal data and outlive an erasure.
+type AuditEntry struct {
+	Action  string    `json:"action"`
+	ActorID *int64    `json:"actor_id,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+type SessionExport struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UserExport is everything the store holds about one user.
+type UserExport struct {
+	ExportedAt  time.Time       `json:"exported_at"`
+	User        User            `json:"user"`
+	HasPassword bool            `json:"has_password"`
+	ErasedAt    *time.Time      `json:"erased_at,omitempty"`
+	Sessions    []SessionExport `json:"sessions"`
+	Erasure     *Erasure        `json:"erasure,omitempty"`
+	Audit       []AuditEntry    `json:"audit"`
+}
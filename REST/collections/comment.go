@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Comment is a message in a task's discussion. UpdatedAt differs from
+// CreatedAt once the comment has been edited.
+type Comment struct {
+	ID        string    `bson:"_id,omitempty" json:"id"`
+	TaskID    string    `bson:"taskId" json:"taskId"`
+	ProjectID string    `bson:"projectId" json:"projectId"`
+	Author    string    `bson:"author" json:"author"`
+	Body      string    `bson:"body" json:"body"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
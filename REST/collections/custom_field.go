@@ -0,0 +1,137 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// CustomField defines a task field of a project. Tasks keep the values in
+// customFields under Key. Enum values are one of Options.
+type CustomField struct {
+	Key     string   `bson:"key" json:"key"`
+	Name    string   `bson:"name" json:"name"`
+	Type    string   `bson:"type" json:"type"`
+	Options []string `bson:"options,omitempty" json:"options,omitempty"`
+}
+
+// Custom field types. Numbers are stored as doubles, dates as YYYY-MM-DD
+// strings, which sort in calendar order.
+const (
+	CustomFieldNumber = "number"
+	CustomFieldText   = "text"
+	CustomFieldEnum   = "enum"
+	CustomFieldDate   = "date"
+)
+
+// customFieldKey keeps keys usable as parts of MongoDB field paths and
+// query parameters.
+var customFieldKey = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,63}$`)
+
+// IsValidCustomFieldKey tells whether key can name a custom field.
+func IsValidCustomFieldKey(key string) bool {
+	return customFieldKey.MatchString(key)
+}
+
+// ValidateCustomFields checks a project's custom field definitions.
+func ValidateCustomFields(fields []CustomField) error {
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if !IsValidCustomFieldKey(field.Key) {
+			return fmt.Errorf("invalid custom field key %q", field.Key)
+		}
+		if seen[field.Key] {
+			return fmt.Errorf("custom field %q is defined twice", field.Key)
+		}
+		seen[field.Key] = true
+
+		if field.Name == "" {
+			return fmt.Errorf("custom field %q needs a name", field.Key)
+		}
+
+		switch field.Type {
+		case CustomFieldNumber, CustomFieldText, CustomFieldDate:
+			if len(field.Options) > 0 {
+				return fmt.Errorf("only enum fields have options, %q is a %s field", field.Key, field.Type)
+			}
+		case CustomFieldEnum:
+			if len(field.Options) == 0 {
+				return fmt.Errorf("enum field %q needs options", field.Key)
+			}
+			for i, option := range field.Options {
+				if option == "" || slices.Contains(field.Options[:i], option) {
+					return fmt.Errorf("enum field %q has an empty or repeated option", field.Key)
+				}
+			}
+		default:
+			return fmt.Errorf("custom field %q has unknown type %q", field.Key, field.Type)
+		}
+	}
+
+	return nil
+}
+
+var errCustomFieldValue = errors.New("invalid custom field value")
+
+// Value checks a value of the field as decoded from JSON and returns it
+// the way it is stored.
+func (f CustomField) Value(raw interface{}) (interface{}, error) {
+	switch f.Type {
+	case CustomFieldNumber:
+		if n, ok := raw.(float64); ok {
+			return n, nil
+		}
+	case CustomFieldText:
+		if s, ok := raw.(string); ok && s != "" {
+			return s, nil
+		}
+	case CustomFieldEnum:
+		if s, ok := raw.(string); ok && slices.Contains(f.Options, s) {
+			return s, nil
+		}
+	case CustomFieldDate:
+		if s, ok := raw.(string); ok {
+			if _, err := time.Parse(time.DateOnly, s); err == nil {
+				return s, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w for %s", errCustomFieldValue, f.Key)
+}
+
+// FindCustomField returns the field with key, and false when there is none.
+func FindCustomField(fields []CustomField, key string) (CustomField, bool) {
+	for _, field := range fields {
+		if field.Key == key {
+			return field, true
+		}
+	}
+	return CustomField{}, false
+}
+
+// CustomFieldValues checks a task's custom field values against the
+// definitions and returns them as stored. Every key must be defined. An
+// empty result comes back as nil, so the task has no customFields at all.
+func CustomFieldValues(fields []CustomField, raw map[string]interface{}) (map[string]interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]interface{}, len(raw))
+	for key, value := range raw {
+		field, ok := FindCustomField(fields, key)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %s", errCustomFieldValue, key)
+		}
+		value, err := field.Value(value)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Dependency says that the task BlockerID blocks the task TaskID: TaskID
+// cannot be completed before BlockerID is. Both tasks belong to projects of
+// OrganizationID.
+type Dependency struct {
+	ID               string    `bson:"_id" json:"-"`
+	OrganizationID   string    `bson:"organizationId" json:"organizationId"`
+	TaskID           string    `bson:"taskId" json:"taskId"`
+	ProjectID        string    `bson:"projectId" json:"projectId"`
+	BlockerID        string    `bson:"blockerId" json:"blockerId"`
+	BlockerProjectID string    `bson:"blockerProjectId" json:"blockerProjectId"`
+	CreatedBy        string    `bson:"createdBy" json:"createdBy"`
+	CreatedAt        time.Time `bson:"createdAt" json:"createdAt"`
+}
+
+// DependencyID names the dependency of taskID on blockerID, so there is
+// only ever one.
+func DependencyID(taskID string, blockerID string) string {
+	return taskID + ":" + blockerID
+}
+
+// DependencyGraph maps each task to the tasks it blocks.
+type DependencyGraph map[string][]string
+
+func NewDependencyGraph(dependencies []Dependency) DependencyGraph {
+	g := DependencyGraph{}
+	for _, d := range dependencies {
+		g[d.BlockerID] = append(g[d.BlockerID], d.TaskID)
+	}
+	return g
+}
+
+// Reaches reports whether from blocks to, directly or through other tasks.
+func (g DependencyGraph) Reaches(from string, to string) bool {
+	seen := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		task := queue[0]
+		queue = queue[1:]
+		for _, next := range g[task] {
+			if next == to {
+				return true
+			}
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// CreatesCycle reports whether making blockerID block taskID would close a
+// cycle, which no order of completing the tasks could satisfy.
+func (g DependencyGraph) CreatesCycle(taskID string, blockerID string) bool {
+	return taskID == blockerID || g.Reaches(taskID, blockerID)
+}
+
+// CriticalPath returns the chain of tasks, each blocking the next, with
+// the greatest total weight, and that weight. Only tasks in weights take
+// part, dependencies on others are ignored. Ties go to the chain that
+// starts with the smaller ID.
+func (g DependencyGraph) CriticalPath(weights map[string]float64) ([]string, float64) {
+	ids := make([]string, 0, len(weights))
+	for id := range weights {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	// Kahn's algorithm orders the tasks so that blockers come first
+	blockers := map[string]int{}
+	for _, id := range ids {
+		for _, next := range g[id] {
+			if _, ok := weights[next]; ok {
+				blockers[next]++
+			}
+		}
+	}
+	order := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if blockers[id] == 0 {
+			order = append(order, id)
+		}
+	}
+	for i := 0; i < len(order); i++ {
+		for _, next := range g[order[i]] {
+			if _, ok := weights[next]; !ok {
+				continue
+			}
+			if blockers[next]--; blockers[next] == 0 {
+				order = append(order, next)
+			}
+		}
+	}
+
+	// Walking back from the end, every task learns the heaviest chain
+	// starting at it from the tasks it blocks
+	total := map[string]float64{}
+	next := map[string]string{}
+	for i := len(order) - 1; i >= 0; i-- {
+		id := order[i]
+		for _, n := range g[id] {
+			if _, ok := weights[n]; !ok {
+				continue
+			}
+			if best := next[id]; best == "" || total[n] > total[best] || (total[n] == total[best] && n < best) {
+				next[id] = n
+			}
+		}
+		total[id] = weights[id] + total[next[id]]
+	}
+
+	var start string
+	for _, id := range order {
+		if start == "" || total[id] > total[start] || (total[id] == total[start] && id < start) {
+			start = id
+		}
+	}
+	if start == "" {
+		return []string{}, 0
+	}
+
+	path := []string{}
+	for id := start; id != ""; id = next[id] {
+		path = append(path, id)
+	}
+	return path, total[start]
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Job tracks work that outlives the request that started it.
+type Job struct {
+	ID         string     `bson:"_id,omitempty" json:"id"`
+	Type       string     `bson:"type" json:"type"`
+	TargetID   string     `bson:"targetId" json:"targetId"`
+	Status     string     `bson:"status" json:"status"`
+	Progress   JobResult  `bson:"progress" json:"progress"`
+	Error      *string    `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time  `bson:"createdAt" json:"createdAt"`
+	StartedAt  *time.Time `bson:"startedAt,omitempty" json:"startedAt,omitempty"`
+	FinishedAt *time.Time `bson:"finishedAt,omitempty" json:"finishedAt,omitempty"`
+}
+
+// JobResult counts the documents a job has moved to the trash so far.
+type JobResult struct {
+	Projects int64 `bson:"projects" json:"projects"`
+	Tasks    int64 `bson:"tasks" json:"tasks"`
+}
+
+const (
+	JobTypeOrganizationDelete = "organization.delete"
+)
+
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
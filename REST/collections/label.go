@@ -0,0 +1,24 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+// Label tags tasks across the projects of an organization. Tasks refer to
+// labels by ID, so renaming or recolouring one shows on every task.
+type Label struct {
+	ID             string    `bson:"_id,omitempty" json:"id"`
+	OrganizationID string    `bson:"organizationId" json:"organizationId"`
+	Name           string    `bson:"name" json:"name"`
+	Color          string    `bson:"color" json:"color"`
+	CreatedAt      time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+var labelColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// IsValidLabelColor accepts hex colours like #d73a4a.
+func IsValidLabelColor(color string) bool {
+	return labelColor.MatchString(color)
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notification is an event the reminder scheduler sent about a task: a
+// reminder ahead of its due date, or that it became overdue.
+type Notification struct {
+	ID         string    `bson:"_id" json:"id"`
+	Type       string    `bson:"type" json:"type"`
+	TaskID     string    `bson:"taskId" json:"taskId"`
+	ProjectID  string    `bson:"projectId" json:"projectId"`
+	Title      string    `bson:"title" json:"title"`
+	AssignedTo *string   `bson:"assignedTo,omitempty" json:"assignedTo,omitempty"`
+	DueAt      time.Time `bson:"dueAt" json:"dueAt"`
+	// Reminder is the offset of a reminder, empty when overdue.
+	Reminder string    `bson:"reminder,omitempty" json:"reminder,omitempty"`
+	At       time.Time `bson:"at" json:"at"`
+}
+
+const (
+	NotificationReminder = "reminder"
+	NotificationOverdue  = "overdue"
+)
+
+// NotificationID names a notification after what it is about, so the same
+// event always gets the same ID however often it is found.
+func NotificationID(taskID string, notificationType string, dueAt time.Time, reminder string) string {
+	return fmt.Sprintf("%s:%s:%d:%s", taskID, notificationType, dueAt.UnixMilli(), reminder)
+}
+
+// Tasks take at most MaxReminders reminders, none earlier than MaxReminder
+// before the due date.
+const (
+	MaxReminders = 5
+	MaxReminder  = 30 * 24 * time.Hour
+)
+
+var errReminder = errors.New("invalid reminder")
+
+// ParseReminder reads a reminder offset, a positive Go duration like "90m"
+// or "24h" or a number of days like "2d".
+func ParseReminder(reminder string) (time.Duration, error) {
+	var offset time.Duration
+	if days, ok := strings.CutSuffix(reminder, "d"); ok {
+		n, err := strconv.ParseUint(days, 10, 16)
+		if err != nil {
+			return 0, fmt.Errorf("%w %q", errReminder, reminder)
+		}
+		offset = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if offset, err = time.ParseDuration(reminder); err != nil {
+			return 0, fmt.Errorf("%w %q", errReminder, reminder)
+		}
+	}
+
+	if offset <= 0 || offset > MaxReminder {
+		return 0, fmt.Errorf("%w %q: must be more than 0 and at most 30 days", errReminder, reminder)
+	}
+	return offset, nil
+}
+
+// ValidateReminders checks a task's reminders.
+func ValidateReminders(reminders []string) error {
+	if len(reminders) > MaxReminders {
+		return fmt.Errorf("%w: at most %d reminders", errReminder, MaxReminders)
+	}
+
+	offsets := make([]time.Duration, 0, len(reminders))
+	for _, reminder := range reminders {
+		offset, err := ParseReminder(reminder)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(offsets, offset) {
+			return fmt.Errorf("%w %q: given twice", errReminder, reminder)
+		}
+		offsets = append(offsets, offset)
+	}
+	return nil
+}
+
+// NextNotification returns when the scheduler next has something to send
+// for a task due at dueAt: the first reminder after after, or else the due
+// date itself, when the task becomes overdue. It is nil without a due date.
+func NextNotification(dueAt *time.Time, reminders []string, after time.Time) *time.Time {
+	if dueAt == nil {
+		return nil
+	}
+
+	next := *dueAt
+	for _, reminder := range reminders {
+		offset, err := ParseReminder(reminder)
+		if err != nil {
+			continue
+		}
+		if at := dueAt.Add(-offset); at.After(after) && at.Before(next) {
+			next = at
+		}
+	}
+	return &next
+}
+
+// DueReminder returns the reminder that is due at now for a task due at
+// dueAt, the one with the smallest offset whose time has come. Reminders
+// missed meanwhile are skipped, only the latest is worth sending.
+func DueReminder(dueAt time.Time, reminders []string, now time.Time) (string, bool) {
+	var due string
+	var dueOffset time.Duration
+	for _, reminder := range reminders {
+		offset, err := ParseReminder(reminder)
+		if err != nil || dueAt.Add(-offset).After(now) {
+			continue
+		}
+		if due == "" || offset < dueOffset {
+			due, dueOffset = reminder, offset
+		}
+	}
+	return due, due != ""
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 type Organization struct {
 	ID          string    `bson:"_id,omitempty" json:"id"`
@@ -9,4 +12,26 @@ type Organization struct {
 	Description *string   `bson:"description,omitempty" json:"description,omitempty"`
 	CreatedAt   time.Time `bson:"createdAt" json:"createdAt"`
 	UpdatedAt   time.Time `bson:"updatedAt" json:"updatedAt"`
+	Version     int64     `bson:"version" json:"version"`
+
+	// Members are the users, as in X-User-ID, who may follow the
+	// organization's change feed and change its members. The user creating
+	// the organization becomes one. Without members nobody may.
+	Members []string `bson:"members,omitempty" json:"members,omitempty"`
+
+	// ArchivedAt makes the organization and everything in it read-only, and
+	// comes with the archived status. DeletedAt puts it in the trash.
+	ArchivedAt *time.Time `bson:"archivedAt,omitempty" json:"archivedAt,omitempty"`
+	DeletedAt  *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+}
+
+const (
+	OrganizationStatusActive   = "active"
+	OrganizationStatusArchived = "archived"
+)
+
+// IsMember tells whether the user may follow the organization's changes and
+// change its members. An empty user is nobody's member.
+func (o *Organization) IsMember(userID string) bool {
+	return userID != "" && slices.Contains(o.Members, userID)
 }
@@ -8,6 +8,44 @@ type Project struct {
 	OrganizationID string    `bson:"organizationId" json:"organizationId"`
 	Status         string    `bson:"status" json:"status"`
 	Description    *string   `bson:"description,omitempty" json:"description,omitempty"`
+	Workflow       *Workflow `bson:"workflow,omitempty" json:"workflow,omitempty"`
 	CreatedAt      time.Time `bson:"createdAt" json:"createdAt"`
 	UpdatedAt      time.Time `bson:"updatedAt" json:"updatedAt"`
+	Version        int64     `bson:"version" json:"version"`
+
+	// CustomFields defines the project's extra task fields.
+	CustomFields []CustomField `bson:"customFields,omitempty" json:"customFields,omitempty"`
+
+	// ArchivedAt makes the project and its tasks read-only, DeletedAt puts
+	// them in the trash. ArchivedWith and DeletedWith name the organization
+	// whose archival or deletion cascaded to the project, so that undoing it
+	// leaves alone what was archived or deleted on its own.
+	ArchivedAt   *time.Time `bson:"archivedAt,omitempty" json:"archivedAt,omitempty"`
+	ArchivedWith string     `bson:"archivedWith,omitempty" json:"-"`
+	DeletedAt    *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+	DeletedWith  string     `bson:"deletedWith,omitempty" json:"-"`
+}
+
+const (
+	ProjectStatusPlanned    = "planned"
+	ProjectStatusInProgress = "in-progress"
+	ProjectStatusOnHold     = "on-hold"
+	ProjectStatusCompleted  = "completed"
+)
+
+// TaskWorkflow returns the project's workflow, or the default one when the
+// project has not configured its own.
+func (p Project) TaskWorkflow() Workflow {
+	if p.Workflow == nil {
+		return DefaultWorkflow()
+	}
+	return *p.Workflow
+}
+
+func IsValidProjectStatus(status string) bool {
+	switch status {
+	case ProjectStatusPlanned, ProjectStatusInProgress, ProjectStatusOnHold, ProjectStatusCompleted:
+		return true
+	}
+	return false
 }
@@ -0,0 +1,26 @@
+package models
+
+// SearchHit is one document matching a text search. Title is the name of an
+// organization or project, or the title of a task.
+type SearchHit struct {
+	Type        string  `json:"type"`
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description *string `json:"description,omitempty"`
+	ProjectID   *string `json:"projectId,omitempty"`
+	Score       float64 `json:"score"`
+}
+
+const (
+	SearchHitOrganization = "organization"
+	SearchHitProject      = "project"
+	SearchHitTask         = "task"
+)
+
+func IsValidSearchHitType(hitType string) bool {
+	switch hitType {
+	case SearchHitOrganization, SearchHitProject, SearchHitTask:
+		return true
+	}
+	return false
+}
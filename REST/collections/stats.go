@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// TaskCounts breaks a set of tasks down by status and priority. Overdue
+// counts the tasks past their due date and not in their workflow's final
+// state.
+type TaskCounts struct {
+	Total      int64            `json:"total"`
+	ByStatus   map[string]int64 `json:"byStatus"`
+	ByPriority map[string]int64 `json:"byPriority"`
+	Unassigned int64            `json:"unassigned"`
+	Overdue    int64            `json:"overdue"`
+}
+
+// AssigneeWorkload counts the tasks assigned to one user.
+type AssigneeWorkload struct {
+	UserID   string           `json:"userId"`
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"byStatus"`
+}
+
+type ProjectStats struct {
+	ProjectID   string             `json:"projectId"`
+	Name        string             `json:"name"`
+	Status      string             `json:"status"`
+	Tasks       TaskCounts         `json:"tasks"`
+	Workload    []AssigneeWorkload `json:"workload"`
+	GeneratedAt time.Time          `json:"generatedAt"`
+}
+
+// OrganizationStats sums up the organization's projects. Projects carries
+// the per-project task counts, Workload spans all projects.
+type OrganizationStats struct {
+	OrganizationID   string             `json:"organizationId"`
+	ProjectsByStatus map[string]int64   `json:"projectsByStatus"`
+	Tasks            TaskCounts         `json:"tasks"`
+	Projects         []ProjectStats     `json:"projects"`
+	Workload         []AssigneeWorkload `json:"workload"`
+	GeneratedAt      time.Time          `json:"generatedAt"`
+}
@@ -13,4 +13,68 @@ type Task struct {
 	Description *string    `bson:"description,omitempty" json:"description,omitempty"`
 	CreatedAt   time.Time  `bson:"createdAt" json:"createdAt"`
 	UpdatedAt   time.Time  `bson:"updatedAt" json:"updatedAt"`
+	Version     int64      `bson:"version" json:"version"`
+
+	// Labels holds IDs of labels of the project's organization, and
+	// CustomFields values of the project's custom fields by key.
+	Labels       []string               `bson:"labels,omitempty" json:"labels,omitempty"`
+	CustomFields map[string]interface{} `bson:"customFields,omitempty" json:"customFields,omitempty"`
+
+	// DueAt is the task's deadline, Reminders offsets before it like "24h"
+	// at which reminders go out. NotifyAt is when the reminder scheduler
+	// next has something to send for the task, see NextNotification.
+	DueAt     *time.Time `bson:"dueAt,omitempty" json:"dueAt,omitempty"`
+	Reminders []string   `bson:"reminders,omitempty" json:"reminders,omitempty"`
+	NotifyAt  *time.Time `bson:"notifyAt,omitempty" json:"-"`
+
+	// Tasks are archived and deleted like projects, see Project. The
+	// cascades name the organization or project they came from.
+	ArchivedAt   *time.Time `bson:"archivedAt,omitempty" json:"archivedAt,omitempty"`
+	ArchivedWith string     `bson:"archivedWith,omitempty" json:"-"`
+	DeletedAt    *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+	DeletedWith  string     `bson:"deletedWith,omitempty" json:"-"`
+
+	// AllowedTransitions is derived from the project workflow when the task
+	// is returned and is never stored.
+	AllowedTransitions []string `bson:"-" json:"allowedTransitions"`
+	// BlockedBy lists the tasks blocking this one and Blocking the tasks it
+	// blocks. They come from the task's dependencies in the same way.
+	BlockedBy []string `bson:"-" json:"blockedBy"`
+	Blocking  []string `bson:"-" json:"blocking"`
+}
+
+const (
+	TaskStatusPending    = "pending"
+	TaskStatusInProgress = "in-progress"
+	TaskStatusReview     = "review"
+	TaskStatusDone       = "done"
+)
+
+const (
+	TaskPriorityLow    = "low"
+	TaskPriorityMedium = "medium"
+	TaskPriorityHigh   = "high"
+	TaskPriorityUrgent = "urgent"
+)
+
+// TaskPriorities lists the priorities from lowest to highest.
+var TaskPriorities = []string{TaskPriorityLow, TaskPriorityMedium, TaskPriorityHigh, TaskPriorityUrgent}
+
+// TaskPriorityRank is the priority's position in TaskPriorities counting
+// from 1, which tasks sort by, and 0 for an unknown priority.
+func TaskPriorityRank(priority string) int {
+	for i, p := range TaskPriorities {
+		if p == priority {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func IsValidTaskPriority(priority string) bool {
+	switch priority {
+	case TaskPriorityLow, TaskPriorityMedium, TaskPriorityHigh, TaskPriorityUrgent:
+		return true
+	}
+	return false
 }
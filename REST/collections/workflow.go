@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Workflow defines the task states of a project and the moves allowed
+// between them. Tasks start in Initial.
+type Workflow struct {
+	Initial     string              `bson:"initial" json:"initial"`
+	States      []string            `bson:"states" json:"states"`
+	Transitions map[string][]string `bson:"transitions" json:"transitions"`
+}
+
+// DefaultWorkflow is used by projects that have not configured their own:
+// pending → in-progress → review → done, with the option to step back.
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		Initial: TaskStatusPending,
+		States:  []string{TaskStatusPending, TaskStatusInProgress, TaskStatusReview, TaskStatusDone},
+		Transitions: map[string][]string{
+			TaskStatusPending:    {TaskStatusInProgress},
+			TaskStatusInProgress: {TaskStatusPending, TaskStatusReview},
+			TaskStatusReview:     {TaskStatusInProgress, TaskStatusDone},
+			TaskStatusDone:       {TaskStatusInProgress},
+		},
+	}
+}
+
+func (w Workflow) Validate() error {
+	if len(w.States) == 0 {
+		return errors.New("workflow needs at least one state")
+	}
+
+	seen := make(map[string]bool, len(w.States))
+	for _, state := range w.States {
+		if state == "" {
+			return errors.New("workflow states must not be empty")
+		}
+		if seen[state] {
+			return fmt.Errorf("workflow state %q is listed twice", state)
+		}
+		seen[state] = true
+	}
+
+	if !seen[w.Initial] {
+		return fmt.Errorf("initial state %q is not a workflow state", w.Initial)
+	}
+
+	for from, targets := range w.Transitions {
+		if !seen[from] {
+			return fmt.Errorf("transition from unknown state %q", from)
+		}
+		for _, to := range targets {
+			if !seen[to] {
+				return fmt.Errorf("transition from %q to unknown state %q", from, to)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w Workflow) HasState(state string) bool {
+	for _, s := range w.States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func (w Workflow) CanTransition(from string, to string) bool {
+	for _, next := range w.Transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the states a task in state from may move to.
+func (w Workflow) Next(from string) []string {
+	next := w.Transitions[from]
+	if next == nil {
+		return []string{}
+	}
+	return next
+}
+
+// Final returns the last of the workflow's states, where finished tasks end
+// up, like done.
+func (w Workflow) Final() string {
+	if len(w.States) == 0 {
+		return ""
+	}
+	return w.States[len(w.States)-1]
+}
+
+// IsFinal tells whether state is the workflow's final state.
+func (w Workflow) IsFinal(state string) bool {
+	return len(w.States) > 0 && w.Final() == state
+}
+
+// StatusTransition records one status change of a task.
+type StatusTransition struct {
+	ID        string    `bson:"_id,omitempty" json:"id"`
+	TaskID    string    `bson:"taskId" json:"taskId"`
+	ProjectID string    `bson:"projectId" json:"projectId"`
+	From      string    `bson:"from" json:"from"`
+	To        string    `bson:"to" json:"to"`
+	Actor     string    `bson:"actor" json:"actor"`
+	At        time.Time `bson:"at" json:"at"`
+}
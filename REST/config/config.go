@@ -0,0 +1,226 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+type Mongo struct {
+	URI string `yaml:"uri" env:"MONGO_URI" env-default:"mongodb://localhost:27017"`
+	// Username and Password are optional, credentials in the URI work too.
+	Username       string        `yaml:"username" env:"MONGO_USERNAME"`
+	Password       string        `yaml:"password" env:"MONGO_PASSWORD"`
+	AuthSource     string        `yaml:"auth_source" env:"MONGO_AUTH_SOURCE" env-default:"admin"`
+	Database       string        `yaml:"database" env:"MONGO_DATABASE" env-default:"task_manager"`
+	MinPoolSize    uint64        `yaml:"min_pool_size" env:"MONGO_MIN_POOL_SIZE" env-default:"0"`
+	MaxPoolSize    uint64        `yaml:"max_pool_size" env:"MONGO_MAX_POOL_SIZE" env-default:"100"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"MONGO_CONNECT_TIMEOUT" env-default:"10s"`
+}
+
+type HTTP struct {
+	Addr         string        `yaml:"address" env:"HTTP_ADDR" env-default:":8080"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" env-default:"10s"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" env-default:"30s"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" env-default:"60s"`
+	// RequestTimeout bounds the database work done for one request.
+	RequestTimeout time.Duration `yaml:"request_timeout" env:"HTTP_REQUEST_TIMEOUT" env-default:"5s"`
+}
+
+type Pagination struct {
+	// CursorSecret signs pagination cursors. Without it a random secret is
+	// used, and cursors stop working when the server restarts.
+	CursorSecret string `yaml:"cursor_secret" env:"CURSOR_SECRET"`
+}
+
+type Jobs struct {
+	Timeout time.Duration `yaml:"timeout" env:"JOB_TIMEOUT" env-default:"30m"`
+}
+
+// Trash keeps deleted organizations, projects and tasks for Retention, the
+// purge worker looks for expired ones every PurgeInterval.
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env:"TRASH_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"TRASH_PURGE_INTERVAL" env-default:"1h"`
+}
+
+// Reminders runs the due date scheduler every Interval. Only the replica
+// holding the lease runs it, a crashed holder's lease runs out after
+// LeaseTTL.
+type Reminders struct {
+	Interval time.Duration `yaml:"interval" env:"REMINDER_INTERVAL" env-default:"1m"`
+	LeaseTTL time.Duration `yaml:"lease_ttl" env:"REMINDER_LEASE_TTL" env-default:"5m"`
+	// Notifier is log or smtp.
+	Notifier string `yaml:"notifier" env:"NOTIFIER" env-default:"log"`
+}
+
+// SMTP is where the smtp notifier sends its mail. Username and Password are
+// optional, without them mail is sent unauthenticated.
+type SMTP struct {
+	Addr     string   `yaml:"address" env:"SMTP_ADDR"`
+	Username string   `yaml:"username" env:"SMTP_USERNAME"`
+	Password string   `yaml:"password" env:"SMTP_PASSWORD"`
+	From     string   `yaml:"from" env:"SMTP_FROM"`
+	To       []string `yaml:"to" env:"SMTP_TO" env-separator:","`
+}
+
+// Bulk limits how many tasks one bulk request may change.
+type Bulk struct {
+	MaxTasks int `yaml:"max_tasks" env:"BULK_MAX_TASKS" env-default:"100"`
+}
+
+type Config struct {
+	Mongo      Mongo      `yaml:"mongo"`
+	HTTP       HTTP       `yaml:"http"`
+	Pagination Pagination `yaml:"pagination"`
+	Jobs       Jobs       `yaml:"jobs"`
+	Trash      Trash      `yaml:"trash"`
+	Reminders  Reminders  `yaml:"reminders"`
+	SMTP       SMTP       `yaml:"smtp"`
+	Bulk       Bulk       `yaml:"bulk"`
+}
+
+// MustLoad reads the configuration from the environment. A YAML file named
+// by CONFIG_PATH or -config is optional, environment variables override it.
+func MustLoad() *Config {
+	configPath := os.Getenv("CONFIG_PATH")
+	if configPath == "" {
+		flags := flag.String("config", "", "path to config file")
+		flag.Parse()
+		configPath = *flags
+	}
+
+	var cfg Config
+	var err error
+	if configPath != "" {
+		if _, statErr := os.Stat(configPath); os.IsNotExist(statErr) {
+			log.Fatalf("config file not found: %v", statErr)
+		}
+		err = cleanenv.ReadConfig(configPath, &cfg)
+	} else {
+		err = cleanenv.ReadEnv(&cfg)
+	}
+	if err != nil {
+		log.Fatalf("failed to read config: %v", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+
+	return &cfg
+}
+
+// Validate reports every problem at once rather than the first.
+func (c Config) Validate() error {
+	var errs []error
+
+	if !strings.HasPrefix(c.Mongo.URI, "mongodb://") && !strings.HasPrefix(c.Mongo.URI, "mongodb+srv://") {
+		errs = append(errs, errors.New("mongo.uri must start with mongodb:// or mongodb+srv://"))
+	} else if _, err := url.Parse(c.Mongo.URI); err != nil {
+		errs = append(errs, fmt.Errorf("mongo.uri: %w", err))
+	}
+	if c.Mongo.Password != "" && c.Mongo.Username == "" {
+		errs = append(errs, errors.New("mongo.password is set without mongo.username"))
+	}
+	if c.Mongo.Database == "" {
+		errs = append(errs, errors.New("mongo.database is required"))
+	}
+	if c.Mongo.MaxPoolSize == 0 {
+		errs = append(errs, errors.New("mongo.max_pool_size must be positive"))
+	}
+	if c.Mongo.MinPoolSize > c.Mongo.MaxPoolSize {
+		errs = append(errs, errors.New("mongo.min_pool_size must not exceed mongo.max_pool_size"))
+	}
+
+	if c.HTTP.Addr == "" {
+		errs = append(errs, errors.New("http.address is required"))
+	}
+	if c.Pagination.CursorSecret != "" && len(c.Pagination.CursorSecret) < 32 {
+		errs = append(errs, errors.New("pagination.cursor_secret must be at least 32 characters"))
+	}
+
+	switch c.Reminders.Notifier {
+	case "log":
+	case "smtp":
+		if c.SMTP.Addr == "" {
+			errs = append(errs, errors.New("smtp.address is required by the smtp notifier"))
+		}
+		if c.SMTP.From == "" {
+			errs = append(errs, errors.New("smtp.from is required by the smtp notifier"))
+		}
+		if len(c.SMTP.To) == 0 {
+			errs = append(errs, errors.New("smtp.to is required by the smtp notifier"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("reminders.notifier must be log or smtp, not %q", c.Reminders.Notifier))
+	}
+	if c.SMTP.Password != "" && c.SMTP.Username == "" {
+		errs = append(errs, errors.New("smtp.password is set without smtp.username"))
+	}
+	if c.Bulk.MaxTasks <= 0 {
+		errs = append(errs, errors.New("bulk.max_tasks must be positive"))
+	}
+	if c.Reminders.LeaseTTL <= c.Reminders.Interval {
+		errs = append(errs, errors.New("reminders.lease_ttl must exceed reminders.interval"))
+	}
+
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"mongo.connect_timeout", c.Mongo.ConnectTimeout},
+		{"http.read_timeout", c.HTTP.ReadTimeout},
+		{"http.write_timeout", c.HTTP.WriteTimeout},
+		{"http.idle_timeout", c.HTTP.IdleTimeout},
+		{"http.request_timeout", c.HTTP.RequestTimeout},
+		{"jobs.timeout", c.Jobs.Timeout},
+		{"trash.retention", c.Trash.Retention},
+		{"trash.purge_interval", c.Trash.PurgeInterval},
+		{"reminders.interval", c.Reminders.Interval},
+		{"reminders.lease_ttl", c.Reminders.LeaseTTL},
+	} {
+		if d.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", d.name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy that is safe to log.
+func (c Config) Redacted() Config {
+	if c.Mongo.Password != "" {
+		c.Mongo.Password = redacted
+	}
+	if c.Pagination.CursorSecret != "" {
+		c.Pagination.CursorSecret = redacted
+	}
+	if c.SMTP.Password != "" {
+		c.SMTP.Password = redacted
+	}
+	if u, err := url.Parse(c.Mongo.URI); err == nil {
+		c.Mongo.URI = u.Redacted()
+	}
+	return c
+}
+
+// String prints the redacted config in the file format, so logging it never
+// leaks secrets.
+func (c Config) String() string {
+	data, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
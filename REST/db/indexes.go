@@ -5,20 +5,177 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func CreateIndexes() error {
 	ctx := context.Background()
 
-	_, err := Database.Collection("projects").Indexes().CreateOne(ctx, mongo.IndexModel{
+	// Keyset pagination of organizations, with and without a status filter
+	_, err := Database.Collection("organizations").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Organization names are unique, project names unique per organization,
+	// both regardless of case. Creating them fails while duplicates exist.
+	// The repositories recognize the index name in duplicate key errors.
+	// Trashed documents keep their names on purpose, so restoring one never
+	// collides with a newer namesake; the name is free once it is purged.
+	caseInsensitive := &options.Collation{Locale: "en", Strength: 2}
+	_, err = Database.Collection("organizations").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetName("name_unique").SetUnique(true).SetCollation(caseInsensitive),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = Database.Collection("projects").Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.M{"organizationId": 1},
 	})
 	if err != nil {
 		return err
 	}
 
+	// Queries without the collation cannot use these, hence the plain
+	// organizationId index above
+	_, err = Database.Collection("projects").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetName("name_unique").SetUnique(true).SetCollation(caseInsensitive),
+	})
+	if err != nil {
+		return err
+	}
+
 	_, err = Database.Collection("tasks").Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.M{"projectId": 1},
 	})
+	if err != nil {
+		return err
+	}
+
+	_, err = Database.Collection("tasks").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"assignedTo": 1},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Filtering by label and pulling deleted labels from tasks
+	_, err = Database.Collection("tasks").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"labels": 1},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Finding the tasks the reminder scheduler has something due for
+	_, err = Database.Collection("tasks").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "notifyAt", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"notifyAt": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = Database.Collection("task_notifications").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "taskId", Value: 1}, {Key: "at", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Dependencies by either task, by organization for the cycle check and
+	// by project for critical paths and purging
+	for _, keys := range []bson.D{
+		{{Key: "taskId", Value: 1}},
+		{{Key: "blockerId", Value: 1}},
+		{{Key: "organizationId", Value: 1}},
+		{{Key: "projectId", Value: 1}, {Key: "blockerProjectId", Value: 1}},
+		{{Key: "blockerProjectId", Value: 1}},
+	} {
+		_, err = Database.Collection("task_dependencies").Indexes().CreateOne(ctx, mongo.IndexModel{Keys: keys})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = Database.Collection("task_transitions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"taskId": 1},
+	})
+	if err != nil {
+		return err
+	}
+
+	// A task's activity and comments in the order of its timeline
+	_, err = Database.Collection("task_activity").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "taskId", Value: 1}, {Key: "at", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = Database.Collection("task_comments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "taskId", Value: 1}, {Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Text search, names and titles weigh more than descriptions. A
+	// collection can only have one text index.
+	for collection, title := range map[string]string{
+		"organizations": "name",
+		"projects":      "name",
+		"tasks":         "title",
+	} {
+		_, err = Database.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: title, Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().
+				SetName("text_search").
+				SetWeights(bson.D{{Key: title, Value: 3}, {Key: "description", Value: 1}}),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Listing the trash and finding what to purge
+	for _, collection := range []string{"organizations", "projects", "tasks"} {
+		_, err = Database.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "deletedAt", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"deletedAt": bson.M{"$exists": true}}),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// View names are unique per organization
+	_, err = Database.Collection("views").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Label names are unique per organization regardless of case, like
+	// project names
+	_, err = Database.Collection("labels").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"organizationId": 1},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = Database.Collection("labels").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetName("name_unique").SetUnique(true).SetCollation(caseInsensitive),
+	})
 	return err
 }
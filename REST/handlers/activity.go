@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ListTaskActivityHandler pages the changes made to the task's fields,
+// oldest first, with who made them and the values before and after.
+func (h *Handler) ListTaskActivityHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	page, limit := parsePage(r.URL.Query())
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Tasks.GetByID(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	activity, total, err := h.Activity.List(ctx, id, page, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(paginatedResponse(activity, page, limit, total))
+}
+
+// TaskTimelineHandler pages the task's activity and comments together in
+// the order they happened.
+func (h *Handler) TaskTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	page, limit := parsePage(r.URL.Query())
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Tasks.GetByID(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	items, total, err := h.Activity.Timeline(ctx, id, page, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(paginatedResponse(items, page, limit, total))
+}
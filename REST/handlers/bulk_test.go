@@ -0,0 +1,85 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+type bulkResponse struct {
+	Data []struct {
+		ID      string `json:"id"`
+		Code    int    `json:"code"`
+		Version *int64 `json:"version"`
+	} `json:"data"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+func TestBulkTasks(t *testing.T) {
+	s := newTestServer(t)
+	projectID := s.newProject()
+	bulk := "/projects/" + projectID + "/tasks/bulk"
+
+	started := s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Started"})
+	pending := s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Pending"})
+	s.do(http.MethodPut, "/tasks/"+started, map[string]any{"status": "in-progress"}, nil)
+
+	// Only the started task may go to review
+	var res bulkResponse
+	rec := s.do(http.MethodPost, bulk, map[string]any{
+		"ids":   []string{started, pending},
+		"patch": map[string]any{"status": "review"},
+	}, &res)
+	if rec.Code != http.StatusOK || res.Succeeded != 1 || res.Failed != 1 {
+		t.Fatalf("bulk status: status %d, response %+v", rec.Code, res)
+	}
+	for _, result := range res.Data {
+		want := http.StatusOK
+		if result.ID == pending {
+			want = http.StatusConflict
+		}
+		if result.Code != want {
+			t.Fatalf("result for %s = %d, want %d", result.ID, result.Code, want)
+		}
+	}
+
+	res = bulkResponse{}
+	rec = s.do(http.MethodPost, bulk, map[string]any{
+		"filter": "status=pending",
+		"patch":  map[string]any{"priority": "urgent"},
+	}, &res)
+	if rec.Code != http.StatusOK || res.Succeeded != 1 || res.Data[0].ID != pending {
+		t.Fatalf("bulk by filter: status %d, response %+v", rec.Code, res)
+	}
+
+	var task struct {
+		Priority string `json:"priority"`
+	}
+	s.do(http.MethodGet, "/tasks/"+pending, nil, &task)
+	if task.Priority != "urgent" {
+		t.Fatalf("priority after bulk = %q, want urgent", task.Priority)
+	}
+}
+
+func TestBulkTasksRejects(t *testing.T) {
+	s := newTestServer(t)
+	projectID := s.newProject()
+	bulk := "/projects/" + projectID + "/tasks/bulk"
+	id := s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Task"})
+
+	for _, tc := range []struct {
+		name string
+		body map[string]any
+		want int
+	}{
+		{"ids and filter", map[string]any{"ids": []string{id}, "filter": "", "patch": map[string]any{"priority": "low"}}, http.StatusBadRequest},
+		{"no tasks", map[string]any{"ids": []string{}, "patch": map[string]any{"priority": "low"}}, http.StatusBadRequest},
+		{"other field", map[string]any{"ids": []string{id}, "patch": map[string]any{"title": "New"}}, http.StatusBadRequest},
+		{"too many", map[string]any{"ids": []string{id, id, id, id}, "patch": map[string]any{"priority": "low"}}, http.StatusRequestEntityTooLarge},
+		{"unknown status", map[string]any{"ids": []string{id}, "patch": map[string]any{"status": "shipped"}}, http.StatusUnprocessableEntity},
+	} {
+		if rec := s.do(http.MethodPost, bulk, tc.body, nil); rec.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.want)
+		}
+	}
+}
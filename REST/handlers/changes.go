@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// changeKeepAlive is how often idle subscriptions get a heartbeat, so
+	// proxies keep them open and dead WebSocket peers are noticed.
+	changeKeepAlive = 30 * time.Second
+	// changeWriteTimeout bounds writing one message to a WebSocket.
+	changeWriteTimeout = 10 * time.Second
+)
+
+// The default origin check only accepts pages served by this host.
+var changesUpgrader = websocket.Upgrader{}
+
+// OrganizationChangesHandler streams the changes to an organization's
+// projects and their tasks. See streamChanges.
+func (h *Handler) OrganizationChangesHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	h.streamChanges(w, r, id, repositories.ChangeScope{OrganizationID: id})
+}
+
+// ProjectChangesHandler streams the changes to a project and its tasks.
+// See streamChanges.
+func (h *Handler) ProjectChangesHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	project, err := h.Projects.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.streamChanges(w, r, project.OrganizationID, repositories.ChangeScope{ProjectID: id})
+}
+
+// errNotMember ends a subscription whose caller left the organization.
+var errNotMember = errors.New("no longer a member")
+
+// memberOf tells whether the user is a member of the organization, see
+// models.Organization.IsMember.
+func (h *Handler) memberOf(ctx context.Context, orgID string, userID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.RequestTimeout)
+	defer cancel()
+
+	org, err := h.Organizations.GetByID(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	return org.IsMember(userID), nil
+}
+
+// streamChanges serves a subscription over WebSocket when the request asks
+// for an upgrade, as server-sent events otherwise, or answers 503 when
+// change feeds are disabled. Only members of the
+// organization in X-User-ID may subscribe, others get 403. Membership is
+// checked again before each change is written, and the subscription ends
+// once the caller is no longer a member. It resumes after the change whose
+// token is in the Last-Event-ID header or the resumeAfter parameter, and
+// answers 410 when that change is too old to resume from. The subscription
+// ends when the feed breaks; clients reconnect with the last token they
+// got.
+func (h *Handler) streamChanges(w http.ResponseWriter, r *http.Request, orgID string, scope repositories.ChangeScope) {
+	if h.ChangeFeedsDisabled {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	caller := r.Header.Get("X-User-ID")
+	member, err := h.memberOf(r.Context(), orgID, caller)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if !member {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	resumeAfter := r.URL.Query().Get("resumeAfter")
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		resumeAfter = lastEventID
+	}
+
+	watchCtx, watchCancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	stream, err := h.Changes.Watch(watchCtx, scope, resumeAfter)
+	watchCancel()
+	if errors.Is(err, repositories.ErrCannotResume) {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	changes := nextChanges(ctx, stream, func(ctx context.Context) error {
+		member, err := h.memberOf(ctx, orgID, caller)
+		if err == nil && !member {
+			err = errNotMember
+		}
+		return err
+	})
+
+	// Subscriptions outlive the server's read and write timeouts
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	if websocket.IsWebSocketUpgrade(r) {
+		writeChangesWebSocket(w, r, cancel, changes)
+	} else {
+		writeChangeEvents(w, rc, changes)
+	}
+}
+
+// changeOrError is what a change stream delivered.
+type changeOrError struct {
+	change *models.Change
+	err    error
+}
+
+// nextChanges reads the stream in the background until ctx ends, the
+// stream fails or allowed, called before each change is delivered, fails.
+// It delivers the error last, then closes the stream and the channel.
+func nextChanges(ctx context.Context, stream repositories.ChangeStream, allowed func(context.Context) error) <-chan changeOrError {
+	changes := make(chan changeOrError)
+
+	go func() {
+		defer close(changes)
+		defer stream.Close(context.Background())
+
+		for {
+			change, err := stream.Next(ctx)
+			if err == nil {
+				err = allowed(ctx)
+			}
+			select {
+			case changes <- changeOrError{change, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return changes
+}
+
+// writeChangeEvents sends each change as an event with the change's token
+// as its ID, which EventSource sends back as Last-Event-ID on reconnect.
+func writeChangeEvents(w http.ResponseWriter, rc *http.ResponseController, changes <-chan changeOrError) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(changeKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case next, ok := <-changes:
+			if !ok || next.err != nil {
+				return
+			}
+			data, err := json.Marshal(next.change)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "id: %s\ndata: %s\n\n", next.change.Token, data)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeChangesWebSocket sends each change as a JSON text message. Clients
+// resume with the token of the last one in the resumeAfter parameter.
+func writeChangesWebSocket(w http.ResponseWriter, r *http.Request, cancel context.CancelFunc, changes <-chan changeOrError) {
+	// Upgrade answers the request itself when it fails
+	conn, err := changesUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Clients only send control frames. Reading handles them and notices
+	// when the client goes away.
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(changeKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case next, ok := <-changes:
+			if !ok || next.err != nil {
+				code, text := websocket.CloseInternalServerErr, "change feed failed"
+				if !ok || errors.Is(next.err, context.Canceled) {
+					code, text = websocket.CloseGoingAway, ""
+				} else if errors.Is(next.err, repositories.ErrCannotResume) {
+					code, text = websocket.CloseTryAgainLater, "cannot resume, reload"
+				} else if errors.Is(next.err, errNotMember) || next.err == mongo.ErrNoDocuments {
+					code, text = websocket.ClosePolicyViolation, "no access"
+				}
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text),
+					time.Now().Add(changeWriteTimeout))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(changeWriteTimeout))
+			if err := conn.WriteJSON(next.change); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(changeWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangesNeedMembership(t *testing.T) {
+	s := newTestServer(t)
+	orgID := s.create("/organizations", map[string]any{"name": "Acme", "status": "active", "members": []string{"alice"}})
+	projectID := s.create("/organizations/"+orgID+"/projects", map[string]any{"name": "Launch"})
+
+	for _, path := range []string{"/organizations/" + orgID + "/changes", "/projects/" + projectID + "/changes"} {
+		if rec := s.do(http.MethodGet, path, nil, nil, "X-User-ID", "bob"); rec.Code != http.StatusForbidden {
+			t.Errorf("%s as a stranger: status %d, want 403", path, rec.Code)
+		}
+		if rec := s.do(http.MethodGet, path, nil, nil); rec.Code != http.StatusForbidden {
+			t.Errorf("%s without a user: status %d, want 403", path, rec.Code)
+		}
+	}
+}
+
+func TestChangesWithoutMembers(t *testing.T) {
+	s := newTestServer(t)
+	orgID := s.create("/organizations", map[string]any{"name": "Acme", "status": "active"})
+
+	for _, caller := range []string{"", "bob"} {
+		if rec := s.do(http.MethodGet, "/organizations/"+orgID+"/changes", nil, nil, "X-User-ID", caller); rec.Code != http.StatusForbidden {
+			t.Errorf("organization without members, user %q: status %d, want 403", caller, rec.Code)
+		}
+	}
+}
+
+func TestMembersChangeOnlyByMembers(t *testing.T) {
+	s := newTestServer(t)
+
+	var created struct {
+		ID      string   `json:"id"`
+		Members []string `json:"members"`
+	}
+	rec := s.do(http.MethodPost, "/organizations", map[string]any{"name": "Acme", "status": "active", "members": []string{"bob"}}, &created, "X-User-ID", "alice")
+	if rec.Code != http.StatusCreated || len(created.Members) != 2 || created.Members[1] != "alice" {
+		t.Fatalf("create: status %d, members %v, want bob and the creator", rec.Code, created.Members)
+	}
+	path := "/organizations/" + created.ID
+	mergePatch := "application/merge-patch+json"
+
+	for _, caller := range []string{"", "mallory"} {
+		if rec := s.do(http.MethodPut, path, map[string]any{"members": []string{"mallory"}}, nil, "X-User-ID", caller); rec.Code != http.StatusForbidden {
+			t.Errorf("PUT members as %q: status %d, want 403", caller, rec.Code)
+		}
+		if rec := s.do(http.MethodPatch, path, map[string]any{"members": []string{"mallory"}}, nil, "Content-Type", mergePatch, "X-User-ID", caller); rec.Code != http.StatusForbidden {
+			t.Errorf("PATCH members as %q: status %d, want 403", caller, rec.Code)
+		}
+	}
+	if rec := s.do(http.MethodPatch, path, map[string]any{"members": nil}, nil, "Content-Type", mergePatch, "X-User-ID", "mallory"); rec.Code != http.StatusForbidden {
+		t.Errorf("removing the members as a stranger: status %d, want 403", rec.Code)
+	}
+	if rec := s.do(http.MethodPut, path, map[string]any{"description": "Rockets"}, nil, "X-User-ID", "mallory"); rec.Code != http.StatusNoContent {
+		t.Errorf("PUT without members as a stranger: status %d, want 204", rec.Code)
+	}
+
+	var patched struct {
+		Members []string `json:"members"`
+	}
+	rec = s.do(http.MethodPatch, path, map[string]any{"members": []string{"bob", "carol"}}, &patched, "Content-Type", mergePatch, "X-User-ID", "bob")
+	if rec.Code != http.StatusOK || len(patched.Members) != 2 || patched.Members[1] != "carol" {
+		t.Fatalf("PATCH members as a member: status %d, members %v", rec.Code, patched.Members)
+	}
+	if rec := s.do(http.MethodPut, path, map[string]any{"members": []string{"alice"}}, nil, "X-User-ID", "alice"); rec.Code != http.StatusForbidden {
+		t.Fatalf("PUT members as a removed member: status %d, want 403", rec.Code)
+	}
+}
+
+func TestChangesStopWithMembership(t *testing.T) {
+	s := newTestServer(t)
+	orgID := s.create("/organizations", map[string]any{"name": "Acme", "status": "active", "members": []string{"alice"}})
+
+	server := httptest.NewServer(s.mux)
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/organizations/"+orgID+"/changes", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-User-ID", "alice")
+	res, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("subscribe as a member: status %d", res.StatusCode)
+	}
+
+	events := make(chan string)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				events <- data
+			}
+		}
+	}()
+	next := func() (string, bool) {
+		t.Helper()
+		select {
+		case data, ok := <-events:
+			return data, ok
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event and the subscription is still open")
+			return "", false
+		}
+	}
+
+	projectID := s.create("/organizations/"+orgID+"/projects", map[string]any{"name": "Launch"})
+	if data, ok := next(); !ok || !strings.Contains(data, projectID) {
+		t.Fatalf("event = %q, want the new project", data)
+	}
+
+	if rec := s.do(http.MethodPut, "/organizations/"+orgID, map[string]any{"members": []string{"carol"}}, nil, "X-User-ID", "alice"); rec.Code != http.StatusNoContent {
+		t.Fatalf("replace members: status %d", rec.Code)
+	}
+	taskID := s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Secret"})
+	if data, ok := next(); ok {
+		t.Fatalf("former member got %q, want the subscription to end before task %s", data, taskID)
+	}
+}
+
+func TestChangesDisabled(t *testing.T) {
+	s := newTestServer(t)
+	s.h.ChangeFeedsDisabled = true
+	orgID := s.create("/organizations", map[string]any{"name": "Acme", "status": "active"})
+
+	if rec := s.do(http.MethodGet, "/organizations/"+orgID+"/changes", nil, nil); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("disabled change feed: status %d, want 503", rec.Code)
+	}
+}
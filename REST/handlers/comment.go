@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ListCommentsHandler pages the task's comments, oldest first.
+func (h *Handler) ListCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	page, limit := parsePage(r.URL.Query())
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Tasks.GetByID(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	comments, total, err := h.Comments.List(ctx, id, page, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(paginatedResponse(comments, page, limit, total))
+}
+
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// CreateCommentHandler adds a comment by the user in X-User-ID. Archived
+// tasks are read-only, commenting on one answers 422.
+func (h *Handler) CreateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	task, err := h.Tasks.GetByID(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if task.ArchivedAt != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	now := time.Now()
+	comment := models.Comment{
+		ID:        primitive.NewObjectID().Hex(),
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		Author:    actorFromRequest(r),
+		Body:      req.Body,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := h.Comments.Create(ctx, comment); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+// UpdateCommentHandler replaces the comment's body.
+func (h *Handler) UpdateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	commentID := r.PathValue("commentId")
+
+	var req CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if status := h.checkCommentWritable(ctx, id, commentID); status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	err := h.Comments.Update(ctx, commentID, bson.M{
+		"body":      req.Body,
+		"updatedAt": time.Now(),
+	})
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	commentID := r.PathValue("commentId")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if status := h.checkCommentWritable(ctx, id, commentID); status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	err := h.Comments.Delete(ctx, commentID)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkCommentWritable returns the status to answer with when the comment
+// cannot be changed: 404 when the task or the comment on it is missing, 422
+// when the task is archived. It returns 0 otherwise.
+func (h *Handler) checkCommentWritable(ctx context.Context, taskID string, commentID string) int {
+	task, err := h.Tasks.GetByID(ctx, taskID)
+	if err == mongo.ErrNoDocuments {
+		return http.StatusNotFound
+	}
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	if task.ArchivedAt != nil {
+		return http.StatusUnprocessableEntity
+	}
+
+	comment, err := h.Comments.GetByID(ctx, commentID)
+	if err == mongo.ErrNoDocuments || (err == nil && comment.TaskID != taskID) {
+		return http.StatusNotFound
+	}
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return 0
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// setDependencies fills BlockedBy and Blocking on tasks.
+func (h *Handler) setDependencies(ctx context.Context, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	index := make(map[string]int, len(tasks))
+	ids := make([]string, 0, len(tasks))
+	for i := range tasks {
+		tasks[i].BlockedBy, tasks[i].Blocking = []string{}, []string{}
+		index[tasks[i].ID] = i
+		ids = append(ids, tasks[i].ID)
+	}
+
+	dependencies, err := h.Dependencies.ListByTasks(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, d := range dependencies {
+		if i, ok := index[d.TaskID]; ok {
+			tasks[i].BlockedBy = append(tasks[i].BlockedBy, d.BlockerID)
+		}
+		if i, ok := index[d.BlockerID]; ok {
+			tasks[i].Blocking = append(tasks[i].Blocking, d.TaskID)
+		}
+	}
+
+	return nil
+}
+
+// blockers returns the tasks blocking the task. Blockers in the trash are
+// left out.
+func (h *Handler) blockers(ctx context.Context, taskID string) ([]models.Task, error) {
+	dependencies, err := h.Dependencies.ListByTasks(ctx, []string{taskID})
+	if err != nil {
+		return nil, err
+	}
+
+	blockers := []models.Task{}
+	for _, d := range dependencies {
+		if d.TaskID != taskID {
+			continue
+		}
+		blocker, err := h.Tasks.GetByID(ctx, d.BlockerID)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		blockers = append(blockers, *blocker)
+	}
+
+	return blockers, nil
+}
+
+// isBlocked tells whether any of the task's blockers is still open, not in
+// the final state of its project's workflow.
+func (h *Handler) isBlocked(ctx context.Context, taskID string) (bool, error) {
+	blockers, err := h.blockers(ctx, taskID)
+	if err != nil {
+		return false, err
+	}
+
+	workflows := map[string]models.Workflow{}
+	for _, blocker := range blockers {
+		workflow, ok := workflows[blocker.ProjectID]
+		if !ok {
+			workflow, err = h.projectWorkflow(ctx, blocker.ProjectID)
+			if err != nil {
+				return false, err
+			}
+			workflows[blocker.ProjectID] = workflow
+		}
+		if !workflow.IsFinal(blocker.Status) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ListTaskBlockersHandler lists the tasks blocking a task, open or not.
+func (h *Handler) ListTaskBlockersHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Tasks.GetByID(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	blockers, err := h.blockers(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if err := h.setDerivedFields(ctx, blockers); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": blockers})
+}
+
+// AddTaskBlockerHandler makes blockerId block the task. Both tasks must be
+// in the same organization (400) and not archived (422). A dependency that
+// would close a cycle answers 409, one that exists already 204.
+func (h *Handler) AddTaskBlockerHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	blockerID := r.PathValue("blockerId")
+	if id == blockerID {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	var tasks [2]*models.Task
+	var projects [2]*models.Project
+	for i, taskID := range []string{id, blockerID} {
+		task, err := h.Tasks.GetByID(ctx, taskID)
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		project, err := h.Projects.GetByID(ctx, task.ProjectID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		tasks[i], projects[i] = task, project
+	}
+
+	if projects[0].OrganizationID != projects[1].OrganizationID {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if tasks[0].ArchivedAt != nil || tasks[1].ArchivedAt != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	dependency := models.Dependency{
+		OrganizationID:   projects[0].OrganizationID,
+		TaskID:           id,
+		ProjectID:        tasks[0].ProjectID,
+		BlockerID:        blockerID,
+		BlockerProjectID: tasks[1].ProjectID,
+		CreatedBy:        actorFromRequest(r),
+		CreatedAt:        time.Now(),
+	}
+	err := h.Dependencies.Add(ctx, dependency)
+	if err == repositories.ErrDependencyExists {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err == repositories.ErrDependencyCycle {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dependency)
+}
+
+func (h *Handler) RemoveTaskBlockerHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	blockerID := r.PathValue("blockerId")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	task, err := h.Tasks.GetByID(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if task.ArchivedAt != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := h.Dependencies.Remove(ctx, id, blockerID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// criticalPathPageSize is how many open tasks ProjectCriticalPathHandler
+// reads at a time.
+const criticalPathPageSize = 100
+
+// ProjectCriticalPathHandler answers with the heaviest chain of open tasks
+// of the project, each blocking the next, and its total weight. Open tasks
+// are those not in the workflow's final state. Every task weighs 1, or with
+// weight=<key> the value of that number custom field, 0 when missing.
+// Dependencies on tasks of other projects are left out.
+func (h *Handler) ProjectCriticalPathHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	project, err := h.Projects.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	weightKey := r.URL.Query().Get("weight")
+	if weightKey != "" {
+		field, ok := models.FindCustomField(project.CustomFields, weightKey)
+		if !ok || field.Type != models.CustomFieldNumber {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	workflow := project.TaskWorkflow()
+	open := repositories.TaskQuery{Conditions: []repositories.TaskCondition{{
+		Field:  "status",
+		Op:     repositories.OpNe,
+		Values: []interface{}{workflow.Final()},
+	}}}
+	tasks := map[string]models.Task{}
+	weights := map[string]float64{}
+	for page := int64(1); ; page++ {
+		batch, total, err := h.Tasks.ListByProject(ctx, id, open, page, criticalPathPageSize)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for _, task := range batch {
+			tasks[task.ID] = task
+			weights[task.ID] = 1
+			if weightKey != "" {
+				weights[task.ID], _ = task.CustomFields[weightKey].(float64)
+			}
+		}
+		if len(batch) < criticalPathPageSize || page*criticalPathPageSize >= total {
+			break
+		}
+	}
+
+	dependencies, err := h.Dependencies.ListByProject(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ids, length := models.NewDependencyGraph(dependencies).CriticalPath(weights)
+
+	path := make([]models.Task, 0, len(ids))
+	for _, taskID := range ids {
+		path = append(path, tasks[taskID])
+	}
+	if err := h.setDerivedFields(ctx, path); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": path, "length": length})
+}
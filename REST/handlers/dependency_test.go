@@ -0,0 +1,37 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBlockers(t *testing.T) {
+	s := newTestServer(t)
+	projectID := s.newProject()
+	blocked := s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Ship"})
+	blocker := s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Test"})
+
+	if rec := s.do(http.MethodPut, "/tasks/"+blocked+"/blockers/"+blocker, nil, nil); rec.Code != http.StatusCreated {
+		t.Fatalf("add blocker: status %d, want 201", rec.Code)
+	}
+	if rec := s.do(http.MethodPut, "/tasks/"+blocked+"/blockers/"+blocker, nil, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("add blocker again: status %d, want 204", rec.Code)
+	}
+	if rec := s.do(http.MethodPut, "/tasks/"+blocker+"/blockers/"+blocked, nil, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("add a cycle: status %d, want 409", rec.Code)
+	}
+
+	for _, status := range []string{"in-progress", "review"} {
+		s.do(http.MethodPut, "/tasks/"+blocked, map[string]any{"status": status}, nil)
+	}
+	if rec := s.do(http.MethodPut, "/tasks/"+blocked, map[string]any{"status": "done"}, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("complete a blocked task: status %d, want 409", rec.Code)
+	}
+
+	if rec := s.do(http.MethodDelete, "/tasks/"+blocked+"/blockers/"+blocker, nil, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("remove blocker: status %d, want 204", rec.Code)
+	}
+	if rec := s.do(http.MethodPut, "/tasks/"+blocked, map[string]any{"status": "done"}, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("complete an unblocked task: status %d, want 204", rec.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"task-manager/config"
+	"task-manager/repositories"
+)
+
+// Handler serves the task manager API on top of the injected repositories.
+type Handler struct {
+	repositories.Repositories
+
+	// RequestTimeout bounds the database work of one request, JobTimeout
+	// that of a background job.
+	RequestTimeout time.Duration
+	JobTimeout     time.Duration
+
+	// CursorSecret signs pagination cursors.
+	CursorSecret []byte
+
+	// BulkMaxTasks is the most tasks one bulk request may change.
+	BulkMaxTasks int
+
+	// ChangeFeedsDisabled has the change feed endpoints answer 503, for
+	// databases that cannot serve change streams.
+	ChangeFeedsDisabled bool
+}
+
+func New(repos repositories.Repositories, cfg *config.Config) *Handler {
+	secret := []byte(cfg.Pagination.CursorSecret)
+	if len(secret) == 0 {
+		// Cursors then only work until the next restart
+		secret = make([]byte, 32)
+		rand.Read(secret)
+	}
+
+	return &Handler{
+		Repositories:   repos,
+		RequestTimeout: cfg.HTTP.RequestTimeout,
+		JobTimeout:     cfg.Jobs.Timeout,
+		CursorSecret:   secret,
+		BulkMaxTasks:   cfg.Bulk.MaxTasks,
+	}
+}
+
+// writeConflict answers 409 naming the field whose value is taken, so
+// clients can point at it.
+func writeConflict(w http.ResponseWriter, conflict *repositories.ConflictError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error": conflict.Error(),
+		"field": conflict.Field,
+	})
+}
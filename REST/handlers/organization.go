@@ -3,7 +3,10 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -16,17 +19,17 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func GetOrganizationByIDHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetOrganizationByIDHandler(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/organizations/")
 	if id == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
 	defer cancel()
 
-	org, err := repositories.GetOrganizationByID(ctx, id)
+	org, err := h.Organizations.GetByID(ctx, id)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			w.WriteHeader(http.StatusNotFound)
@@ -36,18 +39,16 @@ func GetOrganizationByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, org.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(org)
 }
 
-func ListOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
+// ListOrganizationsHandler pages by offset unless the client passes a
+// cursor, an empty one asking for the first page by cursor.
+func (h *Handler) ListOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
-	page, err := strconv.ParseInt(query.Get("page"), 10, 64)
-	if err != nil || page <= 0 {
-		page = 1
-	}
-
 	limit, err := strconv.ParseInt(query.Get("limit"), 10, 64)
 	if err != nil || limit <= 0 || limit > 100 {
 		limit = 10
@@ -58,10 +59,99 @@ func ListOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
 		status = &s
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	if !query.Has("cursor") {
+		h.listOrganizationsByOffset(w, r, limit, status)
+		return
+	}
+
+	keysetQuery := repositories.KeysetQuery{Limit: limit + 1, Status: status}
+	if c := query.Get("cursor"); c != "" {
+		cursor, err := h.decodeCursor(c)
+		if err != nil || cursor.Status != query.Get("status") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if cursor.Before {
+			keysetQuery.Before = cursor.keyset()
+		} else {
+			keysetQuery.After = cursor.keyset()
+		}
+	}
+	withCount := query.Get("count") == "true"
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	orgs, err := h.Organizations.ListKeyset(ctx, keysetQuery)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// One extra document tells whether there is more in the paging
+	// direction. Paging backwards it is the newest one.
+	more := int64(len(orgs)) > limit
+	if more {
+		if keysetQuery.Before != nil {
+			orgs = orgs[1:]
+		} else {
+			orgs = orgs[:limit]
+		}
+	}
+
+	// Coming from a cursor there is always something to go back to.
+	hasNext, hasPrev := more, keysetQuery.After != nil
+	if keysetQuery.Before != nil {
+		hasNext, hasPrev = true, more
+	}
+
+	pagination := map[string]interface{}{
+		"limit": limit,
+		"next":  nil,
+		"prev":  nil,
+	}
+	if len(orgs) > 0 {
+		last, first := orgs[len(orgs)-1], orgs[0]
+		if hasNext {
+			pagination["next"] = h.pageLink(r, limit, withCount, pageCursor{
+				CreatedAt: last.CreatedAt, ID: last.ID, Status: query.Get("status"),
+			})
+		}
+		if hasPrev {
+			pagination["prev"] = h.pageLink(r, limit, withCount, pageCursor{
+				CreatedAt: first.CreatedAt, ID: first.ID, Before: true, Status: query.Get("status"),
+			})
+		}
+	}
+
+	if withCount {
+		total, err := h.Organizations.Count(ctx, status)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		pagination["total"] = total
+	}
+
+	response := map[string]interface{}{
+		"data":       orgs,
+		"pagination": pagination,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) listOrganizationsByOffset(w http.ResponseWriter, r *http.Request, limit int64, status *string) {
+	page, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
 	defer cancel()
 
-	orgs, total, err := repositories.ListOrganizations(ctx, page, limit, status)
+	orgs, total, err := h.Organizations.List(ctx, page, limit, status)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -80,53 +170,92 @@ func ListOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// pageLink keeps the request's filters and replaces the cursor.
+func (h *Handler) pageLink(r *http.Request, limit int64, withCount bool, cursor pageCursor) string {
+	query := url.Values{}
+	if cursor.Status != "" {
+		query.Set("status", cursor.Status)
+	}
+	query.Set("limit", strconv.FormatInt(limit, 10))
+	query.Set("cursor", h.encodeCursor(cursor))
+	if withCount {
+		query.Set("count", "true")
+	}
+	return r.URL.Path + "?" + query.Encode()
+}
+
 type CreateOrganizationRequest struct {
-	Name        string  `json:"name"`
-	Status      string  `json:"status"`
-	Description *string `json:"description,omitempty"`
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	Description *string  `json:"description,omitempty"`
+	Members     []string `json:"members,omitempty"`
 }
 
-func CreateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
 	var req CreateOrganizationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	if req.Name == "" || (req.Status != "active" && req.Status != "archived") {
+	if req.Name == "" || (req.Status != "active" && req.Status != "archived") || !validMembers(req.Members) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
+	// The creator may follow the organization and grant others access
+	members := req.Members
+	if caller := r.Header.Get("X-User-ID"); caller != "" && !slices.Contains(members, caller) {
+		members = append(members, caller)
+	}
+
 	now := time.Now()
 	org := models.Organization{
 		ID:          primitive.NewObjectID().Hex(),
 		Name:        req.Name,
 		Status:      req.Status,
 		Description: req.Description,
+		Members:     members,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
+	if org.Status == models.OrganizationStatusArchived {
+		org.ArchivedAt = &now
+	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
 	defer cancel()
 
-	if err := repositories.CreateOrganization(ctx, org); err != nil {
+	err := h.Organizations.Create(ctx, org)
+	var conflict *repositories.ConflictError
+	if errors.As(err, &conflict) {
+		writeConflict(w, conflict)
+		return
+	}
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	setETag(w, org.Version)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(org)
 }
 
+// UpdateOrganizationRequest replaces the members when Members is given, an
+// empty list removes them all.
 type UpdateOrganizationRequest struct {
-	Name        *string `json:"name,omitempty"`
-	Status      *string `json:"status,omitempty"`
-	Description *string `json:"description,omitempty"`
+	Name        *string  `json:"name,omitempty"`
+	Status      *string  `json:"status,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Members     []string `json:"members,omitempty"`
 }
 
-func UpdateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+// UpdateOrganizationHandler answers 412 when the organization does not
+// match If-Match, or changes before the update applies, and 422 when it is
+// archived or the update would archive it. Only members in X-User-ID may
+// replace the members, others get 403.
+func (h *Handler) UpdateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/organizations/")
 	if id == "" {
 		w.WriteHeader(http.StatusBadRequest)
@@ -151,39 +280,240 @@ func UpdateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		update["status"] = *req.Status
+		// Archiving has its own endpoint, and an organization that is
+		// not archived is active already
+		if *req.Status == "archived" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
 	}
 	if req.Description != nil {
 		update["description"] = *req.Description
 	}
+	var unset []string
+	if !validMembers(req.Members) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(req.Members) > 0 {
+		update["members"] = req.Members
+	} else if req.Members != nil {
+		unset = append(unset, "members")
+	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
 	defer cancel()
 
-	err := repositories.UpdateOrganization(ctx, id, update)
+	// Changing the members is checked against the version it applies to,
+	// so a concurrent change of the members cannot slip in between
+	var ifVersion *int64
+	if hasIfMatch(r) || req.Members != nil {
+		org, err := h.Organizations.GetByID(ctx, id)
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !ifMatch(r, org.Version) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if req.Members != nil && !org.IsMember(r.Header.Get("X-User-ID")) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		ifVersion = &org.Version
+	}
+
+	err := h.Organizations.Patch(ctx, id, repositories.Patch{Set: update, Unset: unset, IfVersion: ifVersion})
 	if err == mongo.ErrNoDocuments {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	if err == repositories.ErrArchived {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+	if err == repositories.ErrVersionConflict {
+		w.WriteHeader(patchVersionConflict(r))
+		return
+	}
+	var conflict *repositories.ConflictError
+	if errors.As(err, &conflict) {
+		writeConflict(w, conflict)
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	// The update applied to exactly that version
+	if ifVersion != nil {
+		setETag(w, *ifVersion+1)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func DeleteOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+// The status changes only by archiving and unarchiving.
+var organizationPatchFields = map[string]patchField{
+	"name":        {Required: true},
+	"description": {},
+	"members": {Value: func(value interface{}) (interface{}, error) {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, errInvalidMembers
+		}
+		members := make([]string, 0, len(items))
+		for _, item := range items {
+			member, ok := item.(string)
+			if !ok {
+				return nil, errInvalidMembers
+			}
+			members = append(members, member)
+		}
+		if !validMembers(members) {
+			return nil, errInvalidMembers
+		}
+		if len(members) == 0 {
+			return nil, nil
+		}
+		return members, nil
+	}},
+}
+
+var errInvalidMembers = errors.New("invalid members")
+
+// validMembers tells whether members names users, none of them twice.
+func validMembers(members []string) bool {
+	for i, member := range members {
+		if member == "" || slices.Contains(members[:i], member) {
+			return false
+		}
+	}
+	return true
+}
+
+// PatchOrganizationHandler applies a merge patch or JSON Patch to the
+// organization and answers with the result. See readPatch and
+// patchVersionConflict for the statuses. Only members in X-User-ID may
+// change the members, others get 403.
+func (h *Handler) PatchOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	org, err := h.Organizations.GetByID(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !ifMatch(r, org.Version) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	current, err := patchDocument(org)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	patch, status := readPatch(r, current, organizationPatchFields)
+	if status != 0 {
+		writePatchError(w, status)
+		return
+	}
+	if _, ok := patch.Set["members"]; (ok || slices.Contains(patch.Unset, "members")) && !org.IsMember(r.Header.Get("X-User-ID")) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if !patch.Empty() {
+		patch.Set["updatedAt"] = time.Now()
+		patch.IfVersion = &org.Version
+		err = h.Organizations.Patch(ctx, id, patch)
+		if err == nil {
+			org, err = h.Organizations.GetByID(ctx, id)
+		}
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err == repositories.ErrArchived {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		if err == repositories.ErrVersionConflict {
+			w.WriteHeader(patchVersionConflict(r))
+			return
+		}
+		var conflict *repositories.ConflictError
+		if errors.As(err, &conflict) {
+			writeConflict(w, conflict)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	setETag(w, org.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
+
+// DeleteOrganizationHandler moves the organization with its projects and
+// tasks to the trash. With ?async=true it answers 202 and a job to poll at
+// /jobs/{id}, which suits organizations too large to delete within a
+// request.
+func (h *Handler) DeleteOrganizationHandler(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/organizations/")
 	if id == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
 	defer cancel()
 
-	err := repositories.DeleteOrganization(ctx, id)
+	if r.URL.Query().Get("async") == "true" {
+		if _, err := h.Organizations.GetByID(ctx, id); err != nil {
+			if err == mongo.ErrNoDocuments {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		job, err := h.startOrganizationDelete(ctx, id)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/jobs/"+job.ID)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+		return
+	}
+
+	_, err := h.Organizations.SoftDelete(ctx, id, time.Now())
 	if err == mongo.ErrNoDocuments {
 		w.WriteHeader(http.StatusNotFound)
 		return
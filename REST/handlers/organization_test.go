@@ -0,0 +1,144 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestOrganizations(t *testing.T) {
+	s := newTestServer(t)
+	id := s.create("/organizations", map[string]any{"name": "Acme", "status": "active"})
+
+	var org struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+	}
+	rec := s.do(http.MethodGet, "/organizations/"+id, nil, &org)
+	if rec.Code != http.StatusOK || org.Name != "Acme" || org.Status != "active" {
+		t.Fatalf("get: status %d, organization %+v", rec.Code, org)
+	}
+	if rec.Header().Get("ETag") == "" {
+		t.Fatal("get answers no ETag")
+	}
+
+	var conflict struct {
+		Field string `json:"field"`
+	}
+	rec = s.do(http.MethodPost, "/organizations", map[string]any{"name": "Acme", "status": "active"}, &conflict)
+	if rec.Code != http.StatusConflict || conflict.Field != "name" {
+		t.Fatalf("taken name: status %d, field %q, want 409 on name", rec.Code, conflict.Field)
+	}
+
+	if rec := s.do(http.MethodPost, "/organizations", map[string]any{"name": "Other", "status": "closed"}, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid status: status %d, want 400", rec.Code)
+	}
+	if rec := s.do(http.MethodPost, "/organizations", map[string]any{"name": "Other", "status": "active", "members": []string{"alice", "alice"}}, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("duplicate member: status %d, want 400", rec.Code)
+	}
+
+	if rec := s.do(http.MethodDelete, "/organizations/"+id, nil, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status %d, want 204", rec.Code)
+	}
+	if rec := s.do(http.MethodGet, "/organizations/"+id, nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("get after delete: status %d, want 404", rec.Code)
+	}
+}
+
+func TestDeleteOrganizationAsync(t *testing.T) {
+	s := newTestServer(t)
+	id := s.create("/organizations", map[string]any{"name": "Acme", "status": "active"})
+	for _, name := range []string{"Launch", "Ops"} {
+		projectID := s.create("/organizations/"+id+"/projects", map[string]any{"name": name})
+		s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Plan"})
+	}
+
+	if rec := s.do(http.MethodDelete, "/organizations/missing?async=true", nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("async delete of a missing organization: status %d, want 404", rec.Code)
+	}
+
+	type job struct {
+		ID       string `json:"id"`
+		Status   string `json:"status"`
+		Progress struct {
+			Projects int64 `json:"projects"`
+			Tasks    int64 `json:"tasks"`
+		} `json:"progress"`
+	}
+	var started job
+	rec := s.do(http.MethodDelete, "/organizations/"+id+"?async=true", nil, &started)
+	if rec.Code != http.StatusAccepted || rec.Header().Get("Location") != "/jobs/"+started.ID {
+		t.Fatalf("async delete: status %d, Location %q, want 202 and /jobs/%s", rec.Code, rec.Header().Get("Location"), started.ID)
+	}
+
+	var got job
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if rec := s.do(http.MethodGet, "/jobs/"+started.ID, nil, &got); rec.Code != http.StatusOK {
+			t.Fatalf("get job: status %d", rec.Code)
+		}
+		if got.Status == "completed" || got.Status == "failed" || time.Now().After(deadline) {
+			break
+		}
+	}
+	if got.Status != "completed" || got.Progress.Projects != 2 || got.Progress.Tasks != 2 {
+		t.Fatalf("job = %+v, want completed with 2 projects and 2 tasks", got)
+	}
+
+	if rec := s.do(http.MethodGet, "/organizations/"+id, nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("get after async delete: status %d, want 404", rec.Code)
+	}
+	if rec := s.do(http.MethodGet, "/jobs/missing", nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("get missing job: status %d, want 404", rec.Code)
+	}
+}
+
+func TestListOrganizations(t *testing.T) {
+	s := newTestServer(t)
+	for _, name := range []string{"One", "Two", "Three"} {
+		s.create("/organizations", map[string]any{"name": name, "status": "active"})
+	}
+
+	var page struct {
+		Data       []map[string]any `json:"data"`
+		Pagination struct {
+			Page  int64 `json:"page"`
+			Limit int64 `json:"limit"`
+			Total int64 `json:"total"`
+		} `json:"pagination"`
+	}
+	if rec := s.do(http.MethodGet, "/organizations?limit=2", nil, &page); rec.Code != http.StatusOK {
+		t.Fatalf("list: status %d", rec.Code)
+	}
+	if len(page.Data) != 2 || page.Pagination.Page != 1 || page.Pagination.Total != 3 {
+		t.Fatalf("default page = %+v, want the first of 3 organizations by offset", page)
+	}
+	if rec := s.do(http.MethodGet, "/organizations?page=2&limit=2", nil, &page); rec.Code != http.StatusOK {
+		t.Fatalf("list: status %d", rec.Code)
+	}
+	if len(page.Data) != 1 || page.Pagination.Page != 2 || page.Pagination.Total != 3 {
+		t.Fatalf("second page = %+v, want the last of 3 organizations", page)
+	}
+
+	var first, second struct {
+		Data       []map[string]any `json:"data"`
+		Pagination struct {
+			Next *string `json:"next"`
+		} `json:"pagination"`
+	}
+	if rec := s.do(http.MethodGet, "/organizations?cursor=&limit=2", nil, &first); rec.Code != http.StatusOK {
+		t.Fatalf("list by cursor: status %d", rec.Code)
+	}
+	if len(first.Data) != 2 || first.Pagination.Next == nil {
+		t.Fatalf("first cursor page = %+v, want 2 organizations and a next link", first)
+	}
+	if rec := s.do(http.MethodGet, *first.Pagination.Next, nil, &second); rec.Code != http.StatusOK {
+		t.Fatalf("next page: status %d", rec.Code)
+	}
+	if len(second.Data) != 1 || second.Pagination.Next != nil {
+		t.Fatalf("second cursor page = %+v, want the last organization", second)
+	}
+
+	if rec := s.do(http.MethodGet, "/organizations?cursor=forged", nil, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("forged cursor: status %d, want 400", rec.Code)
+	}
+}
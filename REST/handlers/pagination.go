@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// parsePage reads the page and limit query parameters, falling back to page
+// 1 and a limit of 10 when they are missing or out of range.
+func parsePage(query url.Values) (int64, int64) {
+	page, err := strconv.ParseInt(query.Get("page"), 10, 64)
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	limit, err := strconv.ParseInt(query.Get("limit"), 10, 64)
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	return page, limit
+}
+
+func paginatedResponse(data interface{}, page int64, limit int64, total int64) map[string]interface{} {
+	return map[string]interface{}{
+		"data": data,
+		"pagination": map[string]interface{}{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStatsCountOverdueTasks(t *testing.T) {
+	s := newTestServer(t)
+	orgID := s.create("/organizations", map[string]any{"name": "Acme", "status": "active"})
+	projectID := s.create("/organizations/"+orgID+"/projects", map[string]any{"name": "Launch"})
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Late", "dueAt": past})
+	finished := s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Finished", "dueAt": past})
+	for _, status := range []string{"in-progress", "review", "done"} {
+		if rec := s.do(http.MethodPut, "/tasks/"+finished, map[string]any{"status": status}, nil); rec.Code != http.StatusNoContent {
+			t.Fatalf("move task to %s: status %d", status, rec.Code)
+		}
+	}
+	s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Undated"})
+	later := s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Later", "dueAt": future})
+
+	type counts struct {
+		Tasks struct {
+			Total   int64 `json:"total"`
+			Overdue int64 `json:"overdue"`
+		} `json:"tasks"`
+	}
+	overdue := func() (project int64, org int64) {
+		t.Helper()
+
+		var stats counts
+		if rec := s.do(http.MethodGet, "/projects/"+projectID+"/stats", nil, &stats); rec.Code != http.StatusOK || stats.Tasks.Total != 4 {
+			t.Fatalf("project stats: status %d, stats %+v", rec.Code, stats)
+		}
+		project = stats.Tasks.Overdue
+
+		stats = counts{}
+		if rec := s.do(http.MethodGet, "/organizations/"+orgID+"/stats", nil, &stats); rec.Code != http.StatusOK || stats.Tasks.Total != 4 {
+			t.Fatalf("organization stats: status %d, stats %+v", rec.Code, stats)
+		}
+		return project, stats.Tasks.Overdue
+	}
+
+	if project, org := overdue(); project != 1 || org != 1 {
+		t.Fatalf("overdue = %d in the project, %d in the organization, want 1", project, org)
+	}
+
+	if rec := s.do(http.MethodPatch, "/tasks/"+later, map[string]any{"dueAt": past}, nil, "Content-Type", "application/merge-patch+json"); rec.Code != http.StatusOK {
+		t.Fatalf("patch due date: status %d, body %q", rec.Code, rec.Body.String())
+	}
+	if project, org := overdue(); project != 2 || org != 2 {
+		t.Fatalf("overdue after moving a due date = %d in the project, %d in the organization, want 2", project, org)
+	}
+}
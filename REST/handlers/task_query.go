@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+)
+
+// Query parameters of task listings that are not filters.
+var taskListParams = map[string]bool{
+	"page":  true,
+	"limit": true,
+	"sort":  true,
+	"view":  true,
+}
+
+var errNoUser = errors.New(`"me" needs the X-User-ID header`)
+
+// parseTaskQuery reads the filter grammar of task listings:
+//
+//	status=done                        equal, same as status[eq]=done
+//	status[ne]=done                    not equal
+//	priority[in]=high,urgent           one of, [nin] for none of
+//	updatedAt[gte]=-7d                 range with gt, gte, lt and lte
+//	assignedTo[exists]=false           whether the field is set
+//	labels[in]=<id>,<id>               tasks carrying any of the labels
+//	customFields.estimate[gt]=3        custom fields by key
+//	sort=-priority,createdAt           sort keys, "-" for descending
+//
+// Times are RFC 3339, a date like 2024-01-31, or a duration back from now
+// like -36h, -7d or -2w. assignedTo=me stands for the user in X-User-ID.
+// Priority sorts from low to urgent, not by name.
+// Custom field values stay strings until typeCustomFieldQuery knows the
+// project's fields. Parameters that are not fields are ignored, unknown
+// fields in brackets are an error.
+func parseTaskQuery(values url.Values, now time.Time, userID string) (repositories.TaskQuery, error) {
+	var query repositories.TaskQuery
+
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		vals := values[key]
+		if taskListParams[key] {
+			continue
+		}
+
+		field, op := key, repositories.OpEq
+		if name, rest, ok := strings.Cut(key, "["); ok {
+			if !strings.HasSuffix(rest, "]") {
+				return query, fmt.Errorf("%w: bad parameter %q", repositories.ErrInvalidTaskQuery, key)
+			}
+			field, op = name, strings.TrimSuffix(rest, "]")
+			if !repositories.IsTaskQueryField(field) {
+				return query, fmt.Errorf("%w: unknown field %q", repositories.ErrInvalidTaskQuery, field)
+			}
+		} else if !repositories.IsTaskQueryField(field) {
+			continue
+		}
+
+		if len(vals) != 1 {
+			return query, fmt.Errorf("%w: %s given more than once", repositories.ErrInvalidTaskQuery, key)
+		}
+
+		condition := repositories.TaskCondition{Field: field, Op: op}
+		switch op {
+		case repositories.OpExists:
+			exists, err := strconv.ParseBool(vals[0])
+			if err != nil {
+				return query, fmt.Errorf("%w: %s takes true or false", repositories.ErrInvalidTaskQuery, key)
+			}
+			condition.Exists = exists
+		case repositories.OpIn, repositories.OpNin:
+			for _, raw := range strings.Split(vals[0], ",") {
+				value, err := parseTaskQueryValue(field, raw, now, userID)
+				if err != nil {
+					return query, err
+				}
+				condition.Values = append(condition.Values, value)
+			}
+		default:
+			value, err := parseTaskQueryValue(field, vals[0], now, userID)
+			if err != nil {
+				return query, err
+			}
+			condition.Values = []interface{}{value}
+		}
+
+		query.Conditions = append(query.Conditions, condition)
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		for _, key := range strings.Split(sort, ",") {
+			field, desc := strings.CutPrefix(key, "-")
+			query.Sort = append(query.Sort, repositories.TaskSort{Field: field, Desc: desc})
+		}
+	}
+
+	return query, query.Validate()
+}
+
+// checkTaskQueryValues rejects statuses the workflow does not have and
+// unknown priorities, which could never match.
+func checkTaskQueryValues(query repositories.TaskQuery, workflow models.Workflow) bool {
+	for _, c := range query.Conditions {
+		for _, value := range c.Values {
+			switch c.Field {
+			case "status":
+				if !workflow.HasState(value.(string)) {
+					return false
+				}
+			case "priority":
+				if !models.IsValidTaskPriority(value.(string)) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func parseTaskQueryValue(field string, raw string, now time.Time, userID string) (interface{}, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("%w: empty value for %s", repositories.ErrInvalidTaskQuery, field)
+	}
+
+	if field == "assignedTo" && raw == "me" {
+		if userID == "" {
+			return nil, errNoUser
+		}
+		return userID, nil
+	}
+
+	if !repositories.IsTaskTimeField(field) {
+		return raw, nil
+	}
+
+	if t, ok := parseQueryTime(raw, now); ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("%w: bad time %q for %s", repositories.ErrInvalidTaskQuery, raw, field)
+}
+
+func parseQueryTime(raw string, now time.Time) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, true
+	}
+	if ago, ok := strings.CutPrefix(raw, "-"); ok {
+		if d, err := parseQueryDuration(ago); err == nil {
+			return now.Add(-d), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// typeCustomFieldQuery turns the values of conditions on custom fields into
+// the fields' types. Number fields take numbers, date fields any time
+// parseTaskQueryValue takes, cut to its date. Fields the project does not
+// define are an error, in conditions and sort keys alike.
+func typeCustomFieldQuery(query repositories.TaskQuery, fields []models.CustomField, now time.Time) error {
+	defined := func(field string) (models.CustomField, error) {
+		key, _ := repositories.CustomFieldKey(field)
+		if f, ok := models.FindCustomField(fields, key); ok {
+			return f, nil
+		}
+		return models.CustomField{}, fmt.Errorf("%w: unknown custom field %q", repositories.ErrInvalidTaskQuery, key)
+	}
+
+	for _, s := range query.Sort {
+		if _, ok := repositories.CustomFieldKey(s.Field); ok {
+			if _, err := defined(s.Field); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, c := range query.Conditions {
+		if _, ok := repositories.CustomFieldKey(c.Field); !ok {
+			continue
+		}
+		field, err := defined(c.Field)
+		if err != nil {
+			return err
+		}
+
+		for i, value := range c.Values {
+			raw := value.(string)
+			switch field.Type {
+			case models.CustomFieldNumber:
+				n, err := strconv.ParseFloat(raw, 64)
+				if err != nil {
+					return fmt.Errorf("%w: bad number %q for %s", repositories.ErrInvalidTaskQuery, raw, c.Field)
+				}
+				c.Values[i] = n
+			case models.CustomFieldDate:
+				t, ok := parseQueryTime(raw, now)
+				if !ok {
+					return fmt.Errorf("%w: bad date %q for %s", repositories.ErrInvalidTaskQuery, raw, c.Field)
+				}
+				c.Values[i] = t.Format(time.DateOnly)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseQueryDuration accepts Go durations plus whole days and weeks.
+func parseQueryDuration(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.ParseUint(n, 10, 16)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,116 @@
+package handlers_test
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestTaskWorkflow(t *testing.T) {
+	s := newTestServer(t)
+	projectID := s.newProject()
+	id := s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Write docs"})
+	task := "/tasks/" + id
+
+	if rec := s.do(http.MethodPut, task, map[string]any{"status": "done"}, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("pending to done: status %d, want 409", rec.Code)
+	}
+	if rec := s.do(http.MethodPut, task, map[string]any{"status": "shipped"}, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown status: status %d, want 400", rec.Code)
+	}
+	for _, status := range []string{"in-progress", "review", "done"} {
+		if rec := s.do(http.MethodPut, task, map[string]any{"status": status}, nil); rec.Code != http.StatusNoContent {
+			t.Fatalf("move to %s: status %d, want 204", status, rec.Code)
+		}
+	}
+
+	var got struct {
+		Status             string   `json:"status"`
+		AllowedTransitions []string `json:"allowedTransitions"`
+	}
+	s.do(http.MethodGet, task, nil, &got)
+	if got.Status != "done" || len(got.AllowedTransitions) != 1 || got.AllowedTransitions[0] != "in-progress" {
+		t.Fatalf("task = %+v, want done and only reopenable", got)
+	}
+}
+
+func TestTaskPreconditions(t *testing.T) {
+	s := newTestServer(t)
+	projectID := s.newProject()
+	id := s.create("/projects/"+projectID+"/tasks", map[string]any{"title": "Write docs"})
+	task := "/tasks/" + id
+
+	etag := s.do(http.MethodGet, task, nil, nil).Header().Get("ETag")
+
+	var patched struct {
+		Title    string `json:"title"`
+		Priority string `json:"priority"`
+	}
+	rec := s.do(http.MethodPatch, task, map[string]any{"priority": "high"}, &patched,
+		"Content-Type", "application/merge-patch+json", "If-Match", etag)
+	if rec.Code != http.StatusOK || patched.Priority != "high" || patched.Title != "Write docs" {
+		t.Fatalf("patch: status %d, task %+v", rec.Code, patched)
+	}
+	if rec.Header().Get("ETag") == etag {
+		t.Fatal("patch kept the ETag")
+	}
+
+	rec = s.do(http.MethodPatch, task, map[string]any{"priority": "low"}, nil,
+		"Content-Type", "application/merge-patch+json", "If-Match", etag)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("patch with a stale ETag: status %d, want 412", rec.Code)
+	}
+	if rec := s.do(http.MethodPut, task, map[string]any{"title": "Stale"}, nil, "If-Match", etag); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("put with a stale ETag: status %d, want 412", rec.Code)
+	}
+
+	if rec := s.do(http.MethodGet, "/tasks/000000000000000000000000", nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing task: status %d, want 404", rec.Code)
+	}
+}
+
+func TestListTasks(t *testing.T) {
+	s := newTestServer(t)
+	projectID := s.newProject()
+	for _, priority := range []string{"low", "high", "medium"} {
+		s.create("/projects/"+projectID+"/tasks", map[string]any{"title": priority, "priority": priority})
+	}
+
+	var page struct {
+		Data []struct {
+			Priority string `json:"priority"`
+		} `json:"data"`
+		Pagination struct {
+			Total int64 `json:"total"`
+		} `json:"pagination"`
+	}
+	rec := s.do(http.MethodGet, "/projects/"+projectID+"/tasks?priority[in]=high,medium", nil, &page)
+	if rec.Code != http.StatusOK || page.Pagination.Total != 2 || len(page.Data) != 2 {
+		t.Fatalf("filter by priority: status %d, page %+v", rec.Code, page)
+	}
+	if rec := s.do(http.MethodGet, "/projects/"+projectID+"/tasks?status=shipped", nil, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown status filter: status %d, want 400", rec.Code)
+	}
+}
+
+func TestSortTasksByPriority(t *testing.T) {
+	s := newTestServer(t)
+	projectID := s.newProject()
+	for _, priority := range []string{"medium", "urgent", "low", "high"} {
+		s.create("/projects/"+projectID+"/tasks", map[string]any{"title": priority, "priority": priority})
+	}
+
+	var page struct {
+		Data []struct {
+			Priority string `json:"priority"`
+		} `json:"data"`
+	}
+	s.do(http.MethodGet, "/projects/"+projectID+"/tasks?sort=-priority", nil, &page)
+	got := []string{}
+	for _, task := range page.Data {
+		got = append(got, task.Priority)
+	}
+	if want := []string{"urgent", "high", "medium", "low"}; !slices.Equal(got, want) {
+		t.Fatalf("priorities = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// validViewQuery checks a view's query string against the task filter
+// grammar. Statuses depend on the project's workflow and are only checked
+// when the view is used.
+func validViewQuery(query string) bool {
+	values, err := url.ParseQuery(query)
+	if err != nil || values.Has("view") {
+		return false
+	}
+
+	// Any user will do, "me" is resolved when the view is used
+	_, err = parseTaskQuery(values, time.Now(), "me")
+	return err == nil
+}
+
+func (h *Handler) ListViewsHandler(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("orgId")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Organizations.GetByID(ctx, orgID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	views, err := h.Views.ListByOrganization(ctx, orgID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": views})
+}
+
+func (h *Handler) GetViewByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	view, err := h.Views.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+type CreateViewRequest struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// CreateViewHandler answers 409 when the organization already has a view
+// with that name.
+func (h *Handler) CreateViewHandler(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("orgId")
+
+	var req CreateViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" || !validViewQuery(req.Query) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Organizations.GetByID(ctx, orgID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	view := models.View{
+		ID:             primitive.NewObjectID().Hex(),
+		OrganizationID: orgID,
+		Name:           req.Name,
+		Query:          req.Query,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if actor := r.Header.Get("X-User-ID"); actor != "" {
+		view.CreatedBy = &actor
+	}
+
+	err := h.Views.Create(ctx, view)
+	if errors.Is(err, repositories.ErrViewNameTaken) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(view)
+}
+
+type UpdateViewRequest struct {
+	Name  *string `json:"name,omitempty"`
+	Query *string `json:"query,omitempty"`
+}
+
+func (h *Handler) UpdateViewHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req UpdateViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	update := bson.M{
+		"updatedAt": time.Now(),
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		update["name"] = *req.Name
+	}
+	if req.Query != nil {
+		if !validViewQuery(*req.Query) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		update["query"] = *req.Query
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	err := h.Views.Update(ctx, id, update)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrViewNameTaken) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteViewHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	err := h.Views.Delete(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,54 @@
+package purge_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/purge"
+	"task-manager/repositories/memory"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestPurgeOrganization(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	deletedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	org := models.Organization{ID: "org", Name: "Acme", Status: models.OrganizationStatusActive, CreatedAt: deletedAt}
+	if err := repos.Organizations.Create(ctx, org); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	for _, id := range []string{"first", "second"} {
+		project := models.Project{ID: id, Name: id, OrganizationID: org.ID, CreatedAt: deletedAt}
+		if err := repos.Projects.Create(ctx, project); err != nil {
+			t.Fatalf("create project: %v", err)
+		}
+		task := models.Task{ID: id + "-task", Title: id, ProjectID: id, Status: models.TaskStatusPending, CreatedAt: deletedAt}
+		if err := repos.Tasks.Create(ctx, task); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+	if _, err := repos.Organizations.SoftDelete(ctx, org.ID, deletedAt); err != nil {
+		t.Fatalf("delete organization: %v", err)
+	}
+
+	// The organization goes first, its projects one by one on the next run
+	for run, want := range []int{1, 2, 0} {
+		purged, err := purge.Purge(ctx, repos, deletedAt.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if purged != want {
+			t.Fatalf("run %d purged %d items, want %d", run, purged, want)
+		}
+	}
+
+	for _, id := range []string{"first-task", "second-task"} {
+		if err := repos.Tasks.Restore(ctx, id); err != mongo.ErrNoDocuments {
+			t.Fatalf("restore task %s: %v, want it purged", id, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Package reminders sends reminders ahead of tasks' due dates and notices
+// when tasks become overdue.
+package reminders
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/config"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// leaseName is the lease that makes one replica at a time the scheduler.
+const leaseName = "reminders"
+
+// batchSize bounds how many tasks each run handles, the rest waits for the
+// next run.
+const batchSize = 100
+
+// Run looks for due notifications every cfg.Interval until ctx is done,
+// whenever it holds the scheduler lease.
+func Run(ctx context.Context, repos repositories.Repositories, cfg config.Reminders, notifier Notifier) {
+	holder := holderID()
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		// The run ends well before the lease, which it renews, runs out
+		runCtx, cancel := context.WithTimeout(ctx, cfg.Interval)
+		held, err := repos.Leases.Acquire(runCtx, leaseName, holder, cfg.LeaseTTL)
+		if err != nil {
+			log.Printf("reminders: %v", err)
+		} else if held {
+			sent, err := Send(runCtx, repos, notifier, time.Now())
+			if err != nil {
+				log.Printf("reminders: %v", err)
+			}
+			if sent > 0 {
+				log.Printf("reminders: sent %d notifications", sent)
+			}
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			releaseCtx, cancel := context.WithTimeout(context.Background(), cfg.Interval)
+			if err := repos.Leases.Release(releaseCtx, leaseName, holder); err != nil {
+				log.Printf("reminders: %v", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Send sends what is due at now for up to batchSize tasks and returns how
+// many notifications it sent. Every notification is recorded before it is
+// sent, under an ID naming the task, due date and reminder, so restarts
+// and replicas never send one twice. A notification that fails to send is
+// logged and forgotten, and the task keeps its schedule, so the next run
+// tries again.
+//
+// Tasks in the final state of their workflow get no notifications, their
+// schedule moves on as though they had been sent. A task whose project
+// cannot be read is logged and skipped, and loses its schedule when the
+// project is gone.
+func Send(ctx context.Context, repos repositories.Repositories, notifier Notifier, now time.Time) (int, error) {
+	tasks, err := repos.Tasks.ListNotifiable(ctx, now, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	workflows := map[string]models.Workflow{}
+	sent := 0
+	for _, task := range tasks {
+		workflow, ok := workflows[task.ProjectID]
+		if !ok {
+			project, err := repos.Projects.GetByID(ctx, task.ProjectID)
+			if err != nil {
+				log.Printf("reminders: task %s: project %s: %v", task.ID, task.ProjectID, err)
+				if err == mongo.ErrNoDocuments {
+					if _, err := repos.Tasks.SetNotifyAt(ctx, task.ID, *task.NotifyAt, nil); err != nil {
+						return sent, err
+					}
+				}
+				continue
+			}
+			workflow = project.TaskWorkflow()
+			workflows[task.ProjectID] = workflow
+		}
+
+		notification, next := due(task, now)
+		if notification != nil && !workflow.IsFinal(task.Status) {
+			err := repos.Notifications.Record(ctx, *notification)
+			switch {
+			case errors.Is(err, repositories.ErrAlreadySent):
+			case err != nil:
+				return sent, err
+			default:
+				if err := notifier.Notify(ctx, *notification); err != nil {
+					log.Printf("reminders: task %s: %v", task.ID, err)
+					if err := repos.Notifications.Forget(ctx, notification.ID); err != nil {
+						return sent, err
+					}
+					continue
+				}
+				sent++
+			}
+		}
+
+		// A task changed meanwhile has a new schedule already
+		if _, err := repos.Tasks.SetNotifyAt(ctx, task.ID, *task.NotifyAt, next); err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// due returns what task has due at now, if anything, and when its next
+// notification is due, nil when it has none left.
+func due(task models.Task, now time.Time) (*models.Notification, *time.Time) {
+	if task.DueAt == nil {
+		return nil, nil
+	}
+
+	notification := models.Notification{
+		Type:       models.NotificationOverdue,
+		TaskID:     task.ID,
+		ProjectID:  task.ProjectID,
+		Title:      task.Title,
+		AssignedTo: task.AssignedTo,
+		DueAt:      *task.DueAt,
+		At:         now,
+	}
+	var next *time.Time
+	if now.Before(*task.DueAt) {
+		reminder, ok := models.DueReminder(*task.DueAt, task.Reminders, now)
+		if !ok {
+			return nil, models.NextNotification(task.DueAt, task.Reminders, now)
+		}
+		notification.Type = models.NotificationReminder
+		notification.Reminder = reminder
+		next = models.NextNotification(task.DueAt, task.Reminders, now)
+	}
+	notification.ID = models.NotificationID(task.ID, notification.Type, *task.DueAt, notification.Reminder)
+
+	return &notification, next
+}
+
+// holderID tells replicas apart, and restarts of the same one.
+func holderID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
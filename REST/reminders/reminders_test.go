@@ -0,0 +1,416 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/config"
+	"task-manager/repositories"
+	"task-manager/repositories/memory"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var dueAt = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// sentMail is what the SMTP stand-in received for one message.
+type sentMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpStandIn accepts mail on a local port and hands every message over on
+// mails, like MailHog or Mailpit would keep it.
+func smtpStandIn(t *testing.T) (string, <-chan sentMail) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	mails := make(chan sentMail, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, mails)
+		}
+	}()
+
+	return listener.Addr().String(), mails
+}
+
+// serveSMTP speaks just enough SMTP for net/smtp.SendMail, without any
+// extensions.
+func serveSMTP(conn net.Conn, mails chan<- sentMail) {
+	text := textproto.NewConn(conn)
+	defer text.Close()
+
+	text.PrintfLine("220 localhost ESMTP stand-in")
+	var m sentMail
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 localhost")
+		case "MAIL":
+			m = sentMail{from: strings.TrimSuffix(strings.TrimPrefix(arg, "FROM:<"), ">")}
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			m.to = append(m.to, strings.TrimSuffix(strings.TrimPrefix(arg, "TO:<"), ">"))
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			m.data = string(data)
+			mails <- m
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, mails := smtpStandIn(t)
+	notifier := NewSMTPNotifier(config.SMTP{
+		Addr: addr,
+		From: "reminders@example.com",
+		To:   []string{"alice@example.com", "bob@example.com"},
+	})
+
+	assignee := "alice"
+	reminder := models.Notification{
+		ID:         models.NotificationID("task", models.NotificationReminder, dueAt, "24h"),
+		Type:       models.NotificationReminder,
+		TaskID:     "task",
+		ProjectID:  "project",
+		Title:      "Ship it",
+		AssignedTo: &assignee,
+		DueAt:      dueAt,
+		Reminder:   "24h",
+		At:         dueAt.Add(-24 * time.Hour),
+	}
+	overdue := reminder
+	overdue.ID = models.NotificationID("task", models.NotificationOverdue, dueAt, "")
+	overdue.Type = models.NotificationOverdue
+	overdue.Reminder = ""
+	overdue.At = dueAt
+
+	for _, tc := range []struct {
+		notification models.Notification
+		subject      string
+		messageID    string
+		body         string
+	}{
+		{
+			notification: reminder,
+			subject:      `Task "Ship it" is due Mon, 01 Jan 2024 12:00:00 UTC`,
+			messageID:    "<task.reminder.1704110400000.24h@task-manager>",
+			body:         `task task "Ship it" in project project, assigned to alice, is due at 2024-01-01T12:00:00Z (reminder 24h before)`,
+		},
+		{
+			notification: overdue,
+			subject:      `Task "Ship it" is overdue`,
+			messageID:    "<task.overdue.1704110400000@task-manager>",
+			body:         `task task "Ship it" in project project, assigned to alice, was due at 2024-01-01T12:00:00Z and is overdue`,
+		},
+	} {
+		if err := notifier.Notify(context.Background(), tc.notification); err != nil {
+			t.Fatalf("notify %s: %v", tc.notification.Type, err)
+		}
+
+		var got sentMail
+		select {
+		case got = <-mails:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: the stand-in got no mail", tc.notification.Type)
+		}
+
+		if got.from != "reminders@example.com" || !slices.Equal(got.to, []string{"alice@example.com", "bob@example.com"}) {
+			t.Fatalf("%s: envelope from %q to %v", tc.notification.Type, got.from, got.to)
+		}
+
+		msg, err := mail.ReadMessage(strings.NewReader(got.data))
+		if err != nil {
+			t.Fatalf("%s: read message: %v", tc.notification.Type, err)
+		}
+		for name, want := range map[string]string{
+			"From":         "reminders@example.com",
+			"To":           "alice@example.com, bob@example.com",
+			"Subject":      tc.subject,
+			"Date":         tc.notification.At.Format(time.RFC1123Z),
+			"Message-ID":   tc.messageID,
+			"Content-Type": "text/plain; charset=utf-8",
+		} {
+			if got := msg.Header.Get(name); got != want {
+				t.Errorf("%s: header %s = %q, want %q", tc.notification.Type, name, got, want)
+			}
+		}
+
+		body, err := io.ReadAll(msg.Body)
+		if err != nil {
+			t.Fatalf("%s: read body: %v", tc.notification.Type, err)
+		}
+		if got := strings.TrimSpace(string(body)); got != tc.body {
+			t.Errorf("%s: body = %q, want %q", tc.notification.Type, got, tc.body)
+		}
+	}
+}
+
+func TestDue(t *testing.T) {
+	at := func(offset time.Duration) *time.Time {
+		t := dueAt.Add(offset)
+		return &t
+	}
+
+	for _, tc := range []struct {
+		name      string
+		dueAt     *time.Time
+		now       time.Time
+		wantType  string
+		reminder  string
+		wantNext  *time.Time
+		reminders []string
+	}{
+		{name: "no due date", now: dueAt},
+		{
+			name:      "before the first reminder",
+			dueAt:     &dueAt,
+			reminders: []string{"24h", "1h"},
+			now:       dueAt.Add(-48 * time.Hour),
+			wantNext:  at(-24 * time.Hour),
+		},
+		{
+			name:      "first reminder",
+			dueAt:     &dueAt,
+			reminders: []string{"24h", "1h"},
+			now:       dueAt.Add(-23 * time.Hour),
+			wantType:  models.NotificationReminder,
+			reminder:  "24h",
+			wantNext:  at(-time.Hour),
+		},
+		{
+			name:      "missed reminders fold into the latest",
+			dueAt:     &dueAt,
+			reminders: []string{"24h", "1h"},
+			now:       dueAt.Add(-30 * time.Minute),
+			wantType:  models.NotificationReminder,
+			reminder:  "1h",
+			wantNext:  &dueAt,
+		},
+		{
+			name:     "no reminders until overdue",
+			dueAt:    &dueAt,
+			now:      dueAt.Add(-time.Hour),
+			wantNext: &dueAt,
+		},
+		{
+			name:      "overdue",
+			dueAt:     &dueAt,
+			reminders: []string{"24h"},
+			now:       dueAt,
+			wantType:  models.NotificationOverdue,
+		},
+		{
+			name:     "long overdue",
+			dueAt:    &dueAt,
+			now:      dueAt.Add(72 * time.Hour),
+			wantType: models.NotificationOverdue,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			task := models.Task{ID: "task", ProjectID: "project", Title: "Ship it", DueAt: tc.dueAt, Reminders: tc.reminders}
+			notification, next := due(task, tc.now)
+
+			switch {
+			case tc.wantType == "" && notification != nil:
+				t.Fatalf("notification = %+v, want none", notification)
+			case tc.wantType != "" && notification == nil:
+				t.Fatalf("no notification, want %s", tc.wantType)
+			case notification != nil:
+				wantID := models.NotificationID(task.ID, tc.wantType, dueAt, tc.reminder)
+				if notification.Type != tc.wantType || notification.Reminder != tc.reminder || notification.ID != wantID || !notification.At.Equal(tc.now) {
+					t.Fatalf("notification = %+v, want %s %q with ID %s", notification, tc.wantType, tc.reminder, wantID)
+				}
+			}
+
+			if (next == nil) != (tc.wantNext == nil) || next != nil && !next.Equal(*tc.wantNext) {
+				t.Fatalf("next = %v, want %v", next, tc.wantNext)
+			}
+		})
+	}
+}
+
+// notifierFunc turns a function into a Notifier.
+type notifierFunc func(ctx context.Context, notification models.Notification) error
+
+func (f notifierFunc) Notify(ctx context.Context, notification models.Notification) error {
+	return f(ctx, notification)
+}
+
+// recorder returns a notifier that keeps what it is asked to send.
+func recorder(sent *[]string) Notifier {
+	return notifierFunc(func(ctx context.Context, notification models.Notification) error {
+		*sent = append(*sent, notification.ID)
+		return nil
+	})
+}
+
+// newDueTask stores a project and a task that became overdue at dueAt.
+func newDueTask(t *testing.T, repos repositories.Repositories, id string) models.Task {
+	t.Helper()
+
+	ctx := context.Background()
+	project := models.Project{ID: id + "-project", Name: id, OrganizationID: "org", CreatedAt: dueAt}
+	if err := repos.Projects.Create(ctx, project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+
+	due := dueAt
+	task := models.Task{
+		ID:        id,
+		Title:     id,
+		ProjectID: project.ID,
+		Status:    models.TaskStatusPending,
+		DueAt:     &due,
+		NotifyAt:  &due,
+		CreatedAt: dueAt,
+	}
+	if err := repos.Tasks.Create(ctx, task); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	return task
+}
+
+func requireNotifications(t *testing.T, repos repositories.Repositories, taskID string, want int) {
+	t.Helper()
+
+	notifications, err := repos.Notifications.ListByTask(context.Background(), taskID)
+	if err != nil {
+		t.Fatalf("list notifications: %v", err)
+	}
+	if len(notifications) != want {
+		t.Fatalf("task %s has %d notifications recorded, want %d", taskID, len(notifications), want)
+	}
+}
+
+func TestSendOnce(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	task := newDueTask(t, repos, "task")
+	now := dueAt.Add(time.Minute)
+
+	var sent []string
+	for run, want := range []int{1, 0} {
+		n, err := Send(ctx, repos, recorder(&sent), now)
+		if err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if n != want {
+			t.Fatalf("run %d sent %d notifications, want %d", run, n, want)
+		}
+	}
+
+	// Another replica that read the task before its schedule moved on finds
+	// the notification recorded already
+	if err := repos.Tasks.Update(ctx, task.ID, bson.M{"notifyAt": dueAt}); err != nil {
+		t.Fatalf("reset schedule: %v", err)
+	}
+	var replica []string
+	n, err := Send(ctx, repos, recorder(&replica), now)
+	if err != nil {
+		t.Fatalf("second replica: %v", err)
+	}
+	if n != 0 || len(replica) != 0 {
+		t.Fatalf("second replica sent %v", replica)
+	}
+
+	if len(sent) != 1 {
+		t.Fatalf("sent %v, want one overdue notice", sent)
+	}
+	requireNotifications(t, repos, task.ID, 1)
+}
+
+func TestSendRetriesFailures(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	task := newDueTask(t, repos, "task")
+	now := dueAt.Add(time.Minute)
+
+	failing := notifierFunc(func(ctx context.Context, notification models.Notification) error {
+		return errors.New("connection refused")
+	})
+	n, err := Send(ctx, repos, failing, now)
+	if err != nil || n != 0 {
+		t.Fatalf("failing send: sent %d, err %v", n, err)
+	}
+	requireNotifications(t, repos, task.ID, 0)
+
+	var sent []string
+	n, err = Send(ctx, repos, recorder(&sent), now)
+	if err != nil || n != 1 {
+		t.Fatalf("retry: sent %d, err %v, want the notice sent", n, err)
+	}
+	requireNotifications(t, repos, task.ID, 1)
+}
+
+func TestSendSkipsTasksWithoutProject(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	task := newDueTask(t, repos, "task")
+
+	// A task left behind by its project, due first so it leads the batch
+	notifyAt := dueAt.Add(-time.Hour)
+	stale := models.Task{
+		ID:        "stale",
+		Title:     "stale",
+		ProjectID: "gone",
+		Status:    models.TaskStatusPending,
+		DueAt:     task.DueAt,
+		NotifyAt:  &notifyAt,
+		CreatedAt: dueAt,
+	}
+	if err := repos.Tasks.Create(ctx, stale); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	var sent []string
+	n, err := Send(ctx, repos, recorder(&sent), dueAt.Add(time.Minute))
+	if err != nil || n != 1 {
+		t.Fatalf("send: sent %d, err %v, want the other task's notice", n, err)
+	}
+	requireNotifications(t, repos, task.ID, 1)
+
+	got, err := repos.Tasks.GetByID(ctx, stale.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.NotifyAt != nil {
+		t.Fatalf("stale task still scheduled at %v", got.NotifyAt)
+	}
+}
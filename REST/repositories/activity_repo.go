@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoActivityRepo struct {
+	db *mongo.Database
+}
+
+func (r *mongoActivityRepo) List(ctx context.Context, taskID string, page int64, limit int64) ([]models.Activity, int64, error) {
+	filter := bson.M{"taskId": taskID}
+
+	opts := options.Find().
+		SetSkip((page - 1) * limit).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.db.
+		Collection("task_activity").
+		Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	activity := []models.Activity{}
+	if err := cursor.All(ctx, &activity); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.db.
+		Collection("task_activity").
+		CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return activity, total, nil
+}
+
+// Timeline joins the comments in with $unionWith, like the trash listing.
+func (r *mongoActivityRepo) Timeline(ctx context.Context, taskID string, page int64, limit int64) ([]models.TimelineItem, int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"taskId": taskID}}},
+		{{Key: "$project", Value: bson.M{
+			"type":     bson.M{"$literal": models.TimelineActivity},
+			"at":       "$at",
+			"activity": "$$ROOT",
+		}}},
+		{{Key: "$unionWith", Value: bson.M{
+			"coll": "task_comments",
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"taskId": taskID}},
+				bson.M{"$project": bson.M{
+					"type":    bson.M{"$literal": models.TimelineComment},
+					"at":      "$createdAt",
+					"comment": "$$ROOT",
+				}},
+			},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}}}},
+	}
+
+	return aggregatePage[models.TimelineItem](ctx, r.db.Collection("task_activity"), pipeline, page, limit)
+}
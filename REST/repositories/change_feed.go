@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Server error codes meaning a change stream cannot go on from its token:
+// InvalidResumeToken, ChangeStreamFatalError and ChangeStreamHistoryLost.
+var lostChangeStreamCodes = []int{260, 280, 286}
+
+type mongoChangeFeed struct {
+	db *mongo.Database
+}
+
+// Watch needs MongoDB 6.0 and pre- and post-images enabled on tasks and
+// projects (see db.EnableChangeStreams). Changes are matched by the project
+// and organization IDs in those images, so deletes match too.
+//
+// An organization scope matches tasks by the IDs of the organization's
+// projects. When one of its projects is created the stream is reopened
+// right after that change with the new project included.
+func (f *mongoChangeFeed) Watch(ctx context.Context, scope ChangeScope, resumeAfter string) (ChangeStream, error) {
+	s := &mongoChangeStream{db: f.db, scope: scope}
+
+	if scope.OrganizationID == "" {
+		if err := s.open(ctx, resumeAfter); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	projects := &mongoProjectRepo{db: f.db}
+	projectIDs, err := projects.ListIDs(ctx, scope.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	s.projectIDs = projectIDs
+	if err := s.open(ctx, resumeAfter); err != nil {
+		return nil, err
+	}
+
+	// Projects created while the stream was being opened are before its
+	// start, so their creation is not going to come through
+	projectIDs, err = projects.ListIDs(ctx, scope.OrganizationID)
+	if err != nil {
+		s.Close(ctx)
+		return nil, err
+	}
+	if len(projectIDs) != len(s.projectIDs) {
+		s.projectIDs = projectIDs
+		if err := s.open(ctx, s.token()); err != nil {
+			s.Close(ctx)
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+type mongoChangeStream struct {
+	db         *mongo.Database
+	scope      ChangeScope
+	projectIDs []string
+	cs         *mongo.ChangeStream
+}
+
+type changeEvent struct {
+	OperationType string `bson:"operationType"`
+	NS            struct {
+		Coll string `bson:"coll"`
+	} `bson:"ns"`
+	DocumentKey struct {
+		ID string `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument             bson.RawValue `bson:"fullDocument"`
+	FullDocumentBeforeChange bson.RawValue `bson:"fullDocumentBeforeChange"`
+	WallTime                 time.Time     `bson:"wallTime"`
+}
+
+// open (re)opens the stream after the change with token resumeAfter.
+func (s *mongoChangeStream) open(ctx context.Context, resumeAfter string) error {
+	opts := options.ChangeStream().
+		SetFullDocument(options.WhenAvailable).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	if resumeAfter != "" {
+		if strings.Trim(resumeAfter, "0123456789abcdefABCDEF") != "" {
+			return ErrCannotResume
+		}
+		opts.SetResumeAfter(bson.M{"_data": resumeAfter})
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: s.match()}}}
+	cs, err := s.db.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return changeStreamError(err)
+	}
+
+	if s.cs != nil {
+		s.cs.Close(ctx)
+	}
+	s.cs = cs
+	return nil
+}
+
+// match selects the changes of the scope by the images of the documents.
+func (s *mongoChangeStream) match() bson.M {
+	var projects, tasks bson.M
+	if s.scope.ProjectID != "" {
+		projects = bson.M{"documentKey._id": s.scope.ProjectID}
+		tasks = bson.M{"$or": bson.A{
+			bson.M{"fullDocument.projectId": s.scope.ProjectID},
+			bson.M{"fullDocumentBeforeChange.projectId": s.scope.ProjectID},
+		}}
+	} else {
+		projects = bson.M{"$or": bson.A{
+			bson.M{"fullDocument.organizationId": s.scope.OrganizationID},
+			bson.M{"fullDocumentBeforeChange.organizationId": s.scope.OrganizationID},
+		}}
+		tasks = bson.M{"$or": bson.A{
+			bson.M{"fullDocument.projectId": bson.M{"$in": s.projectIDs}},
+			bson.M{"fullDocumentBeforeChange.projectId": bson.M{"$in": s.projectIDs}},
+		}}
+	}
+
+	projects["ns.coll"] = "projects"
+	tasks["ns.coll"] = "tasks"
+	return bson.M{
+		"operationType": bson.M{"$in": bson.A{
+			models.ChangeInsert, models.ChangeUpdate, models.ChangeReplace, models.ChangeDelete,
+		}},
+		"$or": bson.A{projects, tasks},
+	}
+}
+
+func (s *mongoChangeStream) Next(ctx context.Context) (*models.Change, error) {
+	if !s.cs.Next(ctx) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := s.cs.Err(); err != nil {
+			return nil, changeStreamError(err)
+		}
+		// Invalidated, e.g. by dropping the database
+		return nil, ErrCannotResume
+	}
+
+	var event changeEvent
+	if err := s.cs.Decode(&event); err != nil {
+		return nil, err
+	}
+
+	change := &models.Change{
+		Token:     s.token(),
+		Operation: event.OperationType,
+		ID:        event.DocumentKey.ID,
+		At:        event.WallTime,
+	}
+	doc := event.FullDocument
+	if doc.Type != bson.TypeEmbeddedDocument {
+		doc = event.FullDocumentBeforeChange
+	}
+
+	if event.NS.Coll == "tasks" {
+		change.Type = models.ChangeTypeTask
+		if doc.Type == bson.TypeEmbeddedDocument {
+			change.Task = &models.Task{}
+			if err := doc.Unmarshal(change.Task); err != nil {
+				return nil, err
+			}
+		}
+		return change, nil
+	}
+
+	change.Type = models.ChangeTypeProject
+	if doc.Type == bson.TypeEmbeddedDocument {
+		change.Project = &models.Project{}
+		if err := doc.Unmarshal(change.Project); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.scope.OrganizationID != "" && change.Operation == models.ChangeInsert &&
+		!slices.Contains(s.projectIDs, change.ID) {
+		s.projectIDs = append(s.projectIDs, change.ID)
+		if err := s.open(ctx, change.Token); err != nil {
+			return nil, err
+		}
+	}
+
+	return change, nil
+}
+
+func (s *mongoChangeStream) Close(ctx context.Context) error {
+	return s.cs.Close(ctx)
+}
+
+// token returns the stream's current resume token.
+func (s *mongoChangeStream) token() string {
+	data, _ := s.cs.ResumeToken().Lookup("_data").StringValueOK()
+	return data
+}
+
+// changeStreamError reports lost history as ErrCannotResume.
+func changeStreamError(err error) error {
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) {
+		for _, code := range lostChangeStreamCodes {
+			if serverErr.HasErrorCode(code) {
+				return ErrCannotResume
+			}
+		}
+	}
+	return err
+}
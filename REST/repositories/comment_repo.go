@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoCommentRepo struct {
+	db *mongo.Database
+}
+
+func (r *mongoCommentRepo) GetByID(ctx context.Context, id string) (*models.Comment, error) {
+	var comment models.Comment
+
+	err := r.db.
+		Collection("task_comments").
+		FindOne(ctx, bson.M{"_id": id}).
+		Decode(&comment)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+func (r *mongoCommentRepo) List(ctx context.Context, taskID string, page int64, limit int64) ([]models.Comment, int64, error) {
+	filter := bson.M{"taskId": taskID}
+
+	opts := options.Find().
+		SetSkip((page - 1) * limit).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.db.
+		Collection("task_comments").
+		Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	comments := []models.Comment{}
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.db.
+		Collection("task_comments").
+		CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return comments, total, nil
+}
+
+func (r *mongoCommentRepo) Create(ctx context.Context, comment models.Comment) error {
+	_, err := r.db.
+		Collection("task_comments").
+		InsertOne(ctx, comment)
+	return err
+}
+
+func (r *mongoCommentRepo) Update(
+	ctx context.Context,
+	id string,
+	update bson.M,
+) error {
+	res, err := r.db.
+		Collection("task_comments").
+		UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoCommentRepo) Delete(ctx context.Context, id string) error {
+	res, err := r.db.
+		Collection("task_comments").
+		DeleteOne(ctx, bson.M{"_id": id})
+
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoDependencyRepo struct {
+	db *mongo.Database
+}
+
+// Add checks for cycles against the organization's dependencies inside the
+// transaction that inserts the new one. Snapshot reads alone would let two
+// transactions each close half a cycle, so both also write the
+// organization's document in dependency_graphs, and the second to commit
+// fails with a write conflict and is retried, seeing the first's dependency.
+func (r *mongoDependencyRepo) Add(ctx context.Context, dependency models.Dependency) error {
+	dependency.ID = models.DependencyID(dependency.TaskID, dependency.BlockerID)
+
+	_, err := inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		_, err := r.db.
+			Collection("dependency_graphs").
+			UpdateOne(sc,
+				bson.M{"_id": dependency.OrganizationID},
+				bson.M{"$set": bson.M{"updatedAt": time.Now()}},
+				options.Update().SetUpsert(true),
+			)
+		if err != nil {
+			return nil, err
+		}
+
+		cursor, err := r.db.
+			Collection("task_dependencies").
+			Find(sc, bson.M{"organizationId": dependency.OrganizationID})
+		if err != nil {
+			return nil, err
+		}
+		dependencies := []models.Dependency{}
+		if err := cursor.All(sc, &dependencies); err != nil {
+			return nil, err
+		}
+
+		for _, d := range dependencies {
+			if d.ID == dependency.ID {
+				return nil, ErrDependencyExists
+			}
+		}
+		if models.NewDependencyGraph(dependencies).CreatesCycle(dependency.TaskID, dependency.BlockerID) {
+			return nil, ErrDependencyCycle
+		}
+
+		_, err = r.db.
+			Collection("task_dependencies").
+			InsertOne(sc, dependency)
+		return nil, err
+	})
+	return err
+}
+
+func (r *mongoDependencyRepo) Remove(ctx context.Context, taskID string, blockerID string) error {
+	res, err := r.db.
+		Collection("task_dependencies").
+		DeleteOne(ctx, bson.M{"_id": models.DependencyID(taskID, blockerID)})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoDependencyRepo) ListByTasks(ctx context.Context, taskIDs []string) ([]models.Dependency, error) {
+	return r.list(ctx, bson.M{"$or": bson.A{
+		bson.M{"taskId": bson.M{"$in": taskIDs}},
+		bson.M{"blockerId": bson.M{"$in": taskIDs}},
+	}})
+}
+
+func (r *mongoDependencyRepo) ListByProject(ctx context.Context, projectID string) ([]models.Dependency, error) {
+	return r.list(ctx, bson.M{"projectId": projectID, "blockerProjectId": projectID})
+}
+
+func (r *mongoDependencyRepo) list(ctx context.Context, filter bson.M) ([]models.Dependency, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.db.
+		Collection("task_dependencies").
+		Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	dependencies := []models.Dependency{}
+	if err := cursor.All(ctx, &dependencies); err != nil {
+		return nil, err
+	}
+
+	return dependencies, nil
+}
+
+// deleteDependencies removes the dependencies that tasks matching filter,
+// a filter on taskId or projectId, are part of either way.
+func deleteDependencies(ctx context.Context, database *mongo.Database, filter bson.M) error {
+	blocker := bson.M{}
+	for field, value := range filter {
+		switch field {
+		case "taskId":
+			blocker["blockerId"] = value
+		case "projectId":
+			blocker["blockerProjectId"] = value
+		}
+	}
+
+	_, err := database.
+		Collection("task_dependencies").
+		DeleteMany(ctx, bson.M{"$or": bson.A{filter, blocker}})
+	return err
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoLabelRepo struct {
+	db *mongo.Database
+}
+
+func (r *mongoLabelRepo) GetByID(ctx context.Context, id string) (*models.Label, error) {
+	var label models.Label
+
+	err := r.db.
+		Collection("labels").
+		FindOne(ctx, bson.M{"_id": id}).
+		Decode(&label)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &label, nil
+}
+
+func (r *mongoLabelRepo) ListByOrganization(ctx context.Context, orgID string) ([]models.Label, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.db.
+		Collection("labels").
+		Find(ctx, bson.M{"organizationId": orgID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	labels := []models.Label{}
+	if err := cursor.All(ctx, &labels); err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
+func (r *mongoLabelRepo) Create(ctx context.Context, label models.Label) error {
+	_, err := r.db.
+		Collection("labels").
+		InsertOne(ctx, label)
+	return nameConflict(err)
+}
+
+func (r *mongoLabelRepo) Update(
+	ctx context.Context,
+	id string,
+	update bson.M,
+) error {
+	res, err := r.db.
+		Collection("labels").
+		UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+
+	if err != nil {
+		return nameConflict(err)
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete pulls the label from the tasks in the same transaction, bumping
+// their versions like any other task write.
+func (r *mongoLabelRepo) Delete(ctx context.Context, id string) (*CascadeResult, error) {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		res, err := r.db.
+			Collection("labels").
+			DeleteOne(sc, bson.M{"_id": id})
+		if err != nil {
+			return nil, err
+		}
+		if res.DeletedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		tasks := r.db.Collection("tasks")
+		values, err := tasks.Distinct(sc, "_id", bson.M{"labels": id})
+		if err != nil {
+			return nil, err
+		}
+		taskIDs := stringValues(values)
+
+		if len(taskIDs) > 0 {
+			_, err = tasks.UpdateMany(sc,
+				bson.M{"_id": bson.M{"$in": taskIDs}},
+				bson.M{
+					"$pull": bson.M{"labels": id},
+					"$set":  bson.M{"updatedAt": time.Now()},
+					"$inc":  bson.M{"version": 1},
+				},
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return &CascadeResult{ProjectIDs: []string{}, TaskIDs: taskIDs}, nil
+	})
+}
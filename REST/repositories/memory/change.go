@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+)
+
+// maxChanges bounds the history the change feed can resume from.
+const maxChanges = 1000
+
+// storedChange is a change with what it takes to match it against scopes.
+type storedChange struct {
+	seq            int64
+	change         models.Change
+	organizationID string
+	projectID      string
+}
+
+// recordTask adds a write to task to the change feed. The caller must hold
+// the write lock, and must record a task's deletion before its project's.
+func (s *store) recordTask(operation string, task models.Task) {
+	c := storedChange{
+		projectID: task.ProjectID,
+		change: models.Change{
+			Type:      models.ChangeTypeTask,
+			Operation: operation,
+			ID:        task.ID,
+			Task:      &task,
+		},
+	}
+	if project, ok := s.projects[task.ProjectID]; ok {
+		c.organizationID = project.OrganizationID
+	}
+	s.record(c)
+}
+
+// recordProject adds a write to project to the change feed. The caller
+// must hold the write lock.
+func (s *store) recordProject(operation string, project models.Project) {
+	s.record(storedChange{
+		organizationID: project.OrganizationID,
+		projectID:      project.ID,
+		change: models.Change{
+			Type:      models.ChangeTypeProject,
+			Operation: operation,
+			ID:        project.ID,
+			Project:   &project,
+		},
+	})
+}
+
+func (s *store) record(c storedChange) {
+	s.changeSeq++
+	c.seq = s.changeSeq
+	c.change.Token = strconv.FormatInt(c.seq, 10)
+	c.change.At = time.Now()
+
+	s.changes = append(s.changes, c)
+	if len(s.changes) > maxChanges {
+		s.changes = append([]storedChange(nil), s.changes[len(s.changes)-maxChanges:]...)
+	}
+
+	// Wake up the streams waiting for a change
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+type changeFeed struct {
+	*store
+}
+
+// Watch resumes from any change still in the last maxChanges. Tokens are
+// the changes' sequence numbers.
+func (f *changeFeed) Watch(ctx context.Context, scope repositories.ChangeScope, resumeAfter string) (repositories.ChangeStream, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	after := f.changeSeq
+	if resumeAfter != "" {
+		seq, err := strconv.ParseInt(resumeAfter, 10, 64)
+		if err != nil || seq < 1 || seq > f.changeSeq || !f.hasChangesAfter(seq) {
+			return nil, repositories.ErrCannotResume
+		}
+		after = seq
+	}
+
+	return &changeStream{store: f.store, scope: scope, after: after}, nil
+}
+
+// hasChangesAfter tells whether every change after seq is still in the
+// history. The caller must hold the lock.
+func (s *store) hasChangesAfter(seq int64) bool {
+	return len(s.changes) == 0 || s.changes[0].seq <= seq+1
+}
+
+type changeStream struct {
+	*store
+	scope repositories.ChangeScope
+	after int64
+}
+
+func (s *changeStream) Next(ctx context.Context) (*models.Change, error) {
+	for {
+		s.mu.RLock()
+		if !s.hasChangesAfter(s.after) {
+			s.mu.RUnlock()
+			return nil, repositories.ErrCannotResume
+		}
+
+		for _, c := range s.changes {
+			if c.seq <= s.after {
+				continue
+			}
+			s.after = c.seq
+			if s.inScope(c) {
+				change, err := clone(c.change)
+				s.mu.RUnlock()
+				if err != nil {
+					return nil, err
+				}
+				return &change, nil
+			}
+		}
+
+		changed := s.changed
+		s.mu.RUnlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *changeStream) inScope(c storedChange) bool {
+	if s.scope.ProjectID != "" {
+		return c.projectID == s.scope.ProjectID
+	}
+	return c.organizationID == s.scope.OrganizationID
+}
+
+func (s *changeStream) Close(ctx context.Context) error {
+	return nil
+}
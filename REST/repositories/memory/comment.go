@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type commentRepo struct {
+	*store
+}
+
+func (r *commentRepo) GetByID(ctx context.Context, id string) (*models.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comment, ok := r.comments[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	comment, err := clone(comment)
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *commentRepo) List(ctx context.Context, taskID string, p int64, limit int64) ([]models.Comment, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comments := []models.Comment{}
+	for _, comment := range r.comments {
+		if comment.TaskID != taskID {
+			continue
+		}
+		comment, err := clone(comment)
+		if err != nil {
+			return nil, 0, err
+		}
+		comments = append(comments, comment)
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		return earlier(comments[i].CreatedAt, comments[i].ID, comments[j].CreatedAt, comments[j].ID)
+	})
+
+	return cut(comments, p, limit), int64(len(comments)), nil
+}
+
+func (r *commentRepo) Create(ctx context.Context, comment models.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.comments[comment.ID]; ok {
+		return duplicateID(comment.ID)
+	}
+
+	comment, err := clone(comment)
+	if err != nil {
+		return err
+	}
+	r.comments[comment.ID] = comment
+	return nil
+}
+
+func (r *commentRepo) Update(ctx context.Context, id string, update bson.M) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment, ok := r.comments[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+
+	comment, err := applySet(comment, update)
+	if err != nil {
+		return err
+	}
+	r.comments[id] = comment
+	return nil
+}
+
+func (r *commentRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.comments[id]; !ok {
+		return mongo.ErrNoDocuments
+	}
+
+	delete(r.comments, id)
+	return nil
+}
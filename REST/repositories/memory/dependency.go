@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type dependencyRepo struct {
+	*store
+}
+
+func (r *dependencyRepo) Add(ctx context.Context, dependency models.Dependency) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dependency.ID = models.DependencyID(dependency.TaskID, dependency.BlockerID)
+	if _, ok := r.dependencies[dependency.ID]; ok {
+		return repositories.ErrDependencyExists
+	}
+
+	dependencies := []models.Dependency{}
+	for _, d := range r.dependencies {
+		if d.OrganizationID == dependency.OrganizationID {
+			dependencies = append(dependencies, d)
+		}
+	}
+	if models.NewDependencyGraph(dependencies).CreatesCycle(dependency.TaskID, dependency.BlockerID) {
+		return repositories.ErrDependencyCycle
+	}
+
+	dependency, err := clone(dependency)
+	if err != nil {
+		return err
+	}
+	r.dependencies[dependency.ID] = dependency
+	return nil
+}
+
+func (r *dependencyRepo) Remove(ctx context.Context, taskID string, blockerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := models.DependencyID(taskID, blockerID)
+	if _, ok := r.dependencies[id]; !ok {
+		return mongo.ErrNoDocuments
+	}
+	delete(r.dependencies, id)
+	return nil
+}
+
+func (r *dependencyRepo) ListByTasks(ctx context.Context, taskIDs []string) ([]models.Dependency, error) {
+	return r.list(func(d models.Dependency) bool {
+		return slices.Contains(taskIDs, d.TaskID) || slices.Contains(taskIDs, d.BlockerID)
+	})
+}
+
+func (r *dependencyRepo) ListByProject(ctx context.Context, projectID string) ([]models.Dependency, error) {
+	return r.list(func(d models.Dependency) bool {
+		return d.ProjectID == projectID && d.BlockerProjectID == projectID
+	})
+}
+
+func (r *dependencyRepo) list(match func(models.Dependency) bool) ([]models.Dependency, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dependencies := []models.Dependency{}
+	for _, d := range r.dependencies {
+		if !match(d) {
+			continue
+		}
+		d, err := clone(d)
+		if err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, d)
+	}
+
+	sort.Slice(dependencies, func(i, j int) bool {
+		return earlier(dependencies[i].CreatedAt, dependencies[i].ID, dependencies[j].CreatedAt, dependencies[j].ID)
+	})
+
+	return dependencies, nil
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type jobRepo struct {
+	*store
+}
+
+func (r *jobRepo) GetByID(ctx context.Context, id string) (*models.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	job, err := clone(job)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepo) Create(ctx context.Context, job models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.jobs[job.ID]; ok {
+		return duplicateID(job.ID)
+	}
+
+	job, err := clone(job)
+	if err != nil {
+		return err
+	}
+	r.jobs[job.ID] = job
+	return nil
+}
+
+func (r *jobRepo) Update(ctx context.Context, id string, update bson.M) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+
+	job, err := applySet(job, update)
+	if err != nil {
+		return err
+	}
+	r.jobs[id] = job
+	return nil
+}
+
+func (r *jobRepo) FailInterrupted(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, job := range r.jobs {
+		if job.Status != models.JobStatusPending && job.Status != models.JobStatusRunning {
+			continue
+		}
+
+		job, err := applySet(job, bson.M{
+			"status":     models.JobStatusFailed,
+			"error":      "interrupted by server restart",
+			"finishedAt": now,
+		})
+		if err != nil {
+			return err
+		}
+		r.jobs[id] = job
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+// Package memory keeps the task manager's data in process memory. It
+// behaves like the Mongo repositories and is meant for tests and local
+// development.
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// store holds every collection behind one lock, which makes cascading
+// deletes atomic just like the Mongo transactions.
+type store struct {
+	mu            sync.RWMutex
+	organizations map[string]models.Organization
+	projects      map[string]models.Project
+	tasks         map[string]models.Task
+	transitions   []models.StatusTransition
+	activity      []models.Activity
+	comments      map[string]models.Comment
+	jobs          map[string]models.Job
+	views         map[string]models.View
+	labels        map[string]models.Label
+	dependencies  map[string]models.Dependency
+	notifications map[string]models.Notification
+	leases        map[string]models.Lease
+
+	// changes is the recent history of the change feed, changeSeq numbers
+	// the changes and changed is closed and replaced on every change.
+	changes   []storedChange
+	changeSeq int64
+	changed   chan struct{}
+}
+
+// New returns empty repositories that share one in-memory store.
+func New() repositories.Repositories {
+	s := &store{
+		organizations: map[string]models.Organization{},
+		projects:      map[string]models.Project{},
+		tasks:         map[string]models.Task{},
+		jobs:          map[string]models.Job{},
+		views:         map[string]models.View{},
+		labels:        map[string]models.Label{},
+		dependencies:  map[string]models.Dependency{},
+		comments:      map[string]models.Comment{},
+		notifications: map[string]models.Notification{},
+		leases:        map[string]models.Lease{},
+		changed:       make(chan struct{}),
+	}
+
+	return repositories.Repositories{
+		Organizations: &organizationRepo{s},
+		Projects:      &projectRepo{s},
+		Tasks:         &taskRepo{s},
+		Jobs:          &jobRepo{s},
+		Views:         &viewRepo{s},
+		Labels:        &labelRepo{s},
+		Activity:      &activityRepo{s},
+		Comments:      &commentRepo{s},
+		Dependencies:  &dependencyRepo{s},
+		Notifications: &notificationRepo{s},
+		Leases:        &leaseRepo{s},
+		Trash:         &trashRepo{s},
+		Search:        &searchRepo{s},
+		Stats:         &statsRepo{s},
+		Changes:       &changeFeed{s},
+	}
+}
+
+// clone round-trips a document through BSON. Stored documents never share
+// memory with callers, and times and ignored fields come back the way the
+// Mongo driver would return them.
+func clone[T any](doc T) (T, error) {
+	var out T
+
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return out, err
+	}
+	if err := bson.Unmarshal(data, &out); err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
+// applySet applies a $set update to a document, keyed by BSON field names.
+func applySet[T any](doc T, update bson.M) (T, error) {
+	return applyPatch(doc, repositories.Patch{Set: update})
+}
+
+// applyPatch applies a $set and $unset to a document. Dotted keys like
+// customFields.estimate reach into embedded documents as in MongoDB.
+func applyPatch[T any](doc T, patch repositories.Patch) (T, error) {
+	var out T
+
+	fields, err := document(doc)
+	if err != nil {
+		return out, err
+	}
+	for key, value := range patch.Set {
+		parent, name := embedded(fields, key, true)
+		parent[name] = value
+	}
+	for _, key := range patch.Unset {
+		if parent, name := embedded(fields, key, false); parent != nil {
+			delete(parent, name)
+		}
+	}
+
+	data, err := bson.Marshal(fields)
+	if err != nil {
+		return out, err
+	}
+	if err := bson.Unmarshal(data, &out); err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
+// embedded returns the document that holds the last part of a dotted key,
+// and that part. Missing documents on the way are created when create is
+// set, otherwise the result is nil.
+func embedded(fields bson.M, key string, create bool) (bson.M, string) {
+	parent, rest, ok := strings.Cut(key, ".")
+	if !ok {
+		return fields, key
+	}
+
+	child, ok := fields[parent].(bson.M)
+	if !ok {
+		if !create {
+			return nil, ""
+		}
+		child = bson.M{}
+		fields[parent] = child
+	}
+	return embedded(child, rest, create)
+}
+
+// document returns doc the way it is stored, keyed by BSON field names.
+func document(doc interface{}) (bson.M, error) {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := bson.M{}
+	if err := bson.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func duplicateID(id string) error {
+	return fmt.Errorf("duplicate id %q", id)
+}
+
+// page sorts docs newest first and cuts out one page of them. key returns a
+// document's creation time and ID, the ID breaks ties.
+func page[T any](docs []T, key func(T) (time.Time, string), page int64, limit int64) []T {
+	sort.Slice(docs, func(i, j int) bool {
+		createdI, idI := key(docs[i])
+		createdJ, idJ := key(docs[j])
+		if createdI.Equal(createdJ) {
+			return idI > idJ
+		}
+		return createdI.After(createdJ)
+	})
+
+	return cut(docs, page, limit)
+}
+
+// cut returns one page of the already sorted docs.
+func cut[T any](docs []T, page int64, limit int64) []T {
+	start := (page - 1) * limit
+	if start >= int64(len(docs)) {
+		return docs[:0]
+	}
+	end := start + limit
+	if end > int64(len(docs)) {
+		end = int64(len(docs))
+	}
+
+	return docs[start:end]
+}
+
+func olderThan(createdAt time.Time, id string, position repositories.Keyset) bool {
+	if createdAt.Equal(position.CreatedAt) {
+		return id < position.ID
+	}
+	return createdAt.Before(position.CreatedAt)
+}
+
+func newerThan(createdAt time.Time, id string, position repositories.Keyset) bool {
+	if createdAt.Equal(position.CreatedAt) {
+		return id > position.ID
+	}
+	return createdAt.After(position.CreatedAt)
+}
+
+// earlier orders oldest first, the ID breaks ties.
+func earlier(atI time.Time, idI string, atJ time.Time, idJ string) bool {
+	if atI.Equal(atJ) {
+		return idI < idJ
+	}
+	return atI.Before(atJ)
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"task-manager/repositories"
+	"task-manager/repositories/memory"
+	"task-manager/repositories/repotest"
+)
+
+func TestMemory(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repositories.Repositories {
+		return memory.New()
+	})
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+)
+
+type notificationRepo struct {
+	*store
+}
+
+func (r *notificationRepo) Record(ctx context.Context, notification models.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.notifications[notification.ID]; ok {
+		return repositories.ErrAlreadySent
+	}
+
+	notification, err := clone(notification)
+	if err != nil {
+		return err
+	}
+	r.notifications[notification.ID] = notification
+	return nil
+}
+
+func (r *notificationRepo) Forget(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.notifications, id)
+	return nil
+}
+
+func (r *notificationRepo) ListByTask(ctx context.Context, taskID string) ([]models.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notifications := []models.Notification{}
+	for _, notification := range r.notifications {
+		if notification.TaskID != taskID {
+			continue
+		}
+		notification, err := clone(notification)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return earlier(notifications[i].At, notifications[i].ID, notifications[j].At, notifications[j].ID)
+	})
+
+	return notifications, nil
+}
+
+type leaseRepo struct {
+	*store
+}
+
+func (r *leaseRepo) Acquire(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	lease, ok := r.leases[name]
+	if ok && lease.Holder != holder && lease.ExpiresAt.After(now) {
+		return false, nil
+	}
+
+	r.leases[name] = models.Lease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (r *leaseRepo) Release(ctx context.Context, name string, holder string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if lease, ok := r.leases[name]; ok && lease.Holder == holder {
+		delete(r.leases, name)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+)
+
+type searchRepo struct {
+	*store
+}
+
+// Search approximates MongoDB's text search: case-insensitive whole words,
+// any of the terms matches and "-term" excludes. There is no stemming, and
+// scores are weighted match counts rather than MongoDB's text scores.
+func (r *searchRepo) Search(ctx context.Context, query repositories.SearchQuery) ([]models.SearchHit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var terms, excluded []string
+	for _, term := range strings.Fields(strings.ToLower(query.Text)) {
+		if negated, ok := strings.CutPrefix(term, "-"); ok {
+			excluded = append(excluded, words(negated)...)
+		} else {
+			terms = append(terms, words(term)...)
+		}
+	}
+
+	wanted := func(hitType string) bool {
+		return len(query.Types) == 0 || slices.Contains(query.Types, hitType)
+	}
+
+	hits := []models.SearchHit{}
+	add := func(hit models.SearchHit) {
+		text := words(hit.Title)
+		var description []string
+		if hit.Description != nil {
+			description = words(*hit.Description)
+		}
+
+		for _, term := range excluded {
+			if slices.Contains(text, term) || slices.Contains(description, term) {
+				return
+			}
+		}
+		for _, term := range terms {
+			hit.Score += 3 * float64(count(text, term))
+			hit.Score += float64(count(description, term))
+		}
+		if hit.Score > 0 {
+			if hit.Description != nil {
+				description := *hit.Description
+				hit.Description = &description
+			}
+			hits = append(hits, hit)
+		}
+	}
+
+	if org, ok := r.organizations[query.OrganizationID]; ok && org.DeletedAt == nil && wanted(models.SearchHitOrganization) {
+		add(models.SearchHit{Type: models.SearchHitOrganization, ID: org.ID, Title: org.Name, Description: org.Description})
+	}
+
+	projectIDs := map[string]bool{}
+	for _, project := range r.projects {
+		if project.OrganizationID != query.OrganizationID {
+			continue
+		}
+		projectIDs[project.ID] = true
+		if project.DeletedAt == nil && wanted(models.SearchHitProject) {
+			add(models.SearchHit{Type: models.SearchHitProject, ID: project.ID, Title: project.Name, Description: project.Description})
+		}
+	}
+
+	if wanted(models.SearchHitTask) {
+		for _, task := range r.tasks {
+			if projectIDs[task.ProjectID] && task.DeletedAt == nil {
+				projectID := task.ProjectID
+				add(models.SearchHit{Type: models.SearchHitTask, ID: task.ID, Title: task.Title, Description: task.Description, ProjectID: &projectID})
+			}
+		}
+	}
+
+	return repositories.RankSearchHits(hits, query.Limit), nil
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func count(words []string, word string) int {
+	n := 0
+	for _, w := range words {
+		if w == word {
+			n++
+		}
+	}
+	return n
+}
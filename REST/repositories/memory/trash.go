@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	models "task-manager/collections"
+)
+
+type trashRepo struct {
+	*store
+}
+
+func (r *trashRepo) ListOrganizations(ctx context.Context, p int64, limit int64) ([]models.Organization, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orgs := []models.Organization{}
+	for _, org := range r.organizations {
+		if org.DeletedAt == nil {
+			continue
+		}
+		org, err := clone(org)
+		if err != nil {
+			return nil, 0, err
+		}
+		orgs = append(orgs, org)
+	}
+
+	total := int64(len(orgs))
+	orgs = page(orgs, func(org models.Organization) (time.Time, string) {
+		return *org.DeletedAt, org.ID
+	}, p, limit)
+
+	return orgs, total, nil
+}
+
+func (r *trashRepo) List(ctx context.Context, orgID string, p int64, limit int64) ([]models.TrashItem, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := []models.TrashItem{}
+	projectIDs := map[string]bool{}
+	for _, project := range r.projects {
+		if project.OrganizationID != orgID {
+			continue
+		}
+		if project.DeletedAt == nil {
+			projectIDs[project.ID] = true
+		} else if project.DeletedWith == "" {
+			items = append(items, projectTrashItem(project))
+		}
+	}
+
+	for _, task := range r.tasks {
+		if projectIDs[task.ProjectID] && task.DeletedAt != nil && task.DeletedWith == "" {
+			items = append(items, taskTrashItem(task))
+		}
+	}
+
+	total := int64(len(items))
+	items = page(items, func(item models.TrashItem) (time.Time, string) {
+		return item.DeletedAt, item.ID
+	}, p, limit)
+
+	return items, total, nil
+}
+
+func (r *trashRepo) Expired(ctx context.Context, before time.Time, limit int64) ([]models.TrashItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var orgs, projects, tasks []models.TrashItem
+	for _, org := range r.organizations {
+		if org.DeletedAt != nil && org.DeletedAt.Before(before) {
+			orgs = append(orgs, models.TrashItem{
+				Type:      models.TrashItemOrganization,
+				ID:        org.ID,
+				Title:     org.Name,
+				DeletedAt: *org.DeletedAt,
+			})
+		}
+	}
+	for _, project := range r.projects {
+		if project.DeletedAt != nil && project.DeletedWith == "" && project.DeletedAt.Before(before) {
+			projects = append(projects, projectTrashItem(project))
+		}
+	}
+	for _, task := range r.tasks {
+		if task.DeletedAt != nil && task.DeletedWith == "" && task.DeletedAt.Before(before) {
+			tasks = append(tasks, taskTrashItem(task))
+		}
+	}
+
+	items := []models.TrashItem{}
+	for _, expired := range [][]models.TrashItem{orgs, projects, tasks} {
+		sort.Slice(expired, func(i, j int) bool {
+			if expired[i].DeletedAt.Equal(expired[j].DeletedAt) {
+				return expired[i].ID < expired[j].ID
+			}
+			return expired[i].DeletedAt.Before(expired[j].DeletedAt)
+		})
+		if int64(len(expired)) > limit {
+			expired = expired[:limit]
+		}
+		items = append(items, expired...)
+	}
+
+	return items, nil
+}
+
+func projectTrashItem(project models.Project) models.TrashItem {
+	return models.TrashItem{
+		Type:      models.TrashItemProject,
+		ID:        project.ID,
+		Title:     project.Name,
+		DeletedAt: *project.DeletedAt,
+	}
+}
+
+func taskTrashItem(task models.Task) models.TrashItem {
+	projectID := task.ProjectID
+	return models.TrashItem{
+		Type:      models.TrashItemTask,
+		ID:        task.ID,
+		Title:     task.Title,
+		ProjectID: &projectID,
+		DeletedAt: *task.DeletedAt,
+	}
+}
@@ -0,0 +1,49 @@
+package repositories_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"task-manager/db"
+	"task-manager/repositories"
+	"task-manager/repositories/repotest"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestMongo runs the conformance suite against the MongoDB at
+// MONGO_TEST_URI, which has to be a 6.0 replica set for transactions and
+// change streams. Every subtest gets a database of its own, dropped
+// afterwards.
+func TestMongo(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(ctx) })
+
+	repotest.Run(t, func(t *testing.T) repositories.Repositories {
+		database := client.Database("task_manager_test_" + primitive.NewObjectID().Hex())
+		t.Cleanup(func() { database.Drop(ctx) })
+
+		// The db helpers work on the package's database, subtests run one
+		// at a time
+		db.Database = database
+		if err := db.CreateIndexes(); err != nil {
+			t.Fatalf("create indexes: %v", err)
+		}
+		if err := db.EnableChangeStreams(); err != nil {
+			t.Fatalf("enable change streams: %v", err)
+		}
+		return repositories.NewMongo(database)
+	})
+}
@@ -0,0 +1,330 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoProjectRepo struct {
+	db *mongo.Database
+}
+
+func (r *mongoProjectRepo) GetByID(ctx context.Context, id string) (*models.Project, error) {
+	var project models.Project
+
+	err := r.db.
+		Collection("projects").
+		FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}).
+		Decode(&project)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+func (r *mongoProjectRepo) List(
+	ctx context.Context,
+	organizationID string,
+	page int64,
+	limit int64,
+	status *string,
+) ([]models.Project, int64, error) {
+
+	filter := bson.M{"organizationId": organizationID, "deletedAt": nil}
+	if status != nil {
+		filter["status"] = *status
+	}
+
+	opts := options.Find().
+		SetSkip((page - 1) * limit).
+		SetLimit(limit).
+		SetSort(bson.M{"createdAt": -1})
+
+	cursor, err := r.db.
+		Collection("projects").
+		Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	projects := []models.Project{}
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.db.
+		Collection("projects").
+		CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return projects, total, nil
+}
+
+func (r *mongoProjectRepo) ListIDs(ctx context.Context, orgID string) ([]string, error) {
+	values, err := r.db.
+		Collection("projects").
+		Distinct(ctx, "_id", bson.M{"organizationId": orgID})
+	if err != nil {
+		return nil, err
+	}
+
+	return stringValues(values), nil
+}
+
+func (r *mongoProjectRepo) Create(ctx context.Context, project models.Project) error {
+	_, err := r.db.
+		Collection("projects").
+		InsertOne(ctx, project)
+	return nameConflict(err)
+}
+
+func (r *mongoProjectRepo) Update(
+	ctx context.Context,
+	id string,
+	update bson.M,
+) error {
+	return r.Patch(ctx, id, Patch{Set: update})
+}
+
+func (r *mongoProjectRepo) Patch(ctx context.Context, id string, patch Patch) error {
+	collection := r.db.Collection("projects")
+	res, err := collection.UpdateOne(ctx, patch.filter(id), patch.update())
+
+	if err != nil {
+		return nameConflict(err)
+	}
+	if res.MatchedCount == 0 {
+		if err := patchMiss(ctx, collection, id, patch); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *mongoProjectRepo) SetWorkflow(ctx context.Context, id string, workflow models.Workflow) error {
+	return r.Update(ctx, id, bson.M{
+		"workflow":  workflow,
+		"updatedAt": time.Now(),
+	})
+}
+
+func (r *mongoProjectRepo) SetCustomFields(ctx context.Context, id string, fields []models.CustomField) error {
+	return r.Update(ctx, id, bson.M{
+		"customFields": fields,
+		"updatedAt":    time.Now(),
+	})
+}
+
+func (r *mongoProjectRepo) Delete(ctx context.Context, id string) error {
+	res, err := r.db.
+		Collection("projects").
+		DeleteOne(ctx, bson.M{"_id": id})
+
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoProjectRepo) Archive(ctx context.Context, id string, at time.Time) (*CascadeResult, error) {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		collection := r.db.Collection("projects")
+		project, err := readLifecycle(sc, collection, id)
+		if err != nil {
+			return nil, err
+		}
+		if project.DeletedAt != nil {
+			return nil, mongo.ErrNoDocuments
+		}
+		if project.ArchivedAt != nil {
+			return nil, ErrArchived
+		}
+
+		update := archival.set(at, "")
+		update["$set"].(bson.M)["updatedAt"] = at
+		if _, err := collection.UpdateOne(sc, bson.M{"_id": id}, update); err != nil {
+			return nil, err
+		}
+
+		return archival.cascadeProject(sc, r.db, id, at)
+	})
+}
+
+func (r *mongoProjectRepo) Unarchive(ctx context.Context, id string) (*CascadeResult, error) {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		collection := r.db.Collection("projects")
+		project, err := readLifecycle(sc, collection, id)
+		if err != nil {
+			return nil, err
+		}
+		if project.DeletedAt != nil {
+			return nil, mongo.ErrNoDocuments
+		}
+		if project.ArchivedAt == nil {
+			return nil, ErrNotArchived
+		}
+
+		org, err := readLifecycle(sc, r.db.Collection("organizations"), project.OrganizationID)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		if err == nil && org.ArchivedAt != nil {
+			return nil, ErrParentArchived
+		}
+
+		update := archival.unset()
+		update["$set"] = bson.M{"updatedAt": time.Now()}
+		if _, err := collection.UpdateOne(sc, bson.M{"_id": id}, update); err != nil {
+			return nil, err
+		}
+
+		return archival.revert(sc, r.db, id)
+	})
+}
+
+func (r *mongoProjectRepo) SoftDelete(ctx context.Context, id string, at time.Time) (*CascadeResult, error) {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		res, err := r.db.
+			Collection("projects").
+			UpdateOne(sc, bson.M{"_id": id, "deletedAt": nil}, deletion.set(at, ""))
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		return deletion.cascadeProject(sc, r.db, id, at)
+	})
+}
+
+// Restore brings the project back unless its organization is in the trash,
+// which includes the case of the project having been deleted along with it.
+func (r *mongoProjectRepo) Restore(ctx context.Context, id string) (*CascadeResult, error) {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		collection := r.db.Collection("projects")
+		project, err := readLifecycle(sc, collection, id)
+		if err != nil {
+			return nil, err
+		}
+		if project.DeletedAt == nil {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		// An organization that is gone has been purged
+		org, err := readLifecycle(sc, r.db.Collection("organizations"), project.OrganizationID)
+		if err == mongo.ErrNoDocuments || (err == nil && org.DeletedAt != nil) {
+			return nil, ErrParentDeleted
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := collection.UpdateOne(sc, bson.M{"_id": id}, deletion.unset()); err != nil {
+			return nil, err
+		}
+
+		return deletion.revert(sc, r.db, id)
+	})
+}
+
+func (r *mongoProjectRepo) DeleteCascade(ctx context.Context, id string) (*CascadeResult, error) {
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	res, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return deleteProjects(sc, r.db, []string{id})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*CascadeResult), nil
+}
+
+func (r *mongoProjectRepo) Purge(ctx context.Context, id string, deletedAt time.Time) (*CascadeResult, error) {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		// Deleting the project first claims it, a restore racing the purge
+		// either ran before and leaves nothing to match or fails after
+		res, err := r.db.
+			Collection("projects").
+			DeleteOne(sc, bson.M{"_id": id, "deletedAt": deletedAt})
+		if err != nil {
+			return nil, err
+		}
+		if res.DeletedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		result, err := deleteProjects(sc, r.db, []string{id})
+		if err != nil {
+			return nil, err
+		}
+		result.ProjectIDs = []string{id}
+		return result, nil
+	})
+}
+
+func deleteProjects(ctx context.Context, database *mongo.Database, projectIDs []string) (*CascadeResult, error) {
+	result := &CascadeResult{ProjectIDs: []string{}, TaskIDs: []string{}}
+	if len(projectIDs) == 0 {
+		return result, nil
+	}
+
+	inProjects := bson.M{"projectId": bson.M{"$in": projectIDs}}
+
+	taskIDs, err := database.
+		Collection("tasks").
+		Distinct(ctx, "_id", inProjects)
+	if err != nil {
+		return nil, err
+	}
+	result.TaskIDs = stringValues(taskIDs)
+
+	if err := deleteTaskHistory(ctx, database, inProjects); err != nil {
+		return nil, err
+	}
+	if _, err := database.Collection("tasks").DeleteMany(ctx, inProjects); err != nil {
+		return nil, err
+	}
+
+	del, err := database.
+		Collection("projects").
+		DeleteMany(ctx, bson.M{"_id": bson.M{"$in": projectIDs}})
+	if err != nil {
+		return nil, err
+	}
+	if del.DeletedCount > 0 {
+		result.ProjectIDs = projectIDs
+	}
+
+	return result, nil
+}
+
+func stringValues(values []interface{}) []string {
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
@@ -0,0 +1,503 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Every implementation reports a missing document as mongo.ErrNoDocuments,
+// so handlers can check for it regardless of the backend. Update methods
+// take the fields to $set, Patch methods can remove fields as well. Every
+// write to an organization, project or task increments its version, and
+// patches with IfVersion only apply to that version.
+//
+// Deleted organizations, projects and tasks stay in the trash until they
+// are purged, reads other than the trash's treat them as missing. Archived
+// ones are read-only, writes to them return ErrArchived. Both states
+// cascade from organizations to their projects and from projects to their
+// tasks, and undoing them brings back only what the cascade changed.
+
+// OrganizationRepository keeps organization names unique regardless of
+// case, Create, Update and Patch return a *ConflictError otherwise.
+type OrganizationRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Organization, error)
+	List(ctx context.Context, page int64, limit int64, status *string) ([]models.Organization, int64, error)
+
+	// ListKeyset pages by (createdAt, _id) instead of skipping, so deep
+	// pages stay cheap and stable while organizations are added.
+	ListKeyset(ctx context.Context, query KeysetQuery) ([]models.Organization, error)
+	Count(ctx context.Context, status *string) (int64, error)
+
+	Create(ctx context.Context, org models.Organization) error
+	Update(ctx context.Context, id string, update bson.M) error
+	Patch(ctx context.Context, id string, patch Patch) error
+
+	// Archive and Unarchive change the status along with the archived
+	// state. Archive returns ErrArchived for an archived organization,
+	// Unarchive ErrNotArchived for one that is not.
+	Archive(ctx context.Context, id string, at time.Time) (*CascadeResult, error)
+	Unarchive(ctx context.Context, id string) (*CascadeResult, error)
+
+	// SoftDelete moves the organization with its projects and tasks to the
+	// trash, Restore brings them back. Restore returns mongo.ErrNoDocuments
+	// for an organization that is not in the trash.
+	SoftDelete(ctx context.Context, id string, at time.Time) (*CascadeResult, error)
+	Restore(ctx context.Context, id string) (*CascadeResult, error)
+
+	// Purge removes the organization with its views and labels, all or
+	// nothing, but only while it is in the trash since deletedAt, and
+	// returns mongo.ErrNoDocuments otherwise. Its projects stay in the
+	// trash as items of their own, returned in ProjectIDs, for the purge to
+	// remove one by one.
+	Purge(ctx context.Context, id string, deletedAt time.Time) (*CascadeResult, error)
+}
+
+// ProjectRepository keeps project names unique within an organization
+// regardless of case, Create, Update and Patch return a *ConflictError
+// otherwise.
+type ProjectRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Project, error)
+	List(ctx context.Context, orgID string, page int64, limit int64, status *string) ([]models.Project, int64, error)
+
+	// ListIDs includes the projects in the trash.
+	ListIDs(ctx context.Context, orgID string) ([]string, error)
+	Create(ctx context.Context, project models.Project) error
+	Update(ctx context.Context, id string, update bson.M) error
+	Patch(ctx context.Context, id string, patch Patch) error
+	SetWorkflow(ctx context.Context, id string, workflow models.Workflow) error
+	SetCustomFields(ctx context.Context, id string, fields []models.CustomField) error
+	Delete(ctx context.Context, id string) error
+
+	// Archive and Unarchive work like the organization's. Unarchive returns
+	// ErrParentArchived while the organization is archived.
+	Archive(ctx context.Context, id string, at time.Time) (*CascadeResult, error)
+	Unarchive(ctx context.Context, id string) (*CascadeResult, error)
+
+	// SoftDelete and Restore work like the organization's. Restore returns
+	// ErrParentDeleted while the organization is in the trash.
+	SoftDelete(ctx context.Context, id string, at time.Time) (*CascadeResult, error)
+	Restore(ctx context.Context, id string) (*CascadeResult, error)
+
+	// DeleteCascade removes one project with its tasks and their
+	// transitions, activity and comments, all or nothing. A missing project
+	// is not an error.
+	DeleteCascade(ctx context.Context, id string) (*CascadeResult, error)
+
+	// Purge works like DeleteCascade, but only while the project is in the
+	// trash since deletedAt, and returns mongo.ErrNoDocuments otherwise.
+	Purge(ctx context.Context, id string, deletedAt time.Time) (*CascadeResult, error)
+}
+
+type TaskRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Task, error)
+	ListByProject(ctx context.Context, projectID string, query TaskQuery, page int64, limit int64) ([]models.Task, int64, error)
+	ListByAssignee(ctx context.Context, userID string, page int64, limit int64, status *string) ([]models.Task, int64, error)
+	Create(ctx context.Context, task models.Task) error
+	Update(ctx context.Context, id string, update bson.M) error
+
+	// Assign and Unassign change assignedTo and assignedAt together and
+	// return the updated task. actor is recorded in the task's activity.
+	Assign(ctx context.Context, id string, userID string, actor string) (*models.Task, error)
+	Unassign(ctx context.Context, id string, actor string) (*models.Task, error)
+
+	// SoftDelete moves the task to the trash, Restore brings it back. Restore
+	// returns ErrParentDeleted while the project is in the trash.
+	SoftDelete(ctx context.Context, id string, at time.Time) error
+	Restore(ctx context.Context, id string) error
+
+	// Delete removes the task with its transitions, activity, comments and
+	// notifications for good.
+	Delete(ctx context.Context, id string) error
+
+	// Purge works like Delete, all or nothing, but only while the task is
+	// in the trash since deletedAt, and returns mongo.ErrNoDocuments
+	// otherwise.
+	Purge(ctx context.Context, id string, deletedAt time.Time) error
+
+	// Transition applies update only while the task is still in status
+	// from, then records the transition. Otherwise it returns
+	// ErrStatusConflict.
+	Transition(ctx context.Context, id string, from string, update bson.M, transition models.StatusTransition) error
+	ListTransitions(ctx context.Context, taskID string) ([]models.StatusTransition, error)
+
+	// Patch applies patch and records what it changed in the task's
+	// activity. With a transition it behaves like Transition: only while the
+	// task is in transition.From, recording the transition.
+	Patch(ctx context.Context, id string, patch Patch, transition *models.StatusTransition) error
+
+	// BulkPatch applies every patch like Patch does, all in one write. It
+	// returns one error per patch, nil for those that applied, and a second
+	// error when the write as a whole failed, in which case none applied.
+	BulkPatch(ctx context.Context, patches []TaskPatch) ([]error, error)
+
+	// CountOutsideStates counts the project's tasks whose status is not one
+	// of states, i.e. tasks a new workflow would strand.
+	CountOutsideStates(ctx context.Context, projectID string, states []string) (int64, error)
+
+	// CountCustomFieldValues counts the project's tasks that hold a value
+	// for the custom field key other than one of except, i.e. tasks new
+	// custom field definitions would leave with an invalid value.
+	CountCustomFieldValues(ctx context.Context, projectID string, key string, except []string) (int64, error)
+
+	// ListNotifiable returns up to limit tasks, neither archived nor
+	// deleted, whose NotifyAt is at or before at, earliest first.
+	ListNotifiable(ctx context.Context, at time.Time, limit int64) ([]models.Task, error)
+
+	// SetNotifyAt moves the task's NotifyAt from from to next, nil unsets
+	// it, without counting as a write to the task. It tells whether NotifyAt
+	// was still from, a task whose due date changed meanwhile is left alone.
+	SetNotifyAt(ctx context.Context, id string, from time.Time, next *time.Time) (bool, error)
+}
+
+type JobRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Job, error)
+	Create(ctx context.Context, job models.Job) error
+	Update(ctx context.Context, id string, update bson.M) error
+
+	// FailInterrupted marks jobs that were still pending or running as
+	// failed. Jobs run in-process, so after a restart nothing is working
+	// on them.
+	FailInterrupted(ctx context.Context) error
+}
+
+// ViewRepository stores saved task views. View names are unique within an
+// organization, Create and Update return ErrViewNameTaken otherwise.
+type ViewRepository interface {
+	GetByID(ctx context.Context, id string) (*models.View, error)
+	ListByOrganization(ctx context.Context, orgID string) ([]models.View, error)
+	Create(ctx context.Context, view models.View) error
+	Update(ctx context.Context, id string, update bson.M) error
+	Delete(ctx context.Context, id string) error
+}
+
+// LabelRepository stores the task labels of organizations. Label names are
+// unique within an organization regardless of case, Create and Update
+// return a *ConflictError otherwise.
+type LabelRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Label, error)
+	ListByOrganization(ctx context.Context, orgID string) ([]models.Label, error)
+	Create(ctx context.Context, label models.Label) error
+	Update(ctx context.Context, id string, update bson.M) error
+
+	// Delete removes the label from the tasks carrying it as well, all or
+	// nothing, and returns the IDs of those tasks.
+	Delete(ctx context.Context, id string) (*CascadeResult, error)
+}
+
+// ActivityRepository reads the field changes that task writes record.
+type ActivityRepository interface {
+	// List pages the task's activity, oldest first.
+	List(ctx context.Context, taskID string, page int64, limit int64) ([]models.Activity, int64, error)
+
+	// Timeline pages the task's activity and comments merged, oldest first.
+	Timeline(ctx context.Context, taskID string, page int64, limit int64) ([]models.TimelineItem, int64, error)
+}
+
+// CommentRepository stores the comments on tasks.
+type CommentRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Comment, error)
+
+	// List pages the task's comments, oldest first.
+	List(ctx context.Context, taskID string, page int64, limit int64) ([]models.Comment, int64, error)
+	Create(ctx context.Context, comment models.Comment) error
+	Update(ctx context.Context, id string, update bson.M) error
+	Delete(ctx context.Context, id string) error
+}
+
+// DependencyRepository stores which tasks block which. The dependencies of
+// an organization's tasks never form a cycle. Purging a task or project
+// removes the dependencies its tasks are part of.
+type DependencyRepository interface {
+	// Add records the dependency unless it would close a cycle, which
+	// returns ErrDependencyCycle, or is recorded already, which returns
+	// ErrDependencyExists. Concurrent additions cannot close a cycle
+	// together either.
+	Add(ctx context.Context, dependency models.Dependency) error
+
+	// Remove deletes the dependency of taskID on blockerID.
+	Remove(ctx context.Context, taskID string, blockerID string) error
+
+	// ListByTasks returns the dependencies any of taskIDs is part of, as
+	// the blocked task or as the blocker.
+	ListByTasks(ctx context.Context, taskIDs []string) ([]models.Dependency, error)
+
+	// ListByProject returns the dependencies between tasks of the project.
+	ListByProject(ctx context.Context, projectID string) ([]models.Dependency, error)
+}
+
+// NotificationRepository records the notifications sent about tasks.
+type NotificationRepository interface {
+	// Record stores the notification before it is sent. It returns
+	// ErrAlreadySent when one with the same ID was recorded, so every
+	// notification goes out at most once.
+	Record(ctx context.Context, notification models.Notification) error
+
+	// Forget removes the record of a notification that failed to send, so
+	// it can be recorded and sent again.
+	Forget(ctx context.Context, id string) error
+
+	// ListByTask returns the task's notifications, oldest first.
+	ListByTask(ctx context.Context, taskID string) ([]models.Notification, error)
+}
+
+// LeaseRepository hands out named leases, which keep background work to one
+// replica at a time.
+type LeaseRepository interface {
+	// Acquire takes the lease for holder, or renews it when holder has it
+	// already, until ttl from now. It tells whether holder has the lease,
+	// which it cannot take while another holder's has not expired.
+	Acquire(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error)
+
+	// Release gives up holder's lease, if holder has it.
+	Release(ctx context.Context, name string, holder string) error
+}
+
+// TrashRepository lists what is in the trash. Projects and tasks deleted
+// along with their organization or project come back with it, so only
+// what was deleted on its own is listed.
+type TrashRepository interface {
+	// ListOrganizations pages the deleted organizations, latest first.
+	ListOrganizations(ctx context.Context, page int64, limit int64) ([]models.Organization, int64, error)
+
+	// List pages the deleted projects of an organization and the deleted
+	// tasks of its other projects, latest first.
+	List(ctx context.Context, orgID string, page int64, limit int64) ([]models.TrashItem, int64, error)
+
+	// Expired returns up to limit organizations, up to limit projects and
+	// up to limit tasks deleted before before, oldest first.
+	Expired(ctx context.Context, before time.Time, limit int64) ([]models.TrashItem, error)
+}
+
+// SearchRepository finds organizations, projects and tasks by text.
+type SearchRepository interface {
+	Search(ctx context.Context, query SearchQuery) ([]models.SearchHit, error)
+}
+
+// StatsRepository aggregates task counts for dashboards. Project returns
+// mongo.ErrNoDocuments for a missing project, Organization returns empty
+// stats for an organization without projects.
+type StatsRepository interface {
+	Project(ctx context.Context, projectID string) (*models.ProjectStats, error)
+	Organization(ctx context.Context, orgID string) (*models.OrganizationStats, error)
+}
+
+// ChangeFeed streams writes to tasks and projects as they happen.
+type ChangeFeed interface {
+	// Watch follows the changes in scope, from the change after the one
+	// with token resumeAfter, or from now on when it is empty. It returns
+	// ErrCannotResume when that change is no longer known.
+	Watch(ctx context.Context, scope ChangeScope, resumeAfter string) (ChangeStream, error)
+}
+
+// ChangeScope selects the changes of one project, or of an organization's
+// projects and all their tasks. Exactly one of the IDs is set.
+type ChangeScope struct {
+	OrganizationID string
+	ProjectID      string
+}
+
+type ChangeStream interface {
+	// Next blocks until the next change. It returns ctx's error when ctx
+	// ends first, and ErrCannotResume when the stream fell too far behind.
+	Next(ctx context.Context) (*models.Change, error)
+	Close(ctx context.Context) error
+}
+
+// Repositories bundles one implementation of each repository.
+type Repositories struct {
+	Organizations OrganizationRepository
+	Projects      ProjectRepository
+	Tasks         TaskRepository
+	Jobs          JobRepository
+	Views         ViewRepository
+	Labels        LabelRepository
+	Activity      ActivityRepository
+	Comments      CommentRepository
+	Dependencies  DependencyRepository
+	Notifications NotificationRepository
+	Leases        LeaseRepository
+	Trash         TrashRepository
+	Search        SearchRepository
+	Stats         StatsRepository
+	Changes       ChangeFeed
+}
+
+// Patch sets and removes fields of a document, keyed by BSON field names.
+type Patch struct {
+	Set   bson.M
+	Unset []string
+
+	// IfVersion makes the patch compare-and-set: it only applies while the
+	// document is at this version, and fails with ErrVersionConflict
+	// otherwise.
+	IfVersion *int64
+
+	// Actor is who makes the change. Task patches record it in the task's
+	// activity.
+	Actor string
+}
+
+// TaskPatch is one task's patch in a BulkPatch, with the transition it
+// makes if it changes the status.
+type TaskPatch struct {
+	ID         string
+	Patch      Patch
+	Transition *models.StatusTransition
+}
+
+// Empty tells whether the patch changes nothing.
+func (p Patch) Empty() bool {
+	return len(p.Set) == 0 && len(p.Unset) == 0
+}
+
+// filter matches the document to patch as long as it is neither archived
+// nor deleted. Documents written before versions were introduced have none,
+// which counts as version 0.
+func (p Patch) filter(id string) bson.M {
+	filter := bson.M{"_id": id, "archivedAt": nil, "deletedAt": nil}
+	if p.IfVersion != nil {
+		if *p.IfVersion == 0 {
+			filter["version"] = bson.M{"$in": bson.A{0, nil}}
+		} else {
+			filter["version"] = *p.IfVersion
+		}
+	}
+	return filter
+}
+
+// update returns the patch as a MongoDB update document.
+func (p Patch) update() bson.M {
+	update := bson.M{"$inc": bson.M{"version": 1}}
+	if len(p.Set) > 0 {
+		update["$set"] = p.Set
+	}
+	if len(p.Unset) > 0 {
+		unset := bson.M{}
+		for _, field := range p.Unset {
+			unset[field] = ""
+		}
+		update["$unset"] = unset
+	}
+	return update
+}
+
+// ErrStatusConflict means the task's status changed between reading it and
+// applying a transition.
+var ErrStatusConflict = errors.New("task status changed concurrently")
+
+// ErrVersionConflict means the document changed since the version a patch
+// was based on.
+var ErrVersionConflict = errors.New("document version changed concurrently")
+
+// ErrArchived means the document is archived and therefore read-only, or,
+// when archiving it, that it is archived already.
+var ErrArchived = errors.New("document is archived")
+
+// ErrNotArchived means there is nothing to unarchive.
+var ErrNotArchived = errors.New("document is not archived")
+
+// ErrParentArchived and ErrParentDeleted mean a project or task cannot be
+// unarchived or restored on its own while what contains it is archived or
+// in the trash.
+var (
+	ErrParentArchived = errors.New("parent is archived")
+	ErrParentDeleted  = errors.New("parent is deleted")
+)
+
+var ErrViewNameTaken = errors.New("view name already taken")
+
+// ErrDependencyCycle means a dependency would make a task block itself,
+// directly or through other tasks. ErrDependencyExists means the dependency
+// is there already.
+var (
+	ErrDependencyCycle  = errors.New("dependency would create a cycle")
+	ErrDependencyExists = errors.New("dependency already exists")
+)
+
+// ErrAlreadySent means a notification was recorded, and sent, before.
+var ErrAlreadySent = errors.New("notification already sent")
+
+// ErrCannotResume means a change feed no longer has the history to continue
+// from a change. Subscribers have to reload and follow from now on.
+var ErrCannotResume = errors.New("cannot resume change feed")
+
+// ConflictError means another document already holds the value of a unique
+// field.
+type ConflictError struct {
+	Field string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Field + " already taken"
+}
+
+// nameConflict turns a duplicate key error on the name_unique index, which
+// db.CreateIndexes creates on organizations, projects and labels, into a
+// ConflictError. Other errors pass through.
+func nameConflict(err error) error {
+	if mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "index: name_unique ") {
+		return &ConflictError{Field: "name"}
+	}
+	return err
+}
+
+// patchMiss tells why a patch matched no document: the document is gone or
+// deleted, archived, or no longer at the patch's version. It returns nil
+// when none of these holds, e.g. when the caller filtered on more.
+func patchMiss(ctx context.Context, collection *mongo.Collection, id string, patch Patch) error {
+	doc, err := readLifecycle(ctx, collection, id)
+	if err != nil {
+		return err
+	}
+
+	if doc.DeletedAt != nil {
+		return mongo.ErrNoDocuments
+	}
+	if doc.ArchivedAt != nil {
+		return ErrArchived
+	}
+	if patch.IfVersion != nil && doc.Version != *patch.IfVersion {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// CascadeResult lists what a cascade removed or changed along with the
+// organization or project it started from, so callers can drop cached
+// copies.
+type CascadeResult struct {
+	ProjectIDs []string
+	TaskIDs    []string
+}
+
+// SearchQuery looks for Text in one organization: the organization itself,
+// its projects and their tasks. Types limits the kinds of hits, all kinds
+// when empty. At most Limit hits come back, best first.
+type SearchQuery struct {
+	OrganizationID string
+	Text           string
+	Types          []string
+	Limit          int64
+}
+
+// Keyset is a position in a newest-first listing.
+type Keyset struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// KeysetQuery selects up to Limit documents older than After or newer than
+// Before, at most one of which is set. Results are newest first either way.
+type KeysetQuery struct {
+	After  *Keyset
+	Before *Keyset
+	Limit  int64
+	Status *string
+}
@@ -0,0 +1,2217 @@
+// Package repotest holds the behaviour every repositories backend must
+// share. Call Run from a backend's tests with a constructor that returns
+// empty repositories, e.g.
+//
+//	func TestMemory(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) repositories.Repositories {
+//			return memory.New()
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Run runs the conformance suite. newRepos is called once per subtest and
+// must return repositories with no data in them.
+func Run(t *testing.T, newRepos func(t *testing.T) repositories.Repositories) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repos repositories.Repositories)
+	}{
+		{"OrganizationCRUD", testOrganizationCRUD},
+		{"OrganizationList", testOrganizationList},
+		{"OrganizationKeyset", testOrganizationKeyset},
+		{"ProjectCRUD", testProjectCRUD},
+		{"ProjectWorkflow", testProjectWorkflow},
+		{"TaskLists", testTaskLists},
+		{"TaskQuery", testTaskQuery},
+		{"TaskAssignment", testTaskAssignment},
+		{"TaskTransition", testTaskTransition},
+		{"Activity", testActivity},
+		{"Comments", testComments},
+		{"Patch", testPatch},
+		{"BulkPatch", testBulkPatch},
+		{"UniqueNames", testUniqueNames},
+		{"Versions", testVersions},
+		{"Archive", testArchive},
+		{"Trash", testTrash},
+		{"Purge", testPurge},
+		{"Changes", testChanges},
+		{"CascadeDelete", testCascadeDelete},
+		{"Jobs", testJobs},
+		{"Views", testViews},
+		{"Labels", testLabels},
+		{"CustomFields", testCustomFields},
+		{"Dependencies", testDependencies},
+		{"Reminders", testReminders},
+		{"Notifications", testNotifications},
+		{"Leases", testLeases},
+		{"Search", testSearch},
+		{"Stats", testStats},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepos(t))
+		})
+	}
+}
+
+var base = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newID() string {
+	return primitive.NewObjectID().Hex()
+}
+
+func newOrganization(t *testing.T, repos repositories.Repositories, status string, createdAt time.Time) models.Organization {
+	t.Helper()
+
+	// Names are unique, so they carry the ID
+	id := newID()
+	org := models.Organization{
+		ID:        id,
+		Name:      "org " + id,
+		Status:    status,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	if err := repos.Organizations.Create(context.Background(), org); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	return org
+}
+
+func newProject(t *testing.T, repos repositories.Repositories, orgID string, createdAt time.Time) models.Project {
+	t.Helper()
+
+	id := newID()
+	project := models.Project{
+		ID:             id,
+		Name:           "project " + id,
+		OrganizationID: orgID,
+		Status:         models.ProjectStatusPlanned,
+		CreatedAt:      createdAt,
+		UpdatedAt:      createdAt,
+	}
+	if err := repos.Projects.Create(context.Background(), project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+	return project
+}
+
+func newTask(t *testing.T, repos repositories.Repositories, projectID string, status string, priority string, createdAt time.Time) models.Task {
+	t.Helper()
+
+	task := models.Task{
+		ID:        newID(),
+		Title:     "task",
+		ProjectID: projectID,
+		Status:    status,
+		Priority:  priority,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	if err := repos.Tasks.Create(context.Background(), task); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	return task
+}
+
+func requireNotFound(t *testing.T, err error) {
+	t.Helper()
+
+	if err != mongo.ErrNoDocuments {
+		t.Fatalf("got error %v, want mongo.ErrNoDocuments", err)
+	}
+}
+
+func testOrganizationCRUD(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+
+	if err := repos.Organizations.Create(ctx, org); err == nil {
+		t.Fatal("creating an organization twice succeeded")
+	}
+
+	got, err := repos.Organizations.GetByID(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("get organization: %v", err)
+	}
+	if got.Name != org.Name || !got.CreatedAt.Equal(org.CreatedAt) {
+		t.Fatalf("got %+v, want %+v", got, org)
+	}
+
+	if err := repos.Organizations.Update(ctx, org.ID, bson.M{"name": "renamed"}); err != nil {
+		t.Fatalf("update organization: %v", err)
+	}
+	got, err = repos.Organizations.GetByID(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("get organization: %v", err)
+	}
+	if got.Name != "renamed" || got.Status != org.Status {
+		t.Fatalf("update changed the wrong fields: %+v", got)
+	}
+
+	_, err = repos.Organizations.GetByID(ctx, newID())
+	requireNotFound(t, err)
+	requireNotFound(t, repos.Organizations.Update(ctx, newID(), bson.M{"name": "x"}))
+}
+
+func testOrganizationList(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	oldest := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	archived := newOrganization(t, repos, models.OrganizationStatusArchived, base.Add(time.Minute))
+	newest := newOrganization(t, repos, models.OrganizationStatusActive, base.Add(2*time.Minute))
+
+	orgs, total, err := repos.Organizations.List(ctx, 1, 2, nil)
+	if err != nil {
+		t.Fatalf("list organizations: %v", err)
+	}
+	if total != 3 || len(orgs) != 2 || orgs[0].ID != newest.ID || orgs[1].ID != archived.ID {
+		t.Fatalf("page 1 = %v (total %d), want newest first", orgs, total)
+	}
+
+	orgs, _, err = repos.Organizations.List(ctx, 2, 2, nil)
+	if err != nil {
+		t.Fatalf("list organizations: %v", err)
+	}
+	if len(orgs) != 1 || orgs[0].ID != oldest.ID {
+		t.Fatalf("page 2 = %v, want the oldest organization", orgs)
+	}
+
+	status := models.OrganizationStatusArchived
+	orgs, total, err = repos.Organizations.List(ctx, 1, 10, &status)
+	if err != nil {
+		t.Fatalf("list organizations: %v", err)
+	}
+	if total != 1 || len(orgs) != 1 || orgs[0].ID != archived.ID {
+		t.Fatalf("status filter = %v (total %d)", orgs, total)
+	}
+}
+
+func testOrganizationKeyset(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+
+	// Two organizations share a timestamp so the ID has to break the tie
+	var orgs []models.Organization
+	for i, offset := range []time.Duration{0, time.Minute, time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		status := models.OrganizationStatusActive
+		if i == 4 {
+			status = models.OrganizationStatusArchived
+		}
+		orgs = append(orgs, newOrganization(t, repos, status, base.Add(offset)))
+	}
+	// Newest first: orgs[4], orgs[3], then the tie with the larger ID first
+	want := []string{orgs[4].ID, orgs[3].ID, orgs[2].ID, orgs[1].ID, orgs[0].ID}
+	if orgs[1].ID > orgs[2].ID {
+		want[2], want[3] = orgs[1].ID, orgs[2].ID
+	}
+
+	position := func(id string) *repositories.Keyset {
+		for _, org := range orgs {
+			if org.ID == id {
+				return &repositories.Keyset{CreatedAt: org.CreatedAt, ID: org.ID}
+			}
+		}
+		return nil
+	}
+	ids := func(orgs []models.Organization) []string {
+		out := []string{}
+		for _, org := range orgs {
+			out = append(out, org.ID)
+		}
+		return out
+	}
+	check := func(name string, got []models.Organization, want []string) {
+		t.Helper()
+		if g := ids(got); !slices.Equal(g, want) {
+			t.Fatalf("%s = %v, want %v", name, g, want)
+		}
+	}
+
+	first, err := repos.Organizations.ListKeyset(ctx, repositories.KeysetQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("list first page: %v", err)
+	}
+	check("first page", first, want[:2])
+
+	next, err := repos.Organizations.ListKeyset(ctx, repositories.KeysetQuery{After: position(want[1]), Limit: 2})
+	if err != nil {
+		t.Fatalf("list next page: %v", err)
+	}
+	check("next page", next, want[2:4])
+
+	prev, err := repos.Organizations.ListKeyset(ctx, repositories.KeysetQuery{Before: position(want[2]), Limit: 2})
+	if err != nil {
+		t.Fatalf("list previous page: %v", err)
+	}
+	check("previous page", prev, want[:2])
+
+	prev, err = repos.Organizations.ListKeyset(ctx, repositories.KeysetQuery{Before: position(want[4]), Limit: 2})
+	if err != nil {
+		t.Fatalf("list previous page: %v", err)
+	}
+	check("previous page of the last", prev, want[2:4])
+
+	status := models.OrganizationStatusActive
+	active, err := repos.Organizations.ListKeyset(ctx, repositories.KeysetQuery{Limit: 10, Status: &status})
+	if err != nil {
+		t.Fatalf("list active: %v", err)
+	}
+	check("active", active, want[1:])
+
+	total, err := repos.Organizations.Count(ctx, &status)
+	if err != nil {
+		t.Fatalf("count active: %v", err)
+	}
+	if total != 4 {
+		t.Fatalf("active count = %d, want 4", total)
+	}
+}
+
+func testProjectCRUD(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	other := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	first := newProject(t, repos, org.ID, base)
+	second := newProject(t, repos, org.ID, base.Add(time.Minute))
+	newProject(t, repos, other.ID, base)
+
+	projects, total, err := repos.Projects.List(ctx, org.ID, 1, 10, nil)
+	if err != nil {
+		t.Fatalf("list projects: %v", err)
+	}
+	if total != 2 || len(projects) != 2 || projects[0].ID != second.ID {
+		t.Fatalf("projects = %v (total %d)", projects, total)
+	}
+
+	ids, err := repos.Projects.ListIDs(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("list project ids: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("project ids = %v, want 2", ids)
+	}
+
+	if err := repos.Projects.Update(ctx, first.ID, bson.M{"status": models.ProjectStatusCompleted}); err != nil {
+		t.Fatalf("update project: %v", err)
+	}
+	got, err := repos.Projects.GetByID(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if got.Status != models.ProjectStatusCompleted {
+		t.Fatalf("status = %q after update", got.Status)
+	}
+
+	if err := repos.Projects.Delete(ctx, first.ID); err != nil {
+		t.Fatalf("delete project: %v", err)
+	}
+	_, err = repos.Projects.GetByID(ctx, first.ID)
+	requireNotFound(t, err)
+	requireNotFound(t, repos.Projects.Delete(ctx, first.ID))
+}
+
+func testProjectWorkflow(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+
+	got, err := repos.Projects.GetByID(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if got.Workflow != nil {
+		t.Fatalf("new project has workflow %+v", got.Workflow)
+	}
+
+	workflow := models.Workflow{
+		Initial:     "todo",
+		States:      []string{"todo", "done"},
+		Transitions: map[string][]string{"todo": {"done"}},
+	}
+	if err := repos.Projects.SetWorkflow(ctx, project.ID, workflow); err != nil {
+		t.Fatalf("set workflow: %v", err)
+	}
+
+	got, err = repos.Projects.GetByID(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if got.Workflow == nil || got.Workflow.Initial != "todo" || !got.Workflow.CanTransition("todo", "done") {
+		t.Fatalf("workflow = %+v", got.Workflow)
+	}
+
+	requireNotFound(t, repos.Projects.SetWorkflow(ctx, newID(), workflow))
+}
+
+func testTaskLists(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	pending := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityHigh, base)
+	newTask(t, repos, project.ID, models.TaskStatusDone, models.TaskPriorityHigh, base.Add(time.Minute))
+	newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base.Add(2*time.Minute))
+
+	tasks, total, err := repos.Tasks.ListByProject(ctx, project.ID, repositories.TaskQuery{}, 1, 10)
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if total != 3 || len(tasks) != 3 {
+		t.Fatalf("tasks = %v (total %d)", tasks, total)
+	}
+
+	query := repositories.TaskQuery{Conditions: []repositories.TaskCondition{
+		{Field: "status", Op: repositories.OpEq, Values: []interface{}{models.TaskStatusPending}},
+		{Field: "priority", Op: repositories.OpEq, Values: []interface{}{models.TaskPriorityHigh}},
+	}}
+	tasks, total, err = repos.Tasks.ListByProject(ctx, project.ID, query, 1, 10)
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if total != 1 || len(tasks) != 1 || tasks[0].ID != pending.ID {
+		t.Fatalf("filtered tasks = %v (total %d)", tasks, total)
+	}
+
+	count, err := repos.Tasks.CountOutsideStates(ctx, project.ID, []string{models.TaskStatusPending})
+	if err != nil {
+		t.Fatalf("count tasks: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("tasks outside pending = %d, want 1", count)
+	}
+}
+
+func testTaskQuery(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	urgent := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityUrgent, base)
+	high := newTask(t, repos, project.ID, models.TaskStatusInProgress, models.TaskPriorityHigh, base.Add(time.Minute))
+	done := newTask(t, repos, project.ID, models.TaskStatusDone, models.TaskPriorityHigh, base.Add(2*time.Minute))
+	low := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base.Add(3*time.Minute))
+	for _, id := range []string{high.ID, done.ID} {
+		if _, err := repos.Tasks.Assign(ctx, id, "user-1", "user-0"); err != nil {
+			t.Fatalf("assign task: %v", err)
+		}
+	}
+
+	ids := func(query repositories.TaskQuery) []string {
+		t.Helper()
+		tasks, total, err := repos.Tasks.ListByProject(ctx, project.ID, query, 1, 10)
+		if err != nil {
+			t.Fatalf("list tasks %+v: %v", query, err)
+		}
+		if total != int64(len(tasks)) {
+			t.Fatalf("total = %d, want %d", total, len(tasks))
+		}
+		out := []string{}
+		for _, task := range tasks {
+			out = append(out, task.ID)
+		}
+		return out
+	}
+	cond := func(field string, op string, values ...interface{}) repositories.TaskCondition {
+		return repositories.TaskCondition{Field: field, Op: op, Values: values}
+	}
+
+	for _, tt := range []struct {
+		name  string
+		query repositories.TaskQuery
+		want  []string
+	}{
+		{"in", repositories.TaskQuery{Conditions: []repositories.TaskCondition{
+			cond("priority", repositories.OpIn, models.TaskPriorityHigh, models.TaskPriorityUrgent),
+		}}, []string{done.ID, high.ID, urgent.ID}},
+		{"assigned to me and not done", repositories.TaskQuery{Conditions: []repositories.TaskCondition{
+			cond("assignedTo", repositories.OpEq, "user-1"),
+			cond("status", repositories.OpNin, models.TaskStatusDone),
+		}}, []string{high.ID}},
+		{"ne matches missing field", repositories.TaskQuery{Conditions: []repositories.TaskCondition{
+			cond("assignedTo", repositories.OpNe, "user-1"),
+		}}, []string{low.ID, urgent.ID}},
+		{"unassigned", repositories.TaskQuery{Conditions: []repositories.TaskCondition{
+			{Field: "assignedTo", Op: repositories.OpExists, Exists: false},
+		}}, []string{low.ID, urgent.ID}},
+		{"created range", repositories.TaskQuery{Conditions: []repositories.TaskCondition{
+			cond("createdAt", repositories.OpGte, base.Add(time.Minute)),
+			cond("createdAt", repositories.OpLt, base.Add(3*time.Minute)),
+		}}, []string{done.ID, high.ID}},
+		{"multi-key sort", repositories.TaskQuery{Sort: []repositories.TaskSort{
+			{Field: "status"},
+			{Field: "createdAt", Desc: true},
+		}}, []string{done.ID, high.ID, low.ID, urgent.ID}},
+		{"priority sorts by rank", repositories.TaskQuery{Sort: []repositories.TaskSort{
+			{Field: "priority", Desc: true},
+			{Field: "createdAt"},
+		}}, []string{urgent.ID, high.ID, done.ID, low.ID}},
+	} {
+		if got := ids(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: tasks = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	for _, query := range []repositories.TaskQuery{
+		{Conditions: []repositories.TaskCondition{cond("title", repositories.OpEq, "task")}},
+		{Conditions: []repositories.TaskCondition{cond("status", "$where", "1")}},
+		{Conditions: []repositories.TaskCondition{cond("status", repositories.OpGt, "a")}},
+		{Conditions: []repositories.TaskCondition{cond("createdAt", repositories.OpEq, "yesterday")}},
+		{Sort: []repositories.TaskSort{{Field: "description"}}},
+	} {
+		_, _, err := repos.Tasks.ListByProject(ctx, project.ID, query, 1, 10)
+		if !errors.Is(err, repositories.ErrInvalidTaskQuery) {
+			t.Errorf("query %+v: err = %v, want ErrInvalidTaskQuery", query, err)
+		}
+	}
+}
+
+func testTaskAssignment(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	task := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+
+	got, err := repos.Tasks.Assign(ctx, task.ID, "user-1", "user-0")
+	if err != nil {
+		t.Fatalf("assign task: %v", err)
+	}
+	if got.AssignedTo == nil || *got.AssignedTo != "user-1" || got.AssignedAt == nil {
+		t.Fatalf("assigned task = %+v", got)
+	}
+
+	tasks, total, err := repos.Tasks.ListByAssignee(ctx, "user-1", 1, 10, nil)
+	if err != nil {
+		t.Fatalf("list assigned tasks: %v", err)
+	}
+	if total != 1 || len(tasks) != 1 || tasks[0].ID != task.ID {
+		t.Fatalf("assigned tasks = %v (total %d)", tasks, total)
+	}
+
+	got, err = repos.Tasks.Unassign(ctx, task.ID, "user-0")
+	if err != nil {
+		t.Fatalf("unassign task: %v", err)
+	}
+	if got.AssignedTo != nil || got.AssignedAt != nil {
+		t.Fatalf("unassigned task = %+v", got)
+	}
+
+	_, err = repos.Tasks.Assign(ctx, newID(), "user-1", "user-0")
+	requireNotFound(t, err)
+	_, err = repos.Tasks.Unassign(ctx, newID(), "user-0")
+	requireNotFound(t, err)
+}
+
+func testTaskTransition(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	task := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+
+	transition := models.StatusTransition{
+		TaskID:    task.ID,
+		ProjectID: project.ID,
+		From:      models.TaskStatusPending,
+		To:        models.TaskStatusInProgress,
+		Actor:     "user-1",
+		At:        base.Add(time.Minute),
+	}
+	update := bson.M{"status": models.TaskStatusInProgress, "updatedAt": transition.At}
+	if err := repos.Tasks.Transition(ctx, task.ID, models.TaskStatusPending, update, transition); err != nil {
+		t.Fatalf("transition task: %v", err)
+	}
+
+	// The task is no longer pending, so the same transition must conflict
+	err := repos.Tasks.Transition(ctx, task.ID, models.TaskStatusPending, update, transition)
+	if !errors.Is(err, repositories.ErrStatusConflict) {
+		t.Fatalf("stale transition returned %v, want ErrStatusConflict", err)
+	}
+	requireNotFound(t, repos.Tasks.Transition(ctx, newID(), models.TaskStatusPending, update, transition))
+
+	got, err := repos.Tasks.GetByID(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.Status != models.TaskStatusInProgress {
+		t.Fatalf("status = %q after transition", got.Status)
+	}
+
+	transitions, err := repos.Tasks.ListTransitions(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("list transitions: %v", err)
+	}
+	if len(transitions) != 1 || transitions[0].ID == "" || transitions[0].Actor != "user-1" {
+		t.Fatalf("transitions = %+v", transitions)
+	}
+}
+
+func testActivity(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	task := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	before := newComment(t, repos, task, base)
+
+	// Setting the priority it has already is no change
+	patch := repositories.Patch{
+		Set:   bson.M{"title": "renamed", "priority": models.TaskPriorityMedium, "updatedAt": base},
+		Actor: "user-1",
+	}
+	if err := repos.Tasks.Patch(ctx, task.ID, patch, nil); err != nil {
+		t.Fatalf("patch task: %v", err)
+	}
+	if _, err := repos.Tasks.Assign(ctx, task.ID, "user-2", "user-1"); err != nil {
+		t.Fatalf("assign task: %v", err)
+	}
+	if _, err := repos.Tasks.Unassign(ctx, task.ID, "user-3"); err != nil {
+		t.Fatalf("unassign task: %v", err)
+	}
+	transition := models.StatusTransition{TaskID: task.ID, ProjectID: project.ID, To: models.TaskStatusDone, Actor: "user-4"}
+	if err := repos.Tasks.Transition(ctx, task.ID, models.TaskStatusPending, bson.M{"status": models.TaskStatusDone}, transition); err != nil {
+		t.Fatalf("transition task: %v", err)
+	}
+	after := newComment(t, repos, task, time.Now().Add(time.Hour))
+
+	activity, total, err := repos.Activity.List(ctx, task.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	if total != 4 || len(activity) != 4 {
+		t.Fatalf("activity = %+v (total %d), want 4 changes", activity, total)
+	}
+	for i, want := range []struct {
+		field, actor string
+		old, new     interface{}
+	}{
+		{"title", "user-1", "task", "renamed"},
+		{"assignedTo", "user-1", nil, "user-2"},
+		{"assignedTo", "user-3", "user-2", nil},
+		{"status", "user-4", models.TaskStatusPending, models.TaskStatusDone},
+	} {
+		got := activity[i]
+		if got.ID == "" || got.TaskID != task.ID || got.ProjectID != project.ID || got.Field != want.field ||
+			got.Actor != want.actor || got.Old != want.old || got.New != want.new {
+			t.Fatalf("activity[%d] = %+v, want %s changed by %s from %v to %v", i, got, want.field, want.actor, want.old, want.new)
+		}
+	}
+
+	// Comments are placed by when they were written
+	timeline, total, err := repos.Activity.Timeline(ctx, task.ID, 2, 4)
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	if total != 6 || len(timeline) != 2 ||
+		timeline[0].Type != models.TimelineActivity || timeline[0].Activity == nil || timeline[0].Activity.ID != activity[3].ID ||
+		timeline[1].Type != models.TimelineComment || timeline[1].Comment == nil || timeline[1].Comment.ID != after.ID {
+		t.Fatalf("timeline page 2 = %+v (total %d)", timeline, total)
+	}
+	timeline, _, err = repos.Activity.Timeline(ctx, task.ID, 1, 2)
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	if len(timeline) != 2 || timeline[0].Comment == nil || timeline[0].Comment.ID != before.ID || !timeline[0].At.Equal(base) ||
+		timeline[1].Activity == nil || timeline[1].Activity.ID != activity[0].ID {
+		t.Fatalf("timeline page 1 = %+v", timeline)
+	}
+
+	if err := repos.Tasks.Delete(ctx, task.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	_, total, err = repos.Activity.Timeline(ctx, task.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	if total != 0 {
+		t.Fatalf("%d timeline items left after deleting the task", total)
+	}
+}
+
+func newComment(t *testing.T, repos repositories.Repositories, task models.Task, createdAt time.Time) models.Comment {
+	t.Helper()
+
+	comment := models.Comment{
+		ID:        newID(),
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		Author:    "user-1",
+		Body:      "looks good",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	if err := repos.Comments.Create(context.Background(), comment); err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	return comment
+}
+
+func testComments(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	task := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	other := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	second := newComment(t, repos, task, base.Add(time.Minute))
+	first := newComment(t, repos, task, base)
+	newComment(t, repos, other, base)
+
+	comments, total, err := repos.Comments.List(ctx, task.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("list comments: %v", err)
+	}
+	if total != 2 || len(comments) != 2 || comments[0].ID != first.ID || comments[1].ID != second.ID {
+		t.Fatalf("comments = %+v (total %d), want oldest first", comments, total)
+	}
+
+	edited := base.Add(time.Hour)
+	if err := repos.Comments.Update(ctx, first.ID, bson.M{"body": "edited", "updatedAt": edited}); err != nil {
+		t.Fatalf("update comment: %v", err)
+	}
+	got, err := repos.Comments.GetByID(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("get comment: %v", err)
+	}
+	if got.Body != "edited" || !got.UpdatedAt.Equal(edited) || !got.CreatedAt.Equal(base) || got.Author != "user-1" {
+		t.Fatalf("comment = %+v", got)
+	}
+	requireNotFound(t, repos.Comments.Update(ctx, newID(), bson.M{"body": "x"}))
+
+	if err := repos.Comments.Delete(ctx, first.ID); err != nil {
+		t.Fatalf("delete comment: %v", err)
+	}
+	requireNotFound(t, repos.Comments.Delete(ctx, first.ID))
+	_, err = repos.Comments.GetByID(ctx, first.ID)
+	requireNotFound(t, err)
+
+	// Comments go with the project's tasks
+	if _, err := repos.Projects.DeleteCascade(ctx, project.ID); err != nil {
+		t.Fatalf("delete project: %v", err)
+	}
+	for _, taskID := range []string{task.ID, other.ID} {
+		_, total, err := repos.Comments.List(ctx, taskID, 1, 10)
+		if err != nil {
+			t.Fatalf("list comments: %v", err)
+		}
+		if total != 0 {
+			t.Fatalf("%d comments left after deleting the project", total)
+		}
+	}
+}
+
+func testPatch(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	if err := repos.Organizations.Update(ctx, org.ID, bson.M{"description": "old"}); err != nil {
+		t.Fatalf("update organization: %v", err)
+	}
+
+	err := repos.Organizations.Patch(ctx, org.ID, repositories.Patch{
+		Set:   bson.M{"name": "renamed"},
+		Unset: []string{"description"},
+	})
+	if err != nil {
+		t.Fatalf("patch organization: %v", err)
+	}
+	gotOrg, err := repos.Organizations.GetByID(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("get organization: %v", err)
+	}
+	if gotOrg.Name != "renamed" || gotOrg.Description != nil {
+		t.Fatalf("patched organization = %+v", gotOrg)
+	}
+	requireNotFound(t, repos.Organizations.Patch(ctx, newID(), repositories.Patch{Set: bson.M{"name": "x"}}))
+
+	project := newProject(t, repos, org.ID, base)
+	if err := repos.Projects.Patch(ctx, project.ID, repositories.Patch{Set: bson.M{"description": "new"}}); err != nil {
+		t.Fatalf("patch project: %v", err)
+	}
+	gotProject, err := repos.Projects.GetByID(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if gotProject.Description == nil || *gotProject.Description != "new" {
+		t.Fatalf("patched project = %+v", gotProject)
+	}
+	requireNotFound(t, repos.Projects.Patch(ctx, newID(), repositories.Patch{Set: bson.M{"name": "x"}}))
+
+	task := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	if err := repos.Tasks.Update(ctx, task.ID, bson.M{"description": "old"}); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+	transition := models.StatusTransition{
+		TaskID:    task.ID,
+		ProjectID: project.ID,
+		From:      models.TaskStatusPending,
+		To:        models.TaskStatusInProgress,
+		Actor:     "user-1",
+		At:        base,
+	}
+	patch := repositories.Patch{
+		Set:   bson.M{"status": models.TaskStatusInProgress},
+		Unset: []string{"description"},
+	}
+	if err := repos.Tasks.Patch(ctx, task.ID, patch, &transition); err != nil {
+		t.Fatalf("patch task: %v", err)
+	}
+	if err := repos.Tasks.Patch(ctx, task.ID, patch, &transition); !errors.Is(err, repositories.ErrStatusConflict) {
+		t.Fatalf("patch task from a stale status: err = %v, want ErrStatusConflict", err)
+	}
+
+	gotTask, err := repos.Tasks.GetByID(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if gotTask.Status != models.TaskStatusInProgress || gotTask.Description != nil {
+		t.Fatalf("patched task = %+v", gotTask)
+	}
+	transitions, err := repos.Tasks.ListTransitions(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("list transitions: %v", err)
+	}
+	if len(transitions) != 1 || transitions[0].ID == "" {
+		t.Fatalf("transitions = %+v, want one", transitions)
+	}
+
+	if err := repos.Tasks.Patch(ctx, task.ID, repositories.Patch{Set: bson.M{"title": "plain"}}, nil); err != nil {
+		t.Fatalf("patch task without transition: %v", err)
+	}
+	requireNotFound(t, repos.Tasks.Patch(ctx, newID(), repositories.Patch{Set: bson.M{"title": "x"}}, nil))
+	requireNotFound(t, repos.Tasks.Patch(ctx, newID(), patch, &transition))
+}
+
+func testBulkPatch(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	moved := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+	raised := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+	stale := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+	started := newTask(t, repos, project.ID, models.TaskStatusInProgress, models.TaskPriorityLow, base)
+	deleted := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+	if err := repos.Tasks.SoftDelete(ctx, deleted.ID, base); err != nil {
+		t.Fatalf("soft delete task: %v", err)
+	}
+
+	version := int64(0)
+	staleVersion := int64(5)
+	raise := repositories.Patch{Set: bson.M{"priority": models.TaskPriorityHigh}, IfVersion: &version, Actor: "user-1"}
+	start := repositories.Patch{Set: bson.M{"status": models.TaskStatusInProgress}, IfVersion: &version, Actor: "user-1"}
+	transition := func(task models.Task) *models.StatusTransition {
+		return &models.StatusTransition{
+			TaskID:    task.ID,
+			ProjectID: project.ID,
+			From:      models.TaskStatusPending,
+			To:        models.TaskStatusInProgress,
+			Actor:     "user-1",
+			At:        base,
+		}
+	}
+
+	errs, err := repos.Tasks.BulkPatch(ctx, []repositories.TaskPatch{
+		{ID: moved.ID, Patch: start, Transition: transition(moved)},
+		{ID: raised.ID, Patch: raise},
+		{ID: stale.ID, Patch: repositories.Patch{Set: bson.M{"priority": models.TaskPriorityHigh}, IfVersion: &staleVersion}},
+		{ID: started.ID, Patch: start, Transition: transition(started)},
+		{ID: deleted.ID, Patch: raise},
+		{ID: newID(), Patch: raise},
+	})
+	if err != nil {
+		t.Fatalf("bulk patch: %v", err)
+	}
+	want := []error{
+		nil,
+		nil,
+		repositories.ErrVersionConflict,
+		repositories.ErrStatusConflict,
+		mongo.ErrNoDocuments,
+		mongo.ErrNoDocuments,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("bulk patch errors = %v, want %v", errs, want)
+	}
+	for i := range want {
+		if !errors.Is(errs[i], want[i]) {
+			t.Fatalf("bulk patch error %d = %v, want %v", i, errs[i], want[i])
+		}
+	}
+
+	gotMoved, err := repos.Tasks.GetByID(ctx, moved.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if gotMoved.Status != models.TaskStatusInProgress || gotMoved.Version != 1 {
+		t.Fatalf("moved task = %+v", gotMoved)
+	}
+	gotRaised, err := repos.Tasks.GetByID(ctx, raised.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if gotRaised.Priority != models.TaskPriorityHigh || gotRaised.Version != 1 {
+		t.Fatalf("raised task = %+v", gotRaised)
+	}
+	gotStale, err := repos.Tasks.GetByID(ctx, stale.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if gotStale.Priority != models.TaskPriorityLow || gotStale.Version != 0 {
+		t.Fatalf("stale task = %+v, want it unchanged", gotStale)
+	}
+
+	transitions, err := repos.Tasks.ListTransitions(ctx, moved.ID)
+	if err != nil {
+		t.Fatalf("list transitions: %v", err)
+	}
+	if len(transitions) != 1 {
+		t.Fatalf("transitions = %+v, want one", transitions)
+	}
+	transitions, err = repos.Tasks.ListTransitions(ctx, started.ID)
+	if err != nil {
+		t.Fatalf("list transitions: %v", err)
+	}
+	if len(transitions) != 0 {
+		t.Fatalf("transitions of the conflicting task = %+v, want none", transitions)
+	}
+
+	activity, _, err := repos.Activity.List(ctx, raised.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	if len(activity) != 1 || activity[0].Actor != "user-1" {
+		t.Fatalf("activity = %+v, want the priority change", activity)
+	}
+}
+
+func testUniqueNames(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	other := newOrganization(t, repos, models.OrganizationStatusActive, base)
+
+	duplicate := org
+	duplicate.ID = newID()
+	duplicate.Name = strings.ToUpper(org.Name)
+	requireNameConflict(t, repos.Organizations.Create(ctx, duplicate))
+	requireNameConflict(t, repos.Organizations.Update(ctx, other.ID, bson.M{"name": org.Name}))
+	requireNameConflict(t, repos.Organizations.Patch(ctx, other.ID, repositories.Patch{Set: bson.M{"name": duplicate.Name}}))
+	if err := repos.Organizations.Update(ctx, org.ID, bson.M{"name": duplicate.Name}); err != nil {
+		t.Fatalf("change the case of an organization's own name: %v", err)
+	}
+
+	project := newProject(t, repos, org.ID, base)
+	sibling := newProject(t, repos, org.ID, base)
+	requireNameConflict(t, repos.Projects.Update(ctx, sibling.ID, bson.M{"name": strings.ToUpper(project.Name)}))
+
+	copied := project
+	copied.ID = newID()
+	requireNameConflict(t, repos.Projects.Create(ctx, copied))
+	copied.OrganizationID = other.ID
+	if err := repos.Projects.Create(ctx, copied); err != nil {
+		t.Fatalf("create project named like one of another organization: %v", err)
+	}
+}
+
+func requireNameConflict(t *testing.T, err error) {
+	t.Helper()
+
+	var conflict *repositories.ConflictError
+	if !errors.As(err, &conflict) || conflict.Field != "name" {
+		t.Fatalf("err = %v, want a name ConflictError", err)
+	}
+}
+
+func testVersions(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	task := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+
+	stale := int64(0)
+	if err := repos.Organizations.Patch(ctx, org.ID, repositories.Patch{Set: bson.M{"description": "one"}, IfVersion: &stale}); err != nil {
+		t.Fatalf("patch organization at its version: %v", err)
+	}
+	err := repos.Organizations.Patch(ctx, org.ID, repositories.Patch{Set: bson.M{"description": "two"}, IfVersion: &stale})
+	if !errors.Is(err, repositories.ErrVersionConflict) {
+		t.Fatalf("stale organization patch returned %v, want ErrVersionConflict", err)
+	}
+	requireNotFound(t, repos.Organizations.Patch(ctx, newID(), repositories.Patch{Set: bson.M{"description": "two"}, IfVersion: &stale}))
+	if err := repos.Organizations.Update(ctx, org.ID, bson.M{"description": "two"}); err != nil {
+		t.Fatalf("update organization: %v", err)
+	}
+	gotOrg, err := repos.Organizations.GetByID(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("get organization: %v", err)
+	}
+	if gotOrg.Version != 2 {
+		t.Fatalf("organization version = %d after two writes, want 2", gotOrg.Version)
+	}
+
+	if err := repos.Projects.Update(ctx, project.ID, bson.M{"description": "one"}); err != nil {
+		t.Fatalf("update project: %v", err)
+	}
+	err = repos.Projects.Patch(ctx, project.ID, repositories.Patch{Set: bson.M{"description": "two"}, IfVersion: &stale})
+	if !errors.Is(err, repositories.ErrVersionConflict) {
+		t.Fatalf("stale project patch returned %v, want ErrVersionConflict", err)
+	}
+
+	if _, err := repos.Tasks.Assign(ctx, task.ID, "user-1", "user-0"); err != nil {
+		t.Fatalf("assign task: %v", err)
+	}
+	gotTask, err := repos.Tasks.Unassign(ctx, task.ID, "user-0")
+	if err != nil {
+		t.Fatalf("unassign task: %v", err)
+	}
+	if gotTask.Version != 2 {
+		t.Fatalf("task version = %d after two writes, want 2", gotTask.Version)
+	}
+	err = repos.Tasks.Patch(ctx, task.ID, repositories.Patch{Set: bson.M{"title": "stale"}, IfVersion: &stale}, nil)
+	if !errors.Is(err, repositories.ErrVersionConflict) {
+		t.Fatalf("stale task patch returned %v, want ErrVersionConflict", err)
+	}
+	transition := &models.StatusTransition{
+		TaskID:    task.ID,
+		ProjectID: project.ID,
+		From:      models.TaskStatusPending,
+		To:        models.TaskStatusInProgress,
+		At:        base,
+	}
+	err = repos.Tasks.Patch(ctx, task.ID, repositories.Patch{Set: bson.M{"status": transition.To}, IfVersion: &stale}, transition)
+	if !errors.Is(err, repositories.ErrVersionConflict) {
+		t.Fatalf("stale task transition returned %v, want ErrVersionConflict", err)
+	}
+	current := gotTask.Version
+	if err := repos.Tasks.Patch(ctx, task.ID, repositories.Patch{Set: bson.M{"status": transition.To}, IfVersion: &current}, transition); err != nil {
+		t.Fatalf("transition task at its version: %v", err)
+	}
+}
+
+func testArchive(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	first := newProject(t, repos, org.ID, base)
+	second := newProject(t, repos, org.ID, base)
+	firstTask := newTask(t, repos, first.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+	secondTask := newTask(t, repos, second.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+
+	// The first project is archived on its own and stays archived when the
+	// organization is unarchived
+	res, err := repos.Projects.Archive(ctx, first.ID, base)
+	if err != nil {
+		t.Fatalf("archive project: %v", err)
+	}
+	if len(res.TaskIDs) != 1 || res.TaskIDs[0] != firstTask.ID {
+		t.Fatalf("project archive cascade = %+v", res)
+	}
+	if _, err := repos.Projects.Archive(ctx, first.ID, base); !errors.Is(err, repositories.ErrArchived) {
+		t.Fatalf("archive archived project returned %v, want ErrArchived", err)
+	}
+	err = repos.Tasks.Update(ctx, firstTask.ID, bson.M{"title": "x"})
+	if !errors.Is(err, repositories.ErrArchived) {
+		t.Fatalf("update archived task returned %v, want ErrArchived", err)
+	}
+	if _, err := repos.Tasks.Assign(ctx, firstTask.ID, "user-1", "user-0"); !errors.Is(err, repositories.ErrArchived) {
+		t.Fatalf("assign archived task returned %v, want ErrArchived", err)
+	}
+
+	res, err = repos.Organizations.Archive(ctx, org.ID, base)
+	if err != nil {
+		t.Fatalf("archive organization: %v", err)
+	}
+	if len(res.ProjectIDs) != 1 || res.ProjectIDs[0] != second.ID || len(res.TaskIDs) != 1 || res.TaskIDs[0] != secondTask.ID {
+		t.Fatalf("organization archive cascade = %+v", res)
+	}
+	gotOrg, err := repos.Organizations.GetByID(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("get organization: %v", err)
+	}
+	if gotOrg.Status != models.OrganizationStatusArchived || gotOrg.ArchivedAt == nil {
+		t.Fatalf("archived organization = %+v", gotOrg)
+	}
+	err = repos.Organizations.Patch(ctx, org.ID, repositories.Patch{Set: bson.M{"description": "x"}})
+	if !errors.Is(err, repositories.ErrArchived) {
+		t.Fatalf("patch archived organization returned %v, want ErrArchived", err)
+	}
+	err = repos.Projects.Update(ctx, second.ID, bson.M{"description": "x"})
+	if !errors.Is(err, repositories.ErrArchived) {
+		t.Fatalf("update archived project returned %v, want ErrArchived", err)
+	}
+	if _, err := repos.Projects.Unarchive(ctx, second.ID); !errors.Is(err, repositories.ErrParentArchived) {
+		t.Fatalf("unarchive project of archived organization returned %v, want ErrParentArchived", err)
+	}
+
+	res, err = repos.Organizations.Unarchive(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("unarchive organization: %v", err)
+	}
+	if len(res.ProjectIDs) != 1 || res.ProjectIDs[0] != second.ID || len(res.TaskIDs) != 1 || res.TaskIDs[0] != secondTask.ID {
+		t.Fatalf("organization unarchive cascade = %+v", res)
+	}
+	if _, err := repos.Organizations.Unarchive(ctx, org.ID); !errors.Is(err, repositories.ErrNotArchived) {
+		t.Fatalf("unarchive active organization returned %v, want ErrNotArchived", err)
+	}
+	gotOrg, err = repos.Organizations.GetByID(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("get organization: %v", err)
+	}
+	if gotOrg.Status != models.OrganizationStatusActive || gotOrg.ArchivedAt != nil {
+		t.Fatalf("unarchived organization = %+v", gotOrg)
+	}
+	if err := repos.Tasks.Update(ctx, secondTask.ID, bson.M{"title": "writable"}); err != nil {
+		t.Fatalf("update unarchived task: %v", err)
+	}
+	gotTask, err := repos.Tasks.GetByID(ctx, firstTask.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if gotTask.ArchivedAt == nil {
+		t.Fatal("task of the project archived on its own was unarchived with the organization")
+	}
+
+	if _, err := repos.Projects.Unarchive(ctx, first.ID); err != nil {
+		t.Fatalf("unarchive project: %v", err)
+	}
+	if err := repos.Tasks.Update(ctx, firstTask.ID, bson.M{"title": "writable"}); err != nil {
+		t.Fatalf("update unarchived task: %v", err)
+	}
+
+	_, err = repos.Organizations.Archive(ctx, newID(), base)
+	requireNotFound(t, err)
+	_, err = repos.Projects.Unarchive(ctx, newID())
+	requireNotFound(t, err)
+}
+
+func testTrash(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	deletedProject := newProject(t, repos, org.ID, base)
+	task := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+	deletedTask := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+	cascadedTask := newTask(t, repos, deletedProject.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+
+	if err := repos.Tasks.SoftDelete(ctx, deletedTask.ID, base); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	requireNotFound(t, repos.Tasks.SoftDelete(ctx, deletedTask.ID, base))
+	_, err := repos.Tasks.GetByID(ctx, deletedTask.ID)
+	requireNotFound(t, err)
+	requireNotFound(t, repos.Tasks.Update(ctx, deletedTask.ID, bson.M{"title": "x"}))
+	tasks, total, err := repos.Tasks.ListByProject(ctx, project.ID, repositories.TaskQuery{}, 1, 10)
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if total != 1 || len(tasks) != 1 || tasks[0].ID != task.ID {
+		t.Fatalf("tasks = %v (total %d), want only the task not in the trash", tasks, total)
+	}
+
+	res, err := repos.Projects.SoftDelete(ctx, deletedProject.ID, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("delete project: %v", err)
+	}
+	if len(res.TaskIDs) != 1 || res.TaskIDs[0] != cascadedTask.ID {
+		t.Fatalf("project delete cascade = %+v", res)
+	}
+	_, err = repos.Tasks.GetByID(ctx, cascadedTask.ID)
+	requireNotFound(t, err)
+	if err := repos.Tasks.Restore(ctx, cascadedTask.ID); !errors.Is(err, repositories.ErrParentDeleted) {
+		t.Fatalf("restore task of deleted project returned %v, want ErrParentDeleted", err)
+	}
+
+	items, total, err := repos.Trash.List(ctx, org.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("list trash: %v", err)
+	}
+	if total != 2 || len(items) != 2 ||
+		items[0].Type != models.TrashItemProject || items[0].ID != deletedProject.ID ||
+		items[1].Type != models.TrashItemTask || items[1].ID != deletedTask.ID ||
+		items[1].ProjectID == nil || *items[1].ProjectID != project.ID {
+		t.Fatalf("trash = %+v (total %d)", items, total)
+	}
+
+	expired, err := repos.Trash.Expired(ctx, base.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("expired: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != deletedTask.ID {
+		t.Fatalf("expired = %+v, want the task deleted first", expired)
+	}
+
+	// Deleting the organization leaves what was deleted before alone
+	res, err = repos.Organizations.SoftDelete(ctx, org.ID, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("delete organization: %v", err)
+	}
+	if len(res.ProjectIDs) != 1 || res.ProjectIDs[0] != project.ID || len(res.TaskIDs) != 1 || res.TaskIDs[0] != task.ID {
+		t.Fatalf("organization delete cascade = %+v", res)
+	}
+	_, err = repos.Organizations.GetByID(ctx, org.ID)
+	requireNotFound(t, err)
+	if _, err := repos.Projects.Restore(ctx, project.ID); !errors.Is(err, repositories.ErrParentDeleted) {
+		t.Fatalf("restore project of deleted organization returned %v, want ErrParentDeleted", err)
+	}
+	orgs, total, err := repos.Trash.ListOrganizations(ctx, 1, 10)
+	if err != nil {
+		t.Fatalf("list deleted organizations: %v", err)
+	}
+	if total != 1 || len(orgs) != 1 || orgs[0].ID != org.ID {
+		t.Fatalf("deleted organizations = %v (total %d)", orgs, total)
+	}
+
+	res, err = repos.Organizations.Restore(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("restore organization: %v", err)
+	}
+	if len(res.ProjectIDs) != 1 || res.ProjectIDs[0] != project.ID || len(res.TaskIDs) != 1 || res.TaskIDs[0] != task.ID {
+		t.Fatalf("organization restore cascade = %+v", res)
+	}
+	_, err = repos.Organizations.Restore(ctx, org.ID)
+	requireNotFound(t, err)
+	_, err = repos.Projects.GetByID(ctx, deletedProject.ID)
+	requireNotFound(t, err)
+
+	res, err = repos.Projects.Restore(ctx, deletedProject.ID)
+	if err != nil {
+		t.Fatalf("restore project: %v", err)
+	}
+	if len(res.TaskIDs) != 1 || res.TaskIDs[0] != cascadedTask.ID {
+		t.Fatalf("project restore cascade = %+v", res)
+	}
+	if err := repos.Tasks.Restore(ctx, deletedTask.ID); err != nil {
+		t.Fatalf("restore task: %v", err)
+	}
+	for _, id := range []string{task.ID, deletedTask.ID, cascadedTask.ID} {
+		if _, err := repos.Tasks.GetByID(ctx, id); err != nil {
+			t.Fatalf("get restored task: %v", err)
+		}
+	}
+
+	items, total, err = repos.Trash.List(ctx, org.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("list trash: %v", err)
+	}
+	if total != 0 || len(items) != 0 {
+		t.Fatalf("trash after restoring = %+v (total %d), want empty", items, total)
+	}
+}
+
+func testPurge(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	task := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+	projectTask := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+
+	// A task restored and deleted again after it expired is not purged
+	if err := repos.Tasks.SoftDelete(ctx, task.ID, base); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	requireNotFound(t, repos.Tasks.Purge(ctx, task.ID, base.Add(time.Hour)))
+	if err := repos.Tasks.Restore(ctx, task.ID); err != nil {
+		t.Fatalf("restore task: %v", err)
+	}
+	requireNotFound(t, repos.Tasks.Purge(ctx, task.ID, base))
+	if err := repos.Tasks.SoftDelete(ctx, task.ID, base.Add(time.Hour)); err != nil {
+		t.Fatalf("delete task again: %v", err)
+	}
+	requireNotFound(t, repos.Tasks.Purge(ctx, task.ID, base))
+	if err := repos.Tasks.Purge(ctx, task.ID, base.Add(time.Hour)); err != nil {
+		t.Fatalf("purge task: %v", err)
+	}
+	requireNotFound(t, repos.Tasks.Restore(ctx, task.ID))
+
+	if _, err := repos.Projects.Purge(ctx, project.ID, base); err != mongo.ErrNoDocuments {
+		t.Fatalf("purge project not in the trash: %v, want mongo.ErrNoDocuments", err)
+	}
+
+	// Purging the organization leaves its projects in the trash on their own
+	if _, err := repos.Organizations.SoftDelete(ctx, org.ID, base); err != nil {
+		t.Fatalf("delete organization: %v", err)
+	}
+	if _, err := repos.Organizations.Purge(ctx, org.ID, base.Add(time.Hour)); err != mongo.ErrNoDocuments {
+		t.Fatalf("purge organization deleted at another time: %v, want mongo.ErrNoDocuments", err)
+	}
+	res, err := repos.Organizations.Purge(ctx, org.ID, base)
+	if err != nil {
+		t.Fatalf("purge organization: %v", err)
+	}
+	if len(res.ProjectIDs) != 1 || res.ProjectIDs[0] != project.ID {
+		t.Fatalf("organization purge = %+v, want its project handed over", res)
+	}
+	_, err = repos.Organizations.Restore(ctx, org.ID)
+	requireNotFound(t, err)
+	if _, err := repos.Projects.Restore(ctx, project.ID); !errors.Is(err, repositories.ErrParentDeleted) {
+		t.Fatalf("restore project of purged organization returned %v, want ErrParentDeleted", err)
+	}
+
+	expired, err := repos.Trash.Expired(ctx, base.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("expired: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != project.ID || !expired[0].DeletedAt.Equal(base) {
+		t.Fatalf("expired = %+v, want the project of the purged organization", expired)
+	}
+	res, err = repos.Projects.Purge(ctx, project.ID, expired[0].DeletedAt)
+	if err != nil {
+		t.Fatalf("purge project: %v", err)
+	}
+	if len(res.ProjectIDs) != 1 || len(res.TaskIDs) != 1 || res.TaskIDs[0] != projectTask.ID {
+		t.Fatalf("project purge = %+v, want the project and its task", res)
+	}
+	requireNotFound(t, repos.Tasks.Restore(ctx, projectTask.ID))
+}
+
+func testChanges(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+
+	projectStream, err := repos.Changes.Watch(ctx, repositories.ChangeScope{ProjectID: project.ID}, "")
+	if err != nil {
+		t.Fatalf("watch project: %v", err)
+	}
+	defer projectStream.Close(ctx)
+	orgStream, err := repos.Changes.Watch(ctx, repositories.ChangeScope{OrganizationID: org.ID}, "")
+	if err != nil {
+		t.Fatalf("watch organization: %v", err)
+	}
+	defer orgStream.Close(ctx)
+
+	task := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+	newTask(t, repos, newProject(t, repos, newOrganization(t, repos, models.OrganizationStatusActive, base).ID, base).ID,
+		models.TaskStatusPending, models.TaskPriorityLow, base)
+	if err := repos.Tasks.Update(ctx, task.ID, bson.M{"title": "renamed"}); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+	if err := repos.Tasks.Delete(ctx, task.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	added := newProject(t, repos, org.ID, base)
+	addedTask := newTask(t, repos, added.ID, models.TaskStatusPending, models.TaskPriorityLow, base)
+
+	first := requireChange(t, projectStream, models.ChangeTypeTask, models.ChangeInsert, task.ID)
+	if first.Task == nil || first.Task.Title != task.Title {
+		t.Fatalf("inserted task = %+v", first.Task)
+	}
+	if change := requireChange(t, projectStream, models.ChangeTypeTask, models.ChangeUpdate, task.ID); change.Task == nil || change.Task.Title != "renamed" {
+		t.Fatalf("updated task = %+v", change.Task)
+	}
+	if change := requireChange(t, projectStream, models.ChangeTypeTask, models.ChangeDelete, task.ID); change.Task == nil || change.Task.ProjectID != project.ID {
+		t.Fatalf("deleted task = %+v", change.Task)
+	}
+
+	requireChange(t, orgStream, models.ChangeTypeTask, models.ChangeInsert, task.ID)
+	requireChange(t, orgStream, models.ChangeTypeTask, models.ChangeUpdate, task.ID)
+	requireChange(t, orgStream, models.ChangeTypeTask, models.ChangeDelete, task.ID)
+	requireChange(t, orgStream, models.ChangeTypeProject, models.ChangeInsert, added.ID)
+	requireChange(t, orgStream, models.ChangeTypeTask, models.ChangeInsert, addedTask.ID)
+
+	// Nothing else happened in the project
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if change, err := projectStream.Next(waitCtx); err == nil {
+		t.Fatalf("unexpected change %+v", change)
+	}
+
+	resumed, err := repos.Changes.Watch(ctx, repositories.ChangeScope{ProjectID: project.ID}, first.Token)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	defer resumed.Close(ctx)
+	requireChange(t, resumed, models.ChangeTypeTask, models.ChangeUpdate, task.ID)
+
+	if _, err := repos.Changes.Watch(ctx, repositories.ChangeScope{ProjectID: project.ID}, "bogus"); !errors.Is(err, repositories.ErrCannotResume) {
+		t.Fatalf("resume from a bogus token: err = %v, want ErrCannotResume", err)
+	}
+}
+
+func requireChange(t *testing.T, stream repositories.ChangeStream, changeType string, operation string, id string) *models.Change {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	change, err := stream.Next(ctx)
+	if err != nil {
+		t.Fatalf("next change: %v", err)
+	}
+	if change.Type != changeType || change.Operation != operation || change.ID != id || change.Token == "" {
+		t.Fatalf("change = %+v, want %s %s of %s", change, changeType, operation, id)
+	}
+	return change
+}
+
+func testCascadeDelete(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	other := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	first := newProject(t, repos, org.ID, base)
+	second := newProject(t, repos, org.ID, base)
+	kept := newProject(t, repos, other.ID, base)
+	firstTask := newTask(t, repos, first.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	secondTask := newTask(t, repos, second.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	keptTask := newTask(t, repos, kept.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+
+	res, err := repos.Projects.DeleteCascade(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("delete project cascade: %v", err)
+	}
+	if len(res.ProjectIDs) != 1 || len(res.TaskIDs) != 1 || res.TaskIDs[0] != firstTask.ID {
+		t.Fatalf("project cascade = %+v", res)
+	}
+
+	res, err = repos.Projects.DeleteCascade(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("repeated project cascade: %v", err)
+	}
+	if len(res.ProjectIDs) != 0 || len(res.TaskIDs) != 0 {
+		t.Fatalf("repeated project cascade = %+v, want nothing", res)
+	}
+
+	if _, err := repos.Tasks.GetByID(ctx, secondTask.ID); err != nil {
+		t.Fatalf("task of another project was deleted: %v", err)
+	}
+
+	if _, err := repos.Tasks.GetByID(ctx, keptTask.ID); err != nil {
+		t.Fatalf("task of another organization was deleted: %v", err)
+	}
+}
+
+// purgeOrganization moves the organization to the trash and purges it,
+// which takes its views and labels with it.
+func purgeOrganization(t *testing.T, repos repositories.Repositories, id string) {
+	t.Helper()
+
+	ctx := context.Background()
+	if _, err := repos.Organizations.SoftDelete(ctx, id, base); err != nil {
+		t.Fatalf("delete organization: %v", err)
+	}
+	if _, err := repos.Organizations.Purge(ctx, id, base); err != nil {
+		t.Fatalf("purge organization: %v", err)
+	}
+}
+
+func newView(t *testing.T, repos repositories.Repositories, orgID string, name string) models.View {
+	t.Helper()
+
+	view := models.View{
+		ID:             newID(),
+		OrganizationID: orgID,
+		Name:           name,
+		Query:          "status=pending",
+		CreatedAt:      base,
+		UpdatedAt:      base,
+	}
+	if err := repos.Views.Create(context.Background(), view); err != nil {
+		t.Fatalf("create view: %v", err)
+	}
+	return view
+}
+
+func testViews(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	other := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	mine := newView(t, repos, org.ID, "mine")
+	urgent := newView(t, repos, org.ID, "urgent")
+	kept := newView(t, repos, other.ID, "mine")
+
+	duplicate := mine
+	duplicate.ID = newID()
+	if err := repos.Views.Create(ctx, duplicate); !errors.Is(err, repositories.ErrViewNameTaken) {
+		t.Fatalf("create duplicate name: err = %v, want ErrViewNameTaken", err)
+	}
+
+	views, err := repos.Views.ListByOrganization(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("list views: %v", err)
+	}
+	if len(views) != 2 || views[0].ID != mine.ID || views[1].ID != urgent.ID {
+		t.Fatalf("views = %v, want mine and urgent", views)
+	}
+
+	if err := repos.Views.Update(ctx, urgent.ID, bson.M{"name": "mine"}); !errors.Is(err, repositories.ErrViewNameTaken) {
+		t.Fatalf("rename to a taken name: err = %v, want ErrViewNameTaken", err)
+	}
+	if err := repos.Views.Update(ctx, urgent.ID, bson.M{"query": "priority=urgent"}); err != nil {
+		t.Fatalf("update view: %v", err)
+	}
+	got, err := repos.Views.GetByID(ctx, urgent.ID)
+	if err != nil {
+		t.Fatalf("get view: %v", err)
+	}
+	if got.Name != "urgent" || got.Query != "priority=urgent" {
+		t.Fatalf("view = %+v", got)
+	}
+	requireNotFound(t, repos.Views.Update(ctx, newID(), bson.M{"name": "x"}))
+
+	if err := repos.Views.Delete(ctx, urgent.ID); err != nil {
+		t.Fatalf("delete view: %v", err)
+	}
+	requireNotFound(t, repos.Views.Delete(ctx, urgent.ID))
+
+	purgeOrganization(t, repos, org.ID)
+	_, err = repos.Views.GetByID(ctx, mine.ID)
+	requireNotFound(t, err)
+	if _, err := repos.Views.GetByID(ctx, kept.ID); err != nil {
+		t.Fatalf("view of another organization was deleted: %v", err)
+	}
+}
+
+func newLabel(t *testing.T, repos repositories.Repositories, orgID string, name string) models.Label {
+	t.Helper()
+
+	label := models.Label{
+		ID:             newID(),
+		OrganizationID: orgID,
+		Name:           name,
+		Color:          "#d73a4a",
+		CreatedAt:      base,
+		UpdatedAt:      base,
+	}
+	if err := repos.Labels.Create(context.Background(), label); err != nil {
+		t.Fatalf("create label: %v", err)
+	}
+	return label
+}
+
+func testLabels(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	other := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	bug := newLabel(t, repos, org.ID, "bug")
+	frontend := newLabel(t, repos, org.ID, "frontend")
+	kept := newLabel(t, repos, other.ID, "bug")
+
+	duplicate := bug
+	duplicate.ID = newID()
+	duplicate.Name = "BUG"
+	requireNameConflict(t, repos.Labels.Create(ctx, duplicate))
+	requireNameConflict(t, repos.Labels.Update(ctx, frontend.ID, bson.M{"name": "Bug"}))
+
+	if err := repos.Labels.Update(ctx, frontend.ID, bson.M{"color": "#0075ca"}); err != nil {
+		t.Fatalf("update label: %v", err)
+	}
+	labels, err := repos.Labels.ListByOrganization(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("list labels: %v", err)
+	}
+	if len(labels) != 2 || labels[0].ID != bug.ID || labels[1].ID != frontend.ID || labels[1].Color != "#0075ca" {
+		t.Fatalf("labels = %+v, want bug and the recoloured frontend", labels)
+	}
+	requireNotFound(t, repos.Labels.Update(ctx, newID(), bson.M{"name": "x"}))
+
+	project := newProject(t, repos, org.ID, base)
+	both := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	onlyBug := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base.Add(time.Minute))
+	none := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base.Add(2*time.Minute))
+	for id, labels := range map[string][]string{both.ID: {bug.ID, frontend.ID}, onlyBug.ID: {bug.ID}} {
+		if err := repos.Tasks.Patch(ctx, id, repositories.Patch{Set: bson.M{"labels": labels}}, nil); err != nil {
+			t.Fatalf("label task: %v", err)
+		}
+	}
+
+	ids := func(c repositories.TaskCondition) []string {
+		t.Helper()
+		tasks, _, err := repos.Tasks.ListByProject(ctx, project.ID, repositories.TaskQuery{Conditions: []repositories.TaskCondition{c}}, 1, 10)
+		if err != nil {
+			t.Fatalf("list tasks by %+v: %v", c, err)
+		}
+		out := []string{}
+		for _, task := range tasks {
+			out = append(out, task.ID)
+		}
+		return out
+	}
+	for _, tt := range []struct {
+		condition repositories.TaskCondition
+		want      []string
+	}{
+		{repositories.TaskCondition{Field: "labels", Op: repositories.OpEq, Values: []interface{}{bug.ID}}, []string{onlyBug.ID, both.ID}},
+		{repositories.TaskCondition{Field: "labels", Op: repositories.OpIn, Values: []interface{}{frontend.ID, kept.ID}}, []string{both.ID}},
+		{repositories.TaskCondition{Field: "labels", Op: repositories.OpNin, Values: []interface{}{frontend.ID}}, []string{none.ID, onlyBug.ID}},
+		{repositories.TaskCondition{Field: "labels", Op: repositories.OpExists, Exists: false}, []string{none.ID}},
+	} {
+		if got := ids(tt.condition); !slices.Equal(got, tt.want) {
+			t.Errorf("%s %s: tasks = %v, want %v", tt.condition.Field, tt.condition.Op, got, tt.want)
+		}
+	}
+	_, _, err = repos.Tasks.ListByProject(ctx, project.ID, repositories.TaskQuery{Sort: []repositories.TaskSort{{Field: "labels"}}}, 1, 10)
+	if !errors.Is(err, repositories.ErrInvalidTaskQuery) {
+		t.Errorf("sort by labels: err = %v, want ErrInvalidTaskQuery", err)
+	}
+
+	// Deleting a label takes it off its tasks
+	res, err := repos.Labels.Delete(ctx, bug.ID)
+	if err != nil {
+		t.Fatalf("delete label: %v", err)
+	}
+	slices.Sort(res.TaskIDs)
+	want := []string{both.ID, onlyBug.ID}
+	slices.Sort(want)
+	if !slices.Equal(res.TaskIDs, want) {
+		t.Fatalf("delete label touched %v, want %v", res.TaskIDs, want)
+	}
+	got, err := repos.Tasks.GetByID(ctx, both.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if !slices.Equal(got.Labels, []string{frontend.ID}) || got.Version != 2 {
+		t.Fatalf("task after deleting its label = %+v", got)
+	}
+	if got := ids(repositories.TaskCondition{Field: "labels", Op: repositories.OpExists, Exists: false}); !slices.Equal(got, []string{none.ID, onlyBug.ID}) {
+		t.Fatalf("unlabelled tasks = %v", got)
+	}
+	_, err = repos.Labels.Delete(ctx, bug.ID)
+	requireNotFound(t, err)
+
+	purgeOrganization(t, repos, org.ID)
+	_, err = repos.Labels.GetByID(ctx, frontend.ID)
+	requireNotFound(t, err)
+	if _, err := repos.Labels.GetByID(ctx, kept.ID); err != nil {
+		t.Fatalf("label of another organization was deleted: %v", err)
+	}
+}
+
+func testCustomFields(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+
+	fields := []models.CustomField{
+		{Key: "estimate", Name: "Estimate", Type: models.CustomFieldNumber},
+		{Key: "tier", Name: "Tier", Type: models.CustomFieldEnum, Options: []string{"gold", "silver"}},
+		{Key: "due", Name: "Due", Type: models.CustomFieldDate},
+	}
+	if err := repos.Projects.SetCustomFields(ctx, project.ID, fields); err != nil {
+		t.Fatalf("set custom fields: %v", err)
+	}
+	got, err := repos.Projects.GetByID(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if len(got.CustomFields) != 3 || got.CustomFields[1].Key != "tier" || !slices.Equal(got.CustomFields[1].Options, []string{"gold", "silver"}) {
+		t.Fatalf("custom fields = %+v", got.CustomFields)
+	}
+
+	small := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	large := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base.Add(time.Minute))
+	none := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base.Add(2*time.Minute))
+	for id, set := range map[string]bson.M{
+		small.ID: {"customFields.estimate": 3.0, "customFields.tier": "gold"},
+		large.ID: {"customFields.estimate": 8.0, "customFields.tier": "silver", "customFields.due": "2024-02-01"},
+	} {
+		if err := repos.Tasks.Patch(ctx, id, repositories.Patch{Set: set, Actor: "user-1"}, nil); err != nil {
+			t.Fatalf("set custom field values: %v", err)
+		}
+	}
+
+	ids := func(query repositories.TaskQuery) []string {
+		t.Helper()
+		tasks, _, err := repos.Tasks.ListByProject(ctx, project.ID, query, 1, 10)
+		if err != nil {
+			t.Fatalf("list tasks %+v: %v", query, err)
+		}
+		out := []string{}
+		for _, task := range tasks {
+			out = append(out, task.ID)
+		}
+		return out
+	}
+	cond := func(field string, op string, values ...interface{}) repositories.TaskCondition {
+		return repositories.TaskCondition{Field: field, Op: op, Values: values}
+	}
+	for _, tt := range []struct {
+		name  string
+		query repositories.TaskQuery
+		want  []string
+	}{
+		{"number range", repositories.TaskQuery{Conditions: []repositories.TaskCondition{
+			cond("customFields.estimate", repositories.OpGt, 5.0),
+		}}, []string{large.ID}},
+		{"enum", repositories.TaskQuery{Conditions: []repositories.TaskCondition{
+			cond("customFields.tier", repositories.OpIn, "gold"),
+		}}, []string{small.ID}},
+		{"date range", repositories.TaskQuery{Conditions: []repositories.TaskCondition{
+			cond("customFields.due", repositories.OpGte, "2024-01-15"),
+		}}, []string{large.ID}},
+		{"unset", repositories.TaskQuery{Conditions: []repositories.TaskCondition{
+			{Field: "customFields.estimate", Op: repositories.OpExists, Exists: false},
+		}}, []string{none.ID}},
+		{"sort", repositories.TaskQuery{Sort: []repositories.TaskSort{
+			{Field: "customFields.estimate", Desc: true},
+		}}, []string{large.ID, small.ID, none.ID}},
+	} {
+		if got := ids(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: tasks = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	for _, query := range []repositories.TaskQuery{
+		{Conditions: []repositories.TaskCondition{cond("customFields.$where", repositories.OpEq, "1")}},
+		{Conditions: []repositories.TaskCondition{cond("customFields.estimate", repositories.OpEq, true)}},
+	} {
+		_, _, err := repos.Tasks.ListByProject(ctx, project.ID, query, 1, 10)
+		if !errors.Is(err, repositories.ErrInvalidTaskQuery) {
+			t.Errorf("query %+v: err = %v, want ErrInvalidTaskQuery", query, err)
+		}
+	}
+
+	// Values change one key at a time, each recorded on its own
+	unset := repositories.Patch{Unset: []string{"customFields.tier"}, Actor: "user-2"}
+	if err := repos.Tasks.Patch(ctx, small.ID, unset, nil); err != nil {
+		t.Fatalf("unset custom field value: %v", err)
+	}
+	task, err := repos.Tasks.GetByID(ctx, small.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if len(task.CustomFields) != 1 || task.CustomFields["estimate"] != 3.0 {
+		t.Fatalf("custom field values = %v, want the estimate only", task.CustomFields)
+	}
+	activity, _, err := repos.Activity.List(ctx, small.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	if len(activity) != 3 ||
+		activity[0].Field != "customFields.estimate" || activity[0].Old != nil || activity[0].New != 3.0 ||
+		activity[1].Field != "customFields.tier" || activity[1].New != "gold" ||
+		activity[2].Field != "customFields.tier" || activity[2].Old != "gold" || activity[2].New != nil {
+		t.Fatalf("activity = %+v", activity)
+	}
+
+	for _, tt := range []struct {
+		key    string
+		except []string
+		want   int64
+	}{
+		{"tier", nil, 1},
+		{"tier", []string{"silver"}, 0},
+		{"tier", []string{"gold"}, 1},
+		{"estimate", nil, 2},
+		{"customer", nil, 0},
+	} {
+		count, err := repos.Tasks.CountCustomFieldValues(ctx, project.ID, tt.key, tt.except)
+		if err != nil {
+			t.Fatalf("count custom field values: %v", err)
+		}
+		if count != tt.want {
+			t.Errorf("%s values outside %v = %d, want %d", tt.key, tt.except, count, tt.want)
+		}
+	}
+}
+
+func newDependency(org models.Organization, task models.Task, blocker models.Task, createdAt time.Time) models.Dependency {
+	return models.Dependency{
+		OrganizationID:   org.ID,
+		TaskID:           task.ID,
+		ProjectID:        task.ProjectID,
+		BlockerID:        blocker.ID,
+		BlockerProjectID: blocker.ProjectID,
+		CreatedBy:        "user-1",
+		CreatedAt:        createdAt,
+	}
+}
+
+func testDependencies(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	other := newProject(t, repos, org.ID, base)
+	a := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	b := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	c := newTask(t, repos, other.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	d := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+
+	// a blocks b blocks c, and a blocks d
+	for i, dependency := range []models.Dependency{
+		newDependency(org, b, a, base),
+		newDependency(org, c, b, base.Add(time.Minute)),
+		newDependency(org, d, a, base.Add(2*time.Minute)),
+	} {
+		if err := repos.Dependencies.Add(ctx, dependency); err != nil {
+			t.Fatalf("add dependency %d: %v", i, err)
+		}
+	}
+	if err := repos.Dependencies.Add(ctx, newDependency(org, b, a, base)); !errors.Is(err, repositories.ErrDependencyExists) {
+		t.Fatalf("add dependency twice: err = %v, want ErrDependencyExists", err)
+	}
+	for _, pair := range [][2]models.Task{{a, c}, {a, b}, {a, a}} {
+		err := repos.Dependencies.Add(ctx, newDependency(org, pair[0], pair[1], base))
+		if !errors.Is(err, repositories.ErrDependencyCycle) {
+			t.Fatalf("add dependency closing a cycle: err = %v, want ErrDependencyCycle", err)
+		}
+	}
+	// Not a cycle: d and c are both after a, on different branches
+	if err := repos.Dependencies.Add(ctx, newDependency(org, c, d, base.Add(3*time.Minute))); err != nil {
+		t.Fatalf("add dependency: %v", err)
+	}
+
+	edges := func(dependencies []models.Dependency) []string {
+		out := []string{}
+		for _, dependency := range dependencies {
+			out = append(out, dependency.BlockerID+">"+dependency.TaskID)
+		}
+		return out
+	}
+	dependencies, err := repos.Dependencies.ListByTasks(ctx, []string{b.ID})
+	if err != nil {
+		t.Fatalf("list dependencies: %v", err)
+	}
+	if got, want := edges(dependencies), []string{a.ID + ">" + b.ID, b.ID + ">" + c.ID}; !slices.Equal(got, want) {
+		t.Fatalf("dependencies of b = %v, want %v", got, want)
+	}
+	if dependencies[0].CreatedBy != "user-1" || dependencies[0].OrganizationID != org.ID || dependencies[1].BlockerProjectID != project.ID {
+		t.Fatalf("dependency = %+v", dependencies[0])
+	}
+	dependencies, err = repos.Dependencies.ListByProject(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("list dependencies: %v", err)
+	}
+	if got, want := edges(dependencies), []string{a.ID + ">" + b.ID, a.ID + ">" + d.ID}; !slices.Equal(got, want) {
+		t.Fatalf("dependencies within the project = %v, want %v", got, want)
+	}
+
+	if err := repos.Dependencies.Remove(ctx, c.ID, b.ID); err != nil {
+		t.Fatalf("remove dependency: %v", err)
+	}
+	requireNotFound(t, repos.Dependencies.Remove(ctx, c.ID, b.ID))
+	// a still blocks c through d, but c may block b now
+	if err := repos.Dependencies.Add(ctx, newDependency(org, a, c, base)); !errors.Is(err, repositories.ErrDependencyCycle) {
+		t.Fatalf("add dependency closing a cycle: err = %v, want ErrDependencyCycle", err)
+	}
+	if err := repos.Dependencies.Add(ctx, newDependency(org, b, c, base.Add(4*time.Minute))); err != nil {
+		t.Fatalf("add dependency: %v", err)
+	}
+
+	// Dependencies go with their tasks either way
+	if err := repos.Tasks.Delete(ctx, d.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	dependencies, err = repos.Dependencies.ListByTasks(ctx, []string{a.ID, b.ID, c.ID, d.ID})
+	if err != nil {
+		t.Fatalf("list dependencies: %v", err)
+	}
+	if got, want := edges(dependencies), []string{a.ID + ">" + b.ID, c.ID + ">" + b.ID}; !slices.Equal(got, want) {
+		t.Fatalf("dependencies after deleting d = %v, want %v", got, want)
+	}
+	if _, err := repos.Projects.DeleteCascade(ctx, other.ID); err != nil {
+		t.Fatalf("delete project: %v", err)
+	}
+	dependencies, err = repos.Dependencies.ListByTasks(ctx, []string{a.ID, b.ID, c.ID})
+	if err != nil {
+		t.Fatalf("list dependencies: %v", err)
+	}
+	if got, want := edges(dependencies), []string{a.ID + ">" + b.ID}; !slices.Equal(got, want) {
+		t.Fatalf("dependencies after deleting c's project = %v, want %v", got, want)
+	}
+}
+
+func testReminders(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	later := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	sooner := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	future := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	deleted := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	for _, tt := range []struct {
+		id       string
+		notifyAt time.Time
+	}{
+		{later.ID, base.Add(2 * time.Hour)},
+		{sooner.ID, base.Add(time.Hour)},
+		{future.ID, base.Add(48 * time.Hour)},
+		{deleted.ID, base},
+	} {
+		update := bson.M{"dueAt": tt.notifyAt.Add(time.Hour), "notifyAt": tt.notifyAt}
+		if err := repos.Tasks.Update(ctx, tt.id, update); err != nil {
+			t.Fatalf("update task: %v", err)
+		}
+	}
+	if err := repos.Tasks.SoftDelete(ctx, deleted.ID, base); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+
+	notifiable := func(limit int64) []string {
+		t.Helper()
+		tasks, err := repos.Tasks.ListNotifiable(ctx, base.Add(3*time.Hour), limit)
+		if err != nil {
+			t.Fatalf("list notifiable tasks: %v", err)
+		}
+		ids := []string{}
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+	if got := notifiable(10); !slices.Equal(got, []string{sooner.ID, later.ID}) {
+		t.Fatalf("notifiable = %v, want earliest first without future and deleted tasks", got)
+	}
+	if got := notifiable(1); !slices.Equal(got, []string{sooner.ID}) {
+		t.Fatalf("notifiable = %v, want the limit", got)
+	}
+
+	// Only the notifyAt the caller read is replaced
+	next := base.Add(24 * time.Hour)
+	set, err := repos.Tasks.SetNotifyAt(ctx, sooner.ID, base, &next)
+	if err != nil || set {
+		t.Fatalf("set notifyAt from a stale time = %v, %v, want false", set, err)
+	}
+	set, err = repos.Tasks.SetNotifyAt(ctx, sooner.ID, base.Add(time.Hour), &next)
+	if err != nil || !set {
+		t.Fatalf("set notifyAt = %v, %v, want true", set, err)
+	}
+	set, err = repos.Tasks.SetNotifyAt(ctx, later.ID, base.Add(2*time.Hour), nil)
+	if err != nil || !set {
+		t.Fatalf("unset notifyAt = %v, %v, want true", set, err)
+	}
+	if got := notifiable(10); len(got) != 0 {
+		t.Fatalf("notifiable = %v, want none", got)
+	}
+	got, err := repos.Tasks.GetByID(ctx, sooner.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.NotifyAt == nil || !got.NotifyAt.Equal(next) || got.Version != 1 {
+		t.Fatalf("task = %+v, want notifyAt %v and no new version", got, next)
+	}
+	got, err = repos.Tasks.GetByID(ctx, later.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.NotifyAt != nil || got.DueAt == nil {
+		t.Fatalf("task = %+v, want notifyAt unset and dueAt kept", got)
+	}
+
+	tasks, _, err := repos.Tasks.ListByProject(ctx, project.ID, repositories.TaskQuery{
+		Conditions: []repositories.TaskCondition{
+			{Field: "dueAt", Op: repositories.OpLt, Values: []interface{}{base.Add(4 * time.Hour)}},
+		},
+		Sort: []repositories.TaskSort{{Field: "dueAt"}},
+	}, 1, 10)
+	if err != nil {
+		t.Fatalf("list tasks due soon: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != sooner.ID || tasks[1].ID != later.ID {
+		t.Fatalf("tasks due soon = %+v", tasks)
+	}
+}
+
+func testNotifications(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	project := newProject(t, repos, org.ID, base)
+	task := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityMedium, base)
+	dueAt := base.Add(24 * time.Hour)
+
+	overdue := models.Notification{
+		ID:        models.NotificationID(task.ID, models.NotificationOverdue, dueAt, ""),
+		Type:      models.NotificationOverdue,
+		TaskID:    task.ID,
+		ProjectID: project.ID,
+		Title:     task.Title,
+		DueAt:     dueAt,
+		At:        dueAt,
+	}
+	reminder := overdue
+	reminder.ID = models.NotificationID(task.ID, models.NotificationReminder, dueAt, "1h")
+	reminder.Type = models.NotificationReminder
+	reminder.Reminder = "1h"
+	reminder.At = dueAt.Add(-time.Hour)
+	for _, notification := range []models.Notification{overdue, reminder} {
+		if err := repos.Notifications.Record(ctx, notification); err != nil {
+			t.Fatalf("record notification: %v", err)
+		}
+	}
+	if err := repos.Notifications.Record(ctx, overdue); !errors.Is(err, repositories.ErrAlreadySent) {
+		t.Fatalf("record notification twice: err = %v, want ErrAlreadySent", err)
+	}
+
+	// A forgotten notification can be recorded again
+	if err := repos.Notifications.Forget(ctx, overdue.ID); err != nil {
+		t.Fatalf("forget notification: %v", err)
+	}
+	if err := repos.Notifications.Record(ctx, overdue); err != nil {
+		t.Fatalf("record forgotten notification: %v", err)
+	}
+
+	notifications, err := repos.Notifications.ListByTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("list notifications: %v", err)
+	}
+	if len(notifications) != 2 || notifications[0].ID != reminder.ID || notifications[1].ID != overdue.ID {
+		t.Fatalf("notifications = %+v, want oldest first", notifications)
+	}
+	if notifications[0].Reminder != "1h" || !notifications[0].DueAt.Equal(dueAt) {
+		t.Fatalf("notification = %+v", notifications[0])
+	}
+
+	// Notifications go with the task
+	if err := repos.Tasks.Delete(ctx, task.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	notifications, err = repos.Notifications.ListByTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("list notifications: %v", err)
+	}
+	if len(notifications) != 0 {
+		t.Fatalf("%d notifications left after deleting the task", len(notifications))
+	}
+}
+
+func testLeases(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+
+	acquire := func(holder string, ttl time.Duration, want bool) {
+		t.Helper()
+		got, err := repos.Leases.Acquire(ctx, "test", holder, ttl)
+		if err != nil {
+			t.Fatalf("acquire lease: %v", err)
+		}
+		if got != want {
+			t.Fatalf("%s acquired the lease = %v, want %v", holder, got, want)
+		}
+	}
+
+	acquire("a", time.Minute, true)
+	acquire("b", time.Minute, false)
+	// Renewing
+	acquire("a", time.Millisecond, true)
+	time.Sleep(20 * time.Millisecond)
+	// Taking over an expired lease
+	acquire("b", time.Minute, true)
+	acquire("a", time.Minute, false)
+
+	// Only the holder releases
+	if err := repos.Leases.Release(ctx, "test", "a"); err != nil {
+		t.Fatalf("release lease: %v", err)
+	}
+	acquire("a", time.Minute, false)
+	if err := repos.Leases.Release(ctx, "test", "b"); err != nil {
+		t.Fatalf("release lease: %v", err)
+	}
+	acquire("a", time.Minute, true)
+}
+
+func testJobs(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+
+	running := models.Job{
+		ID:        newID(),
+		Type:      models.JobTypeOrganizationDelete,
+		TargetID:  newID(),
+		Status:    models.JobStatusPending,
+		CreatedAt: base,
+	}
+	if err := repos.Jobs.Create(ctx, running); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	done := running
+	done.ID = newID()
+	if err := repos.Jobs.Create(ctx, done); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	progress := models.JobResult{Projects: 2, Tasks: 5}
+	if err := repos.Jobs.Update(ctx, running.ID, bson.M{"status": models.JobStatusRunning, "progress": progress}); err != nil {
+		t.Fatalf("update job: %v", err)
+	}
+	if err := repos.Jobs.Update(ctx, done.ID, bson.M{"status": models.JobStatusCompleted}); err != nil {
+		t.Fatalf("update job: %v", err)
+	}
+	requireNotFound(t, repos.Jobs.Update(ctx, newID(), bson.M{"status": models.JobStatusRunning}))
+
+	if err := repos.Jobs.FailInterrupted(ctx); err != nil {
+		t.Fatalf("fail interrupted jobs: %v", err)
+	}
+
+	got, err := repos.Jobs.GetByID(ctx, running.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if got.Status != models.JobStatusFailed || got.Error == nil || got.FinishedAt == nil || got.Progress != progress {
+		t.Fatalf("interrupted job = %+v", got)
+	}
+
+	got, err = repos.Jobs.GetByID(ctx, done.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if got.Status != models.JobStatusCompleted {
+		t.Fatalf("completed job became %q", got.Status)
+	}
+}
+
+// testSearch sticks to exact words, which both MongoDB and the in-memory
+// search match the same way.
+func testSearch(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	other := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	description := "where every launch starts"
+	if err := repos.Organizations.Update(ctx, org.ID, bson.M{"name": "Launch Company"}); err != nil {
+		t.Fatalf("rename organization: %v", err)
+	}
+	project := newProject(t, repos, org.ID, base)
+	if err := repos.Projects.Update(ctx, project.ID, bson.M{"name": "Pad", "description": description}); err != nil {
+		t.Fatalf("update project: %v", err)
+	}
+	task := newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityHigh, base)
+	if err := repos.Tasks.Update(ctx, task.ID, bson.M{"title": "Plan the launch"}); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+	newTask(t, repos, project.ID, models.TaskStatusPending, models.TaskPriorityHigh, base)
+	foreign := newTask(t, repos, newProject(t, repos, other.ID, base).ID, models.TaskStatusPending, models.TaskPriorityHigh, base)
+	if err := repos.Tasks.Update(ctx, foreign.ID, bson.M{"title": "launch"}); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+
+	search := func(query repositories.SearchQuery) []string {
+		t.Helper()
+		query.OrganizationID = org.ID
+		if query.Limit == 0 {
+			query.Limit = 10
+		}
+		hits, err := repos.Search.Search(ctx, query)
+		if err != nil {
+			t.Fatalf("search %+v: %v", query, err)
+		}
+		found := []string{}
+		for i, hit := range hits {
+			if i > 0 && hit.Score > hits[i-1].Score {
+				t.Fatalf("hits not ordered by score: %+v", hits)
+			}
+			if hit.Type == models.SearchHitTask && (hit.ProjectID == nil || *hit.ProjectID != project.ID) {
+				t.Fatalf("task hit without its project: %+v", hit)
+			}
+			found = append(found, hit.Type+":"+hit.ID)
+		}
+		slices.Sort(found)
+		return found
+	}
+
+	orgHit, projectHit, taskHit := "organization:"+org.ID, "project:"+project.ID, "task:"+task.ID
+	for _, tt := range []struct {
+		name  string
+		query repositories.SearchQuery
+		want  []string
+	}{
+		{"all types", repositories.SearchQuery{Text: "launch"}, []string{orgHit, projectHit, taskHit}},
+		{"tasks only", repositories.SearchQuery{Text: "launch", Types: []string{models.SearchHitTask}}, []string{taskHit}},
+		{"excluded term", repositories.SearchQuery{Text: "launch -pad"}, []string{orgHit, taskHit}},
+		{"no match", repositories.SearchQuery{Text: "nothing"}, []string{}},
+	} {
+		want := slices.Clone(tt.want)
+		slices.Sort(want)
+		if got := search(tt.query); !slices.Equal(got, want) {
+			t.Errorf("%s: hits = %v, want %v", tt.name, got, want)
+		}
+	}
+
+	if got := search(repositories.SearchQuery{Text: "launch", Limit: 2}); len(got) != 2 {
+		t.Errorf("limited hits = %v, want 2", got)
+	}
+}
+
+func testStats(t *testing.T, repos repositories.Repositories) {
+	ctx := context.Background()
+	org := newOrganization(t, repos, models.OrganizationStatusActive, base)
+	first := newProject(t, repos, org.ID, base)
+	second := newProject(t, repos, org.ID, base)
+	if err := repos.Projects.Update(ctx, second.ID, bson.M{"status": models.ProjectStatusCompleted}); err != nil {
+		t.Fatalf("update project: %v", err)
+	}
+	empty := newProject(t, repos, newOrganization(t, repos, models.OrganizationStatusActive, base).ID, base)
+
+	assign := func(task models.Task, userID string) {
+		if _, err := repos.Tasks.Assign(ctx, task.ID, userID, "user-0"); err != nil {
+			t.Fatalf("assign task: %v", err)
+		}
+	}
+	due := func(task models.Task, at time.Time) models.Task {
+		if err := repos.Tasks.Update(ctx, task.ID, bson.M{"dueAt": at}); err != nil {
+			t.Fatalf("set due date: %v", err)
+		}
+		return task
+	}
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	// Only the pending task past its due date is overdue, the done one is
+	// finished and the other is not due yet
+	assign(due(newTask(t, repos, first.ID, models.TaskStatusPending, models.TaskPriorityHigh, base), future), "user-1")
+	assign(newTask(t, repos, first.ID, models.TaskStatusPending, models.TaskPriorityHigh, base), "user-1")
+	assign(due(newTask(t, repos, first.ID, models.TaskStatusDone, models.TaskPriorityLow, base), past), "user-2")
+	due(newTask(t, repos, first.ID, models.TaskStatusPending, models.TaskPriorityLow, base), past)
+	assign(newTask(t, repos, second.ID, models.TaskStatusDone, models.TaskPriorityHigh, base), "user-2")
+	assign(newTask(t, repos, second.ID, models.TaskStatusDone, models.TaskPriorityHigh, base), "user-2")
+
+	stats, err := repos.Stats.Project(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("project stats: %v", err)
+	}
+	if stats.Tasks.Total != 4 || stats.Tasks.Unassigned != 1 || stats.Tasks.Overdue != 1 ||
+		stats.Tasks.ByStatus[models.TaskStatusPending] != 3 || stats.Tasks.ByPriority[models.TaskPriorityLow] != 2 {
+		t.Fatalf("project task counts = %+v", stats.Tasks)
+	}
+	if len(stats.Workload) != 2 || stats.Workload[0].UserID != "user-1" || stats.Workload[0].Total != 2 {
+		t.Fatalf("project workload = %+v", stats.Workload)
+	}
+
+	stats, err = repos.Stats.Project(ctx, empty.ID)
+	if err != nil {
+		t.Fatalf("empty project stats: %v", err)
+	}
+	if stats.Tasks.Total != 0 || len(stats.Workload) != 0 {
+		t.Fatalf("empty project stats = %+v", stats)
+	}
+
+	// Done is only final where the workflow ends with it
+	custom := newProject(t, repos, newOrganization(t, repos, models.OrganizationStatusActive, base).ID, base)
+	workflow := models.Workflow{
+		Initial:     models.TaskStatusPending,
+		States:      []string{models.TaskStatusPending, models.TaskStatusDone, "released"},
+		Transitions: map[string][]string{models.TaskStatusPending: {models.TaskStatusDone}, models.TaskStatusDone: {"released"}},
+	}
+	if err := repos.Projects.SetWorkflow(ctx, custom.ID, workflow); err != nil {
+		t.Fatalf("set workflow: %v", err)
+	}
+	due(newTask(t, repos, custom.ID, models.TaskStatusDone, models.TaskPriorityLow, base), past)
+	due(newTask(t, repos, custom.ID, "released", models.TaskPriorityLow, base), past)
+	stats, err = repos.Stats.Project(ctx, custom.ID)
+	if err != nil {
+		t.Fatalf("custom workflow stats: %v", err)
+	}
+	if stats.Tasks.Overdue != 1 {
+		t.Fatalf("overdue tasks with a custom workflow = %d, want 1", stats.Tasks.Overdue)
+	}
+
+	_, err = repos.Stats.Project(ctx, newID())
+	requireNotFound(t, err)
+
+	orgStats, err := repos.Stats.Organization(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("organization stats: %v", err)
+	}
+	if len(orgStats.Projects) != 2 || orgStats.ProjectsByStatus[models.ProjectStatusCompleted] != 1 {
+		t.Fatalf("organization projects = %+v", orgStats)
+	}
+	if orgStats.Tasks.Total != 6 || orgStats.Tasks.Overdue != 1 ||
+		orgStats.Tasks.ByStatus[models.TaskStatusDone] != 3 || orgStats.Tasks.ByPriority[models.TaskPriorityHigh] != 4 {
+		t.Fatalf("organization task counts = %+v", orgStats.Tasks)
+	}
+	if len(orgStats.Workload) != 2 || orgStats.Workload[0].UserID != "user-2" || orgStats.Workload[0].Total != 3 ||
+		orgStats.Workload[0].ByStatus[models.TaskStatusDone] != 3 {
+		t.Fatalf("organization workload = %+v", orgStats.Workload)
+	}
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoSearchRepo struct {
+	db *mongo.Database
+}
+
+// searchDocument decodes the fields of any searchable collection along with
+// the text score.
+type searchDocument struct {
+	ID          string  `bson:"_id"`
+	Name        string  `bson:"name"`
+	Title       string  `bson:"title"`
+	Description *string `bson:"description"`
+	ProjectID   *string `bson:"projectId"`
+	Score       float64 `bson:"score"`
+}
+
+// Search runs the text query against each collection's text index. The
+// scores come from MongoDB, so hits of different collections compare. What
+// is in the trash is not found.
+func (r *mongoSearchRepo) Search(ctx context.Context, query SearchQuery) ([]models.SearchHit, error) {
+	hits := []models.SearchHit{}
+
+	search := func(hitType string, collection string, filter bson.M) error {
+		if len(query.Types) > 0 && !slices.Contains(query.Types, hitType) {
+			return nil
+		}
+
+		filter["$text"] = bson.M{"$search": query.Text}
+		score := bson.M{"$meta": "textScore"}
+		opts := options.Find().
+			SetProjection(bson.M{"name": 1, "title": 1, "description": 1, "projectId": 1, "score": score}).
+			SetSort(bson.D{{Key: "score", Value: score}}).
+			SetLimit(query.Limit)
+
+		cursor, err := r.db.
+			Collection(collection).
+			Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		var docs []searchDocument
+		if err := cursor.All(ctx, &docs); err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			title := doc.Name
+			if hitType == models.SearchHitTask {
+				title = doc.Title
+			}
+			hits = append(hits, models.SearchHit{
+				Type:        hitType,
+				ID:          doc.ID,
+				Title:       title,
+				Description: doc.Description,
+				ProjectID:   doc.ProjectID,
+				Score:       doc.Score,
+			})
+		}
+		return nil
+	}
+
+	if err := search(models.SearchHitOrganization, "organizations", bson.M{"_id": query.OrganizationID, "deletedAt": nil}); err != nil {
+		return nil, err
+	}
+	if err := search(models.SearchHitProject, "projects", bson.M{"organizationId": query.OrganizationID, "deletedAt": nil}); err != nil {
+		return nil, err
+	}
+
+	projects := &mongoProjectRepo{db: r.db}
+	projectIDs, err := projects.ListIDs(ctx, query.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(projectIDs) > 0 {
+		if err := search(models.SearchHitTask, "tasks", bson.M{"projectId": bson.M{"$in": projectIDs}, "deletedAt": nil}); err != nil {
+			return nil, err
+		}
+	}
+
+	return RankSearchHits(hits, query.Limit), nil
+}
+
+// RankSearchHits orders hits best first, ties broken by type and ID, and
+// keeps at most limit of them.
+func RankSearchHits(hits []models.SearchHit, limit int64) []models.SearchHit {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Type != hits[j].Type {
+			return hits[i].Type < hits[j].Type
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	if int64(len(hits)) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
@@ -0,0 +1,508 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoTaskRepo struct {
+	db *mongo.Database
+}
+
+func (r *mongoTaskRepo) GetByID(ctx context.Context, id string) (*models.Task, error) {
+	var task models.Task
+
+	err := r.db.
+		Collection("tasks").
+		FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}).
+		Decode(&task)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+func (r *mongoTaskRepo) ListByProject(
+	ctx context.Context,
+	projectID string,
+	query TaskQuery,
+	page int64,
+	limit int64,
+) ([]models.Task, int64, error) {
+
+	filter, err := taskQueryFilter(bson.M{"projectId": projectID, "deletedAt": nil}, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return r.list(ctx, filter, taskQuerySort(query), page, limit)
+}
+
+func (r *mongoTaskRepo) ListByAssignee(
+	ctx context.Context,
+	userID string,
+	page int64,
+	limit int64,
+	status *string,
+) ([]models.Task, int64, error) {
+
+	filter := bson.M{"assignedTo": userID, "deletedAt": nil}
+	if status != nil {
+		filter["status"] = *status
+	}
+
+	return r.list(ctx, filter, taskQuerySort(TaskQuery{}), page, limit)
+}
+
+func (r *mongoTaskRepo) list(ctx context.Context, filter bson.M, sort bson.D, page int64, limit int64) ([]models.Task, int64, error) {
+	var cursor *mongo.Cursor
+	var err error
+	if sortsByPriority(sort) {
+		cursor, err = r.db.
+			Collection("tasks").
+			Aggregate(ctx, mongo.Pipeline{
+				{{Key: "$match", Value: filter}},
+				{{Key: "$addFields", Value: bson.M{priorityRankField: priorityRank()}}},
+				{{Key: "$sort", Value: sort}},
+				{{Key: "$skip", Value: (page - 1) * limit}},
+				{{Key: "$limit", Value: limit}},
+				{{Key: "$project", Value: bson.M{priorityRankField: 0}}},
+			})
+	} else {
+		opts := options.Find().
+			SetSkip((page - 1) * limit).
+			SetLimit(limit).
+			SetSort(sort)
+
+		cursor, err = r.db.
+			Collection("tasks").
+			Find(ctx, filter, opts)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.db.
+		Collection("tasks").
+		CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return tasks, total, nil
+}
+
+// sortsByPriority tells whether sort needs priorityRankField.
+func sortsByPriority(sort bson.D) bool {
+	for _, e := range sort {
+		if e.Key == priorityRankField {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *mongoTaskRepo) Create(ctx context.Context, task models.Task) error {
+	_, err := r.db.
+		Collection("tasks").
+		InsertOne(ctx, task)
+	return err
+}
+
+func (r *mongoTaskRepo) Update(
+	ctx context.Context,
+	id string,
+	update bson.M,
+) error {
+	return r.Patch(ctx, id, Patch{Set: update}, nil)
+}
+
+// Assign sets assignedTo and assignedAt in a single update so readers never
+// see one without the other.
+func (r *mongoTaskRepo) Assign(ctx context.Context, id string, userID string, actor string) (*models.Task, error) {
+	now := time.Now()
+	patch := Patch{
+		Set: bson.M{
+			"assignedTo": userID,
+			"assignedAt": now,
+			"updatedAt":  now,
+		},
+		Actor: actor,
+	}
+
+	if err := r.Patch(ctx, id, patch, nil); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *mongoTaskRepo) Unassign(ctx context.Context, id string, actor string) (*models.Task, error) {
+	patch := Patch{
+		Set:   bson.M{"updatedAt": time.Now()},
+		Unset: []string{"assignedTo", "assignedAt"},
+		Actor: actor,
+	}
+
+	if err := r.Patch(ctx, id, patch, nil); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Delete removes the task together with its history in one transaction.
+func (r *mongoTaskRepo) Delete(ctx context.Context, id string) error {
+	_, err := inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		res, err := r.db.
+			Collection("tasks").
+			DeleteOne(sc, bson.M{"_id": id})
+		if err != nil {
+			return nil, err
+		}
+		if res.DeletedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		return &CascadeResult{}, deleteTaskHistory(sc, r.db, bson.M{"taskId": id})
+	})
+	return err
+}
+
+func (r *mongoTaskRepo) Purge(ctx context.Context, id string, deletedAt time.Time) error {
+	_, err := inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		res, err := r.db.
+			Collection("tasks").
+			DeleteOne(sc, bson.M{"_id": id, "deletedAt": deletedAt})
+		if err != nil {
+			return nil, err
+		}
+		if res.DeletedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		return &CascadeResult{}, deleteTaskHistory(sc, r.db, bson.M{"taskId": id})
+	})
+	return err
+}
+
+func (r *mongoTaskRepo) SoftDelete(ctx context.Context, id string, at time.Time) error {
+	res, err := r.db.
+		Collection("tasks").
+		UpdateOne(ctx, bson.M{"_id": id, "deletedAt": nil}, deletion.set(at, ""))
+
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Restore brings the task back unless its project is in the trash, which
+// includes the case of the task having been deleted along with it.
+func (r *mongoTaskRepo) Restore(ctx context.Context, id string) error {
+	task, err := readLifecycle(ctx, r.db.Collection("tasks"), id)
+	if err != nil {
+		return err
+	}
+	if task.DeletedAt == nil {
+		return mongo.ErrNoDocuments
+	}
+
+	project, err := readLifecycle(ctx, r.db.Collection("projects"), task.ProjectID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	if err == nil && project.DeletedAt != nil {
+		return ErrParentDeleted
+	}
+
+	res, err := r.db.
+		Collection("tasks").
+		UpdateOne(ctx, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}}, deletion.unset())
+
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoTaskRepo) Transition(
+	ctx context.Context,
+	id string,
+	from string,
+	update bson.M,
+	transition models.StatusTransition,
+) error {
+	transition.From = from
+	return r.Patch(ctx, id, Patch{Set: update, Actor: transition.Actor}, &transition)
+}
+
+// Patch writes the task, its activity and the status transition, if any,
+// in one transaction, so none of them is recorded without the others.
+func (r *mongoTaskRepo) Patch(
+	ctx context.Context,
+	id string,
+	patch Patch,
+	transition *models.StatusTransition,
+) error {
+	filter := patch.filter(id)
+	if transition != nil {
+		filter["status"] = transition.From
+	}
+
+	_, err := inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		// The task as it was before tells the activity the old values
+		var before bson.M
+		collection := r.db.Collection("tasks")
+		err := collection.
+			FindOneAndUpdate(sc, filter, patch.update()).
+			Decode(&before)
+
+		if err == mongo.ErrNoDocuments {
+			if err := patchMiss(sc, collection, id, patch); err != nil {
+				return nil, err
+			}
+			if transition == nil {
+				return nil, ErrVersionConflict
+			}
+			return nil, ErrStatusConflict
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if activity := TaskActivity(before, patch, time.Now()); len(activity) > 0 {
+			docs := make([]interface{}, len(activity))
+			for i := range activity {
+				docs[i] = activity[i]
+			}
+			if _, err := r.db.Collection("task_activity").InsertMany(sc, docs); err != nil {
+				return nil, err
+			}
+		}
+		if transition == nil {
+			return &CascadeResult{}, nil
+		}
+
+		recorded := *transition
+		recorded.ID = primitive.NewObjectID().Hex()
+		_, err = r.db.
+			Collection("task_transitions").
+			InsertOne(sc, recorded)
+		return &CascadeResult{}, err
+	})
+	return err
+}
+
+// BulkPatch checks every patch against the tasks as a transaction reads
+// them and writes the ones that apply with a single BulkWrite, so a task
+// that changes meanwhile aborts and retries the transaction rather than
+// being written over.
+func (r *mongoTaskRepo) BulkPatch(ctx context.Context, patches []TaskPatch) ([]error, error) {
+	var errs []error
+	_, err := inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		ids := make([]string, len(patches))
+		for i, item := range patches {
+			ids[i] = item.ID
+		}
+
+		collection := r.db.Collection("tasks")
+		cursor, err := collection.Find(sc, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return nil, err
+		}
+		defer cursor.Close(sc)
+
+		tasks := map[string]models.Task{}
+		docs := map[string]bson.M{}
+		for cursor.Next(sc) {
+			var task models.Task
+			var doc bson.M
+			if err := cursor.Decode(&task); err != nil {
+				return nil, err
+			}
+			if err := cursor.Decode(&doc); err != nil {
+				return nil, err
+			}
+			tasks[task.ID] = task
+			docs[task.ID] = doc
+		}
+		if err := cursor.Err(); err != nil {
+			return nil, err
+		}
+
+		// A retried transaction starts over from what it reads again
+		errs = make([]error, len(patches))
+		writes := []mongo.WriteModel{}
+		activity := []interface{}{}
+		transitions := []interface{}{}
+		now := time.Now()
+		for i, item := range patches {
+			task, ok := tasks[item.ID]
+			switch {
+			case !ok || task.DeletedAt != nil:
+				errs[i] = mongo.ErrNoDocuments
+			case task.ArchivedAt != nil:
+				errs[i] = ErrArchived
+			case item.Patch.IfVersion != nil && task.Version != *item.Patch.IfVersion:
+				errs[i] = ErrVersionConflict
+			case item.Transition != nil && task.Status != item.Transition.From:
+				errs[i] = ErrStatusConflict
+			}
+			if errs[i] != nil {
+				continue
+			}
+
+			filter := item.Patch.filter(item.ID)
+			if item.Transition != nil {
+				filter["status"] = item.Transition.From
+			}
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(filter).
+				SetUpdate(item.Patch.update()))
+
+			for _, entry := range TaskActivity(docs[item.ID], item.Patch, now) {
+				activity = append(activity, entry)
+			}
+			if item.Transition != nil {
+				recorded := *item.Transition
+				recorded.ID = primitive.NewObjectID().Hex()
+				transitions = append(transitions, recorded)
+			}
+		}
+		if len(writes) == 0 {
+			return nil, nil
+		}
+
+		res, err := collection.BulkWrite(sc, writes, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount != int64(len(writes)) {
+			return nil, errBulkPatchMiss
+		}
+
+		if len(activity) > 0 {
+			if _, err := r.db.Collection("task_activity").InsertMany(sc, activity); err != nil {
+				return nil, err
+			}
+		}
+		if len(transitions) > 0 {
+			if _, err := r.db.Collection("task_transitions").InsertMany(sc, transitions); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// errBulkPatchMiss means a task checked within a BulkPatch's transaction
+// no longer matched its update, which the transaction should rule out.
+var errBulkPatchMiss = errors.New("bulk patch missed a task it checked")
+
+func (r *mongoTaskRepo) ListTransitions(ctx context.Context, taskID string) ([]models.StatusTransition, error) {
+	cursor, err := r.db.
+		Collection("task_transitions").
+		Find(ctx, bson.M{"taskId": taskID}, options.Find().SetSort(bson.M{"at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	transitions := []models.StatusTransition{}
+	if err := cursor.All(ctx, &transitions); err != nil {
+		return nil, err
+	}
+
+	return transitions, nil
+}
+
+func (r *mongoTaskRepo) CountOutsideStates(ctx context.Context, projectID string, states []string) (int64, error) {
+	return r.db.
+		Collection("tasks").
+		CountDocuments(ctx, bson.M{"projectId": projectID, "status": bson.M{"$nin": states}})
+}
+
+func (r *mongoTaskRepo) ListNotifiable(ctx context.Context, at time.Time, limit int64) ([]models.Task, error) {
+	opts := options.Find().
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "notifyAt", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.db.
+		Collection("tasks").
+		Find(ctx, bson.M{"notifyAt": bson.M{"$lte": at}, "archivedAt": nil, "deletedAt": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (r *mongoTaskRepo) SetNotifyAt(ctx context.Context, id string, from time.Time, next *time.Time) (bool, error) {
+	update := bson.M{"$unset": bson.M{"notifyAt": ""}}
+	if next != nil {
+		update = bson.M{"$set": bson.M{"notifyAt": *next}}
+	}
+
+	res, err := r.db.
+		Collection("tasks").
+		UpdateOne(ctx, bson.M{"_id": id, "notifyAt": from}, update)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func (r *mongoTaskRepo) CountCustomFieldValues(ctx context.Context, projectID string, key string, except []string) (int64, error) {
+	value := bson.M{"$exists": true}
+	if len(except) > 0 {
+		value["$nin"] = except
+	}
+
+	return r.db.
+		Collection("tasks").
+		CountDocuments(ctx, bson.M{"projectId": projectID, "customFields." + key: value})
+}
+
+// taskHistory holds what is recorded about tasks, by taskId and projectId,
+// and goes along with them. So do their dependencies.
+var taskHistory = []string{"task_transitions", "task_activity", "task_comments", "task_notifications"}
+
+func deleteTaskHistory(ctx context.Context, database *mongo.Database, filter bson.M) error {
+	for _, collection := range taskHistory {
+		if _, err := database.Collection(collection).DeleteMany(ctx, filter); err != nil {
+			return err
+		}
+	}
+	return deleteDependencies(ctx, database, filter)
+}
@@ -3,13 +3,11 @@ package cache
 import (
 	"context"
 	"encoding/json"
-	"time"
 
 	models "task-manager/collections"
-)
 
-// Cache TTL (5 minutes)
-const organizationTTL = 5 * time.Minute
+	"github.com/redis/go-redis/v9"
+)
 
 // GetOrganization retrieves organization from Redis
 func GetOrganization(ctx context.Context, id string) (*models.Organization, error) {
@@ -21,6 +19,9 @@ func GetOrganization(ctx context.Context, id string) (*models.Organization, erro
 	if err != nil {
 		return nil, err
 	}
+	if val == tombstone {
+		return nil, redis.Nil
+	}
 
 	// Convert JSON back to struct
 	var org models.Organization
@@ -31,23 +32,25 @@ func GetOrganization(ctx context.Context, id string) (*models.Organization, erro
 	return &org, nil
 }
 
-// SetOrganization stores organization in Redis
+// SetOrganization stores organization in Redis unless a newer version is
+// cached already
 func SetOrganization(ctx context.Context, org models.Organization) error {
 	// Generate cache key
 	key := "organization:" + org.ID
 
-	// Convert struct to JSON
-	data, err := json.Marshal(org)
-	if err != nil {
-		return err
-	}
-
 	// Store in Redis with TTL
-	return Client.Set(ctx, key, data, organizationTTL).Err()
+	return setVersioned(ctx, key, org, org.Version, organizationTTL)
 }
 
 // DeleteOrganization removes organization from cache
 func DeleteOrganization(ctx context.Context, id string) error {
 	key := "organization:" + id
 	return Client.Del(ctx, key).Err()
+}
+
+// TombstoneOrganization marks the organization deleted, so a read that got
+// it before the delete cannot cache it again
+func TombstoneOrganization(ctx context.Context, id string) error {
+	key := "organization:" + id
+	return setTombstone(ctx, key, organizationTTL)
 }
\ No newline at end of file
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	models "task-manager/collections"
+)
+
+// GetProject retrieves project from Redis
+func GetProject(ctx context.Context, id string) (*models.Project, error) {
+	key := "project:" + id
+
+	val, err := Client.Get(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var project models.Project
+	if err := json.Unmarshal([]byte(val), &project); err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// SetProject stores project in Redis unless a newer version is cached already
+func SetProject(ctx context.Context, project models.Project) error {
+	key := "project:" + project.ID
+
+	return setVersioned(ctx, key, project, project.Version, projectTTL)
+}
+
+// DeleteProject removes project from cache
+func DeleteProject(ctx context.Context, id string) error {
+	key := "project:" + id
+	return Client.Del(ctx, key).Err()
+}
+
+// DeleteProjects removes several projects from cache in one round trip
+func DeleteProjects(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = "project:" + id
+	}
+	return Client.Del(ctx, keys...).Err()
+}
@@ -2,25 +2,56 @@ package cache
 
 import (
 	"context"
+	"crypto/tls"
+	"net"
 	"time"
 
+	"task-manager/config"
+
 	"github.com/redis/go-redis/v9"
 )
 
 // Global Redis client
 var Client *redis.Client
 
+// Cache TTLs, set from the config by Connect
+var (
+	organizationTTL time.Duration
+	projectTTL      time.Duration
+	taskTTL         time.Duration
+	statsTTL        time.Duration
+)
+
 // Connect initializes the Redis connection
-func Connect() error {
+func Connect(cfg config.Redis, ttl config.Cache) error {
+	opts := &redis.Options{
+		Addr:        cfg.Addr,
+		Password:    cfg.Password,
+		DB:          cfg.DB,
+		DialTimeout: cfg.DialTimeout,
+	}
+	if cfg.TLS {
+		host, _, err := net.SplitHostPort(cfg.Addr)
+		if err != nil {
+			return err
+		}
+		opts.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: host,
+		}
+	}
+
 	// Create a new Redis client
-	Client = redis.NewClient(&redis.Options{
-		Addr: "localhost:6379", // Redis server address
-		DB:   0,                // Default Redis DB
-	})
+	Client = redis.NewClient(opts)
+
+	organizationTTL = ttl.OrganizationTTL
+	projectTTL = ttl.ProjectTTL
+	taskTTL = ttl.TaskTTL
+	statsTTL = ttl.StatsTTL
 
 	// Ping Redis to verify connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DialTimeout)
 	defer cancel()
 
 	return Client.Ping(ctx).Err()
-}
\ No newline at end of file
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	models "task-manager/collections"
+)
+
+// GetOrganizationStats retrieves organization stats from Redis
+func GetOrganizationStats(ctx context.Context, id string) (*models.OrganizationStats, error) {
+	val, err := Client.Get(ctx, "stats:organization:"+id).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var stats models.OrganizationStats
+	if err := json.Unmarshal([]byte(val), &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// SetOrganizationStats stores organization stats in Redis
+func SetOrganizationStats(ctx context.Context, stats models.OrganizationStats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+
+	return Client.Set(ctx, "stats:organization:"+stats.OrganizationID, data, statsTTL).Err()
+}
+
+// GetProjectStats retrieves project stats from Redis
+func GetProjectStats(ctx context.Context, id string) (*models.ProjectStats, error) {
+	val, err := Client.Get(ctx, "stats:project:"+id).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var stats models.ProjectStats
+	if err := json.Unmarshal([]byte(val), &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// SetProjectStats stores project stats in Redis
+func SetProjectStats(ctx context.Context, stats models.ProjectStats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+
+	return Client.Set(ctx, "stats:project:"+stats.ProjectID, data, statsTTL).Err()
+}
+
+// DeleteStats removes the stats of an organization and of projects in one
+// round trip. An empty orgID leaves organization stats alone.
+func DeleteStats(ctx context.Context, orgID string, projectIDs ...string) error {
+	keys := make([]string, 0, len(projectIDs)+1)
+	if orgID != "" {
+		keys = append(keys, "stats:organization:"+orgID)
+	}
+	for _, id := range projectIDs {
+		keys = append(keys, "stats:project:"+id)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return Client.Del(ctx, keys...).Err()
+}
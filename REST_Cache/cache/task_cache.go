@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	models "task-manager/collections"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GetTask retrieves task from Redis
+func GetTask(ctx context.Context, id string) (*models.Task, error) {
+	key := "task:" + id
+
+	val, err := Client.Get(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if val == tombstone {
+		return nil, redis.Nil
+	}
+
+	var task models.Task
+	if err := json.Unmarshal([]byte(val), &task); err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// SetTask stores task in Redis unless a newer version is cached already
+func SetTask(ctx context.Context, task models.Task) error {
+	key := "task:" + task.ID
+
+	return setVersioned(ctx, key, task, task.Version, taskTTL)
+}
+
+// DeleteTask removes task from cache
+func DeleteTask(ctx context.Context, id string) error {
+	key := "task:" + id
+	return Client.Del(ctx, key).Err()
+}
+
+// TombstoneTask marks the task deleted, so a read that got it before the
+// delete cannot cache it again
+func TombstoneTask(ctx context.Context, id string) error {
+	key := "task:" + id
+	return setTombstone(ctx, key, taskTTL)
+}
+
+// DeleteTasks removes several tasks from cache in one round trip
+func DeleteTasks(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = "task:" + id
+	}
+	return Client.Del(ctx, keys...).Err()
+}
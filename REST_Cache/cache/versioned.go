@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// setIfNotOlder stores a JSON document unless the cached copy has a higher
+// version. Copies without a version, cached before there were versions,
+// are replaced.
+var setIfNotOlder = redis.NewScript(`
+local cached = redis.call("GET", KEYS[1])
+if cached then
+	local ok, doc = pcall(cjson.decode, cached)
+	if ok and type(doc) == "table" and type(doc.version) == "number" and doc.version > tonumber(ARGV[2]) then
+		return 0
+	end
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+return 1
+`)
+
+// setVersioned caches a document with a version field, so that a write that
+// read the document before a newer one was cached cannot replace it.
+func setVersioned(ctx context.Context, key string, doc any, version int64, ttl time.Duration) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return setIfNotOlder.Run(ctx, Client, []string{key}, data, version, ttl.Milliseconds()).Err()
+}
+
+// tombstone stands in for a deleted document. Its version is above any
+// document's, so setIfNotOlder never replaces it with a copy read before the
+// delete; only a plain delete, as on restore, clears it.
+var tombstone = fmt.Sprintf(`{"version":%d,"deleted":true}`, int64(math.MaxInt64))
+
+// setTombstone marks the document at key deleted for ttl.
+func setTombstone(ctx context.Context, key string, ttl time.Duration) error {
+	return Client.Set(ctx, key, tombstone, ttl).Err()
+}
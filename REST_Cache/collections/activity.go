@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Activity records one field change of a task: who changed it and what it
+// held before and after. Old is null for a field that was not set, New for
+// one that was removed.
+type Activity struct {
+	ID        string      `bson:"_id,omitempty" json:"id"`
+	TaskID    string      `bson:"taskId" json:"taskId"`
+	ProjectID string      `bson:"projectId" json:"projectId"`
+	Actor     string      `bson:"actor" json:"actor"`
+	Field     string      `bson:"field" json:"field"`
+	Old       interface{} `bson:"old" json:"old"`
+	New       interface{} `bson:"new" json:"new"`
+	At        time.Time   `bson:"at" json:"at"`
+}
+
+// TimelineItem is an activity or a comment of a task, whichever Type says.
+// At is when the change was made or the comment written.
+type TimelineItem struct {
+	Type     string    `bson:"type" json:"type"`
+	At       time.Time `bson:"at" json:"at"`
+	Activity *Activity `bson:"activity,omitempty" json:"activity,omitempty"`
+	Comment  *Comment  `bson:"comment,omitempty" json:"comment,omitempty"`
+}
+
+const (
+	TimelineActivity = "activity"
+	TimelineComment  = "comment"
+)
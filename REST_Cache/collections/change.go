@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Change is one write to a task or project as delivered to subscribers.
+// Task or Project holds the document after the change, or as it was before
+// a delete.
+type Change struct {
+	// Token resumes a subscription right after this change.
+	Token     string    `json:"token"`
+	Type      string    `json:"type"`
+	Operation string    `json:"operation"`
+	ID        string    `json:"id"`
+	Task      *Task     `json:"task,omitempty"`
+	Project   *Project  `json:"project,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+const (
+	ChangeTypeTask    = "task"
+	ChangeTypeProject = "project"
+)
+
+const (
+	ChangeInsert  = "insert"
+	ChangeUpdate  = "update"
+	ChangeReplace = "replace"
+	ChangeDelete  = "delete"
+)
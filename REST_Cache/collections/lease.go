@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Lease gives one holder, such as one replica of the task manager, the
+// right to do a piece of work until ExpiresAt unless it renews it.
+type Lease struct {
+	Name      string    `bson:"_id" json:"name"`
+	Holder    string    `bson:"holder" json:"holder"`
+	ExpiresAt time.Time `bson:"expiresAt" json:"expiresAt"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// TrashItem is a deleted organization, project or task. Title is the name
+// of an organization or project, or the title of a task.
+type TrashItem struct {
+	Type      string    `bson:"type" json:"type"`
+	ID        string    `bson:"_id" json:"id"`
+	Title     string    `bson:"title" json:"title"`
+	ProjectID *string   `bson:"projectId,omitempty" json:"projectId,omitempty"`
+	DeletedAt time.Time `bson:"deletedAt" json:"deletedAt"`
+}
+
+const (
+	TrashItemOrganization = "organization"
+	TrashItemProject      = "project"
+	TrashItemTask         = "task"
+)
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// View is a task query saved under a name for everyone in an organization.
+// Query is kept as the query string of a task listing, e.g.
+// "priority[in]=high,urgent&assignedTo=me&sort=-updatedAt", so relative
+// values like "me" or "-7d" are resolved whenever the view is used.
+type View struct {
+	ID             string    `bson:"_id,omitempty" json:"id"`
+	OrganizationID string    `bson:"organizationId" json:"organizationId"`
+	Name           string    `bson:"name" json:"name"`
+	Query          string    `bson:"query" json:"query"`
+	CreatedBy      *string   `bson:"createdBy,omitempty" json:"createdBy,omitempty"`
+	CreatedAt      time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time `bson:"updatedAt" json:"updatedAt"`
+}
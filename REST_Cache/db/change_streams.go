@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// EnableChangeStreams has MongoDB keep tasks and projects as they were
+// before and after each change, so change streams can tell which project
+// and organization an update or delete belongs to. It needs MongoDB 6.0 and
+// collections that exist, which CreateIndexes takes care of.
+func EnableChangeStreams() error {
+	ctx := context.Background()
+
+	for _, collection := range []string{"tasks", "projects"} {
+		err := Database.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collection},
+			{Key: "changeStreamPreAndPostImages", Value: bson.M{"enabled": true}},
+		}).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -2,7 +2,8 @@ package db
 
 import (
 	"context"
-	"time"
+
+	"task-manager/config"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -11,18 +12,30 @@ import (
 var Client *mongo.Client
 var Database *mongo.Database
 
-func Connect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func Connect(cfg config.Mongo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(
-		"mongodb://localhost:27017",
-	))
+	opts := options.Client().
+		ApplyURI(cfg.URI).
+		SetMinPoolSize(cfg.MinPoolSize).
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetConnectTimeout(cfg.ConnectTimeout)
+
+	if cfg.Username != "" {
+		opts.SetAuth(options.Credential{
+			Username:   cfg.Username,
+			Password:   cfg.Password,
+			AuthSource: cfg.AuthSource,
+		})
+	}
+
+	client, err := mongo.Connect(ctx, opts)
 	if err != nil {
 		return err
 	}
 
 	Client = client
-	Database = client.Database("task_manager")
+	Database = client.Database(cfg.Database)
 	return nil
 }
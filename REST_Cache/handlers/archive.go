@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ArchiveOrganizationHandler makes the organization with its projects and
+// tasks read-only and answers with the archived organization. See
+// writeLifecycleError for the failures.
+func (h *Handler) ArchiveOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	res, err := h.Organizations.Archive(ctx, id, time.Now())
+	if err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+	h.invalidateOrganizationAsync(id, res)
+
+	h.writeOrganization(ctx, w, id)
+}
+
+// UnarchiveOrganizationHandler makes the organization writable again along
+// with what archiving it made read-only. Projects archived on their own stay
+// archived.
+func (h *Handler) UnarchiveOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	res, err := h.Organizations.Unarchive(ctx, id)
+	if err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+	h.invalidateOrganizationAsync(id, res)
+
+	h.writeOrganization(ctx, w, id)
+}
+
+func (h *Handler) ArchiveProjectHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	res, err := h.Projects.Archive(ctx, id, time.Now())
+	if err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+	h.invalidateProjectAsync(id, "", res)
+
+	h.writeProject(ctx, w, id)
+}
+
+// UnarchiveProjectHandler answers 409 while the organization is archived.
+func (h *Handler) UnarchiveProjectHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	res, err := h.Projects.Unarchive(ctx, id)
+	if err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+	h.invalidateProjectAsync(id, "", res)
+
+	h.writeProject(ctx, w, id)
+}
+
+// writeLifecycleError answers 404 for a missing document, or when restoring
+// one that is not in the trash, and 409 when the document or what contains
+// it is in the wrong state, e.g. when archiving an archived one.
+func writeLifecycleError(w http.ResponseWriter, err error) {
+	switch err {
+	case mongo.ErrNoDocuments:
+		w.WriteHeader(http.StatusNotFound)
+	case repositories.ErrArchived, repositories.ErrNotArchived,
+		repositories.ErrParentArchived, repositories.ErrParentDeleted:
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// writeOrganization answers with the organization as it is now.
+func (h *Handler) writeOrganization(ctx context.Context, w http.ResponseWriter, id string) {
+	org, err := h.Organizations.GetByID(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	setETag(w, org.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
+
+func (h *Handler) writeProject(ctx context.Context, w http.ResponseWriter, id string) {
+	project, err := h.Projects.GetByID(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	setETag(w, project.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+func (h *Handler) writeTask(ctx context.Context, w http.ResponseWriter, id string) {
+	task, err := h.Tasks.GetByID(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.setTaskDerivedFields(ctx, task); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	setETag(w, task.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"task-manager/cache"
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BulkTaskRequest names the tasks to patch either by IDs or by Filter, a
+// query string as ListTasksHandler takes it, never both.
+type BulkTaskRequest struct {
+	IDs    []string                   `json:"ids"`
+	Filter *string                    `json:"filter"`
+	Patch  map[string]json.RawMessage `json:"patch"`
+}
+
+// BulkTaskResult is the outcome for one task, Code being the status the
+// task's own PATCH would have answered. Version is the task's new version
+// when the patch applied.
+type BulkTaskResult struct {
+	ID      string `json:"id"`
+	Code    int    `json:"code"`
+	Version *int64 `json:"version,omitempty"`
+}
+
+// bulkTaskPatch is what a bulk request changes, each field set only when
+// the request patches it. A nil AssignedTo with Unassign set unassigns.
+type bulkTaskPatch struct {
+	Status     *string
+	Priority   *string
+	AssignedTo *string
+	Unassign   bool
+}
+
+// readBulkTaskPatch decodes the patch, telling whether it is valid: not
+// empty and only made of the fields a bulk request can change.
+func readBulkTaskPatch(raw map[string]json.RawMessage) (bulkTaskPatch, bool) {
+	var patch bulkTaskPatch
+	if len(raw) == 0 {
+		return patch, false
+	}
+
+	for key, value := range raw {
+		switch key {
+		case "status":
+			if err := json.Unmarshal(value, &patch.Status); err != nil || patch.Status == nil {
+				return patch, false
+			}
+		case "priority":
+			if err := json.Unmarshal(value, &patch.Priority); err != nil || patch.Priority == nil {
+				return patch, false
+			}
+			if !models.IsValidTaskPriority(*patch.Priority) {
+				return patch, false
+			}
+		case "assignedTo":
+			if err := json.Unmarshal(value, &patch.AssignedTo); err != nil {
+				return patch, false
+			}
+			if patch.AssignedTo == nil {
+				patch.Unassign = true
+			} else if *patch.AssignedTo == "" {
+				return patch, false
+			}
+		default:
+			return patch, false
+		}
+	}
+	return patch, true
+}
+
+// BulkTaskHandler applies one patch to many tasks of a project in a single
+// write. Every task is checked like PATCH /tasks/{id} checks it, the ones
+// that fail are reported and left alone while the rest are changed.
+func (h *Handler) BulkTaskHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectId")
+
+	var req BulkTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if (req.IDs == nil) == (req.Filter == nil) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if req.IDs != nil && len(req.IDs) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) > h.BulkMaxTasks {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	bulk, ok := readBulkTaskPatch(req.Patch)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	project, err := h.Projects.GetByID(ctx, projectID)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if project.ArchivedAt != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	workflow := project.TaskWorkflow()
+	if bulk.Status != nil && !workflow.HasState(*bulk.Status) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	var tasks []*models.Task
+	if req.Filter != nil {
+		tasks, ok = h.bulkFilterTasks(ctx, w, r, project, *req.Filter)
+	} else {
+		tasks, ok = h.bulkTasks(ctx, w, projectID, req.IDs)
+	}
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	actor := actorFromRequest(r)
+	results := make([]BulkTaskResult, 0, len(tasks))
+	patches := []repositories.TaskPatch{}
+	pending := []int{}
+	for _, task := range tasks {
+		result := BulkTaskResult{ID: task.ID}
+		item, code, err := h.bulkTaskPatch(ctx, task, bulk, workflow, actor, now)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if code != 0 {
+			result.Code = code
+		} else {
+			patches = append(patches, item)
+			pending = append(pending, len(results))
+		}
+		results = append(results, result)
+	}
+
+	if len(patches) > 0 {
+		errs, err := h.Tasks.BulkPatch(ctx, patches)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		for i, err := range errs {
+			result := &results[pending[i]]
+			switch err {
+			case nil:
+				version := *patches[i].Patch.IfVersion + 1
+				result.Code = http.StatusOK
+				result.Version = &version
+			case mongo.ErrNoDocuments:
+				result.Code = http.StatusNotFound
+			case repositories.ErrArchived:
+				result.Code = http.StatusUnprocessableEntity
+			default:
+				result.Code = http.StatusConflict
+			}
+		}
+	}
+
+	changed := []string{}
+	for _, result := range results {
+		if result.Code == http.StatusOK {
+			changed = append(changed, result.ID)
+		}
+	}
+	succeeded := len(changed)
+
+	// Every field a bulk request changes counts in the stats
+	if succeeded > 0 {
+		orgID := project.OrganizationID
+		go func() {
+			cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+			defer cacheCancel()
+
+			cache.DeleteTasks(cacheCtx, changed)
+			h.invalidateStats(cacheCtx, projectID, orgID)
+		}()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":      results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
+
+// bulkTasks loads the tasks named by ids in their order, skipping repeated
+// IDs. Tasks that are missing or belong to another project come back with
+// only their ID set, so they are reported as not found.
+func (h *Handler) bulkTasks(
+	ctx context.Context,
+	w http.ResponseWriter,
+	projectID string,
+	ids []string,
+) ([]*models.Task, bool) {
+	tasks := []*models.Task{}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if id == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return nil, false
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		task, err := h.Tasks.GetByID(ctx, id)
+		if err != nil && err != mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusInternalServerError)
+			return nil, false
+		}
+		if err != nil || task.ProjectID != projectID {
+			task = &models.Task{ID: id}
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, true
+}
+
+// bulkFilterTasks lists the project's tasks matching filter, answering 413
+// when there are more than one bulk request may change.
+func (h *Handler) bulkFilterTasks(
+	ctx context.Context,
+	w http.ResponseWriter,
+	r *http.Request,
+	project *models.Project,
+	filter string,
+) ([]*models.Task, bool) {
+	query, err := url.ParseQuery(filter)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+
+	now := time.Now()
+	taskQuery, err := parseTaskQuery(query, now, r.Header.Get("X-User-ID"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	if err := typeCustomFieldQuery(taskQuery, project.CustomFields, now); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	if !checkTaskQueryValues(taskQuery, project.TaskWorkflow()) {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+
+	found, total, err := h.Tasks.ListByProject(ctx, project.ID, taskQuery, 1, int64(h.BulkMaxTasks))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	if total > int64(h.BulkMaxTasks) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+
+	tasks := make([]*models.Task, len(found))
+	for i := range found {
+		tasks[i] = &found[i]
+	}
+	return tasks, true
+}
+
+// bulkTaskPatch turns the bulk patch into the task's own patch. A non-zero
+// code is what the task's PATCH would have answered instead of applying it.
+func (h *Handler) bulkTaskPatch(
+	ctx context.Context,
+	task *models.Task,
+	bulk bulkTaskPatch,
+	workflow models.Workflow,
+	actor string,
+	now time.Time,
+) (repositories.TaskPatch, int, error) {
+	item := repositories.TaskPatch{ID: task.ID}
+	if task.ProjectID == "" {
+		return item, http.StatusNotFound, nil
+	}
+	if task.ArchivedAt != nil {
+		return item, http.StatusUnprocessableEntity, nil
+	}
+
+	patch := repositories.Patch{
+		Set:       bson.M{"updatedAt": now},
+		IfVersion: &task.Version,
+		Actor:     actor,
+	}
+	if bulk.Priority != nil {
+		patch.Set["priority"] = *bulk.Priority
+	}
+	if bulk.AssignedTo != nil {
+		patch.Set["assignedTo"] = *bulk.AssignedTo
+		patch.Set["assignedAt"] = now
+	}
+	if bulk.Unassign {
+		patch.Unset = []string{"assignedTo", "assignedAt"}
+	}
+
+	if bulk.Status != nil && *bulk.Status != task.Status {
+		to := *bulk.Status
+		if !workflow.CanTransition(task.Status, to) {
+			return item, http.StatusConflict, nil
+		}
+		if workflow.IsFinal(to) {
+			blocked, err := h.isBlocked(ctx, task.ID)
+			if err != nil {
+				return item, 0, err
+			}
+			if blocked {
+				return item, http.StatusConflict, nil
+			}
+		}
+
+		patch.Set["status"] = to
+		item.Transition = &models.StatusTransition{
+			TaskID:    task.ID,
+			ProjectID: task.ProjectID,
+			From:      task.Status,
+			To:        to,
+			Actor:     actor,
+			At:        now,
+		}
+	}
+
+	item.Patch = patch
+	return item, 0, nil
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"task-manager/repositories"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the position handed to clients between keyset pages. It is
+// signed, so clients can neither forge positions nor swap the filter it was
+// issued for.
+type pageCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+	// Before marks cursors of prev links, which page towards newer
+	// documents.
+	Before bool   `json:"b,omitempty"`
+	Status string `json:"s,omitempty"`
+}
+
+func (c pageCursor) keyset() *repositories.Keyset {
+	return &repositories.Keyset{CreatedAt: c.CreatedAt, ID: c.ID}
+}
+
+func (h *Handler) encodeCursor(c pageCursor) string {
+	payload, _ := json.Marshal(c)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + h.signCursor(encoded)
+}
+
+func (h *Handler) decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+
+	encoded, signature, ok := strings.Cut(s, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(h.signCursor(encoded))) {
+		return c, errInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, errInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &c); err != nil || c.ID == "" {
+		return c, errInvalidCursor
+	}
+
+	return c, nil
+}
+
+func (h *Handler) signCursor(encoded string) string {
+	mac := hmac.New(sha256.New, h.CursorSecret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
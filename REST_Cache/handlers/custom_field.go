@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"task-manager/cache"
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CustomFieldsRequest carries a project's complete list of custom fields.
+type CustomFieldsRequest struct {
+	Data []models.CustomField `json:"data"`
+}
+
+func (h *Handler) GetProjectFieldsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	// Try the cache first, any cache error falls through to the database
+	project, err := cache.GetProject(ctx, id)
+	if err != nil {
+		project, err = h.Projects.GetByID(ctx, id)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// Store project in cache for future requests (fire-and-forget)
+		go func() {
+			cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+			defer cacheCancel()
+			cache.SetProject(cacheCtx, *project)
+		}()
+	}
+
+	fields := project.CustomFields
+	if fields == nil {
+		fields = []models.CustomField{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": fields})
+}
+
+// UpdateProjectFieldsHandler replaces a project's custom fields. It answers
+// 409 when tasks hold values the new definitions would strand: of a field
+// that is dropped or changes type, or an enum option that is dropped.
+func (h *Handler) UpdateProjectFieldsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req CustomFieldsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if req.Data == nil {
+		req.Data = []models.CustomField{}
+	}
+	if err := models.ValidateCustomFields(req.Data); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	project, err := h.Projects.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	stranded, err := h.strandedFieldValues(ctx, id, project.CustomFields, req.Data)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if stranded > 0 {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	err = h.Projects.SetCustomFields(ctx, id, req.Data)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err == repositories.ErrArchived {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Drop the stale copy and re-cache the project with its new fields
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+
+		cache.DeleteProject(cacheCtx, id)
+
+		updatedProject, err := h.Projects.GetByID(cacheCtx, id)
+		if err == nil {
+			cache.SetProject(cacheCtx, *updatedProject)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": req.Data})
+}
+
+// strandedFieldValues counts the task values that fit the old custom
+// fields but not the new ones.
+func (h *Handler) strandedFieldValues(ctx context.Context, projectID string, old []models.CustomField, fields []models.CustomField) (int64, error) {
+	var stranded int64
+	for _, before := range old {
+		var except []string
+		after, ok := models.FindCustomField(fields, before.Key)
+		if ok && after.Type == before.Type {
+			if after.Type != models.CustomFieldEnum {
+				continue
+			}
+			except = after.Options
+		}
+
+		count, err := h.Tasks.CountCustomFieldValues(ctx, projectID, before.Key, except)
+		if err != nil {
+			return 0, err
+		}
+		stranded += count
+	}
+	return stranded, nil
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// versionETag is the strong entity tag of a document version.
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", versionETag(version))
+}
+
+// hasIfMatch tells whether the request is conditional on If-Match.
+func hasIfMatch(r *http.Request) bool {
+	return len(r.Header.Values("If-Match")) > 0
+}
+
+// ifMatch tells whether the request's If-Match header matches a document at
+// version. A missing header matches anything. Weak tags never match, as
+// If-Match uses the strong comparison.
+func ifMatch(r *http.Request, version int64) bool {
+	if !hasIfMatch(r) {
+		return true
+	}
+
+	etag := versionETag(version)
+	for _, value := range r.Header.Values("If-Match") {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// patchVersionConflict is the status for a patch that lost the race with
+// another write: 412 when the client made it conditional, 409 otherwise as
+// the client did not say which version it meant to patch.
+func patchVersionConflict(r *http.Request) int {
+	if hasIfMatch(r) {
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusConflict
+}
@@ -0,0 +1,116 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"task-manager/cache"
+	"task-manager/config"
+	"task-manager/handlers"
+	"task-manager/repositories/memory"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func init() {
+	// Nothing listens there, so every cache lookup misses and the handlers
+	// fall back to the repositories
+	cache.Client = redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+}
+
+type testServer struct {
+	t   *testing.T
+	h   *handlers.Handler
+	mux *http.ServeMux
+}
+
+// newTestServer serves the routes under test from in-memory repositories.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	h := handlers.New(memory.New(), &config.Config{
+		HTTP:  config.HTTP{RequestTimeout: 5 * time.Second},
+		Jobs:  config.Jobs{Timeout: time.Minute},
+		Cache: config.Cache{Timeout: time.Second},
+		Bulk:  config.Bulk{MaxTasks: 3},
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /organizations", h.ListOrganizationsHandler)
+	mux.HandleFunc("POST /organizations", h.CreateOrganizationHandler)
+	mux.HandleFunc("GET /organizations/{id}", h.GetOrganizationByIDHandler)
+	mux.HandleFunc("PUT /organizations/{id}", h.UpdateOrganizationHandler)
+	mux.HandleFunc("PATCH /organizations/{id}", h.PatchOrganizationHandler)
+	mux.HandleFunc("DELETE /organizations/{id}", h.DeleteOrganizationHandler)
+	mux.HandleFunc("GET /organizations/{id}/changes", h.OrganizationChangesHandler)
+	mux.HandleFunc("GET /organizations/{id}/stats", h.GetOrganizationStatsHandler)
+	mux.HandleFunc("GET /jobs/{id}", h.GetJobByIDHandler)
+	mux.HandleFunc("POST /organizations/{orgId}/projects", h.CreateProjectHandler)
+	mux.HandleFunc("GET /projects/{projectId}/tasks", h.ListTasksHandler)
+	mux.HandleFunc("POST /projects/{projectId}/tasks", h.CreateTaskHandler)
+	mux.HandleFunc("POST /projects/{projectId}/tasks/bulk", h.BulkTaskHandler)
+	mux.HandleFunc("GET /projects/{id}/changes", h.ProjectChangesHandler)
+	mux.HandleFunc("GET /projects/{id}/stats", h.GetProjectStatsHandler)
+	mux.HandleFunc("GET /tasks/{id}", h.GetTaskByIDHandler)
+	mux.HandleFunc("PUT /tasks/{id}", h.UpdateTaskHandler)
+	mux.HandleFunc("PATCH /tasks/{id}", h.PatchTaskHandler)
+	mux.HandleFunc("PUT /tasks/{id}/blockers/{blockerId}", h.AddTaskBlockerHandler)
+	mux.HandleFunc("DELETE /tasks/{id}/blockers/{blockerId}", h.RemoveTaskBlockerHandler)
+
+	return &testServer{t: t, h: h, mux: mux}
+}
+
+// do sends body as JSON, with the headers given as name and value pairs,
+// and decodes the answer into out when it is not nil.
+func (s *testServer) do(method string, path string, body any, out any, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			s.t.Fatalf("encode body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, req)
+
+	if out != nil && rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: decode %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec
+}
+
+// create posts body and returns the id of what it created.
+func (s *testServer) create(path string, body any) string {
+	s.t.Helper()
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if rec := s.do(http.MethodPost, path, body, &created); rec.Code != http.StatusCreated {
+		s.t.Fatalf("POST %s: status %d, body %q", path, rec.Code, rec.Body.String())
+	}
+	return created.ID
+}
+
+// newProject creates an organization with one project and returns the
+// project's id.
+func (s *testServer) newProject() string {
+	s.t.Helper()
+
+	orgID := s.create("/organizations", map[string]any{"name": "Acme", "status": "active"})
+	return s.create("/organizations/"+orgID+"/projects", map[string]any{"name": "Launch"})
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (h *Handler) GetJobByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	job, err := h.Jobs.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+func (h *Handler) startOrganizationDelete(ctx context.Context, orgID string) (*models.Job, error) {
+	job := models.Job{
+		ID:        primitive.NewObjectID().Hex(),
+		Type:      models.JobTypeOrganizationDelete,
+		TargetID:  orgID,
+		Status:    models.JobStatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.Jobs.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	go h.runOrganizationDelete(job)
+
+	return &job, nil
+}
+
+func (h *Handler) runOrganizationDelete(job models.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.JobTimeout)
+	defer cancel()
+
+	if err := h.Jobs.Update(ctx, job.ID, bson.M{
+		"status":    models.JobStatusRunning,
+		"startedAt": time.Now(),
+	}); err != nil {
+		log.Printf("job %s: %v", job.ID, err)
+	}
+
+	progress, err := h.softDeleteOrganization(ctx, job.TargetID)
+
+	update := bson.M{
+		"status":     models.JobStatusCompleted,
+		"progress":   progress,
+		"finishedAt": time.Now(),
+	}
+	if err != nil {
+		update["status"] = models.JobStatusFailed
+		update["error"] = err.Error()
+	}
+
+	if err := h.Jobs.Update(ctx, job.ID, update); err != nil {
+		log.Printf("job %s: %v", job.ID, err)
+	}
+}
+
+// softDeleteOrganization moves the organization with its projects and
+// tasks to the trash, where the purge worker removes them for good later.
+func (h *Handler) softDeleteOrganization(ctx context.Context, orgID string) (models.JobResult, error) {
+	var progress models.JobResult
+
+	res, err := h.Organizations.SoftDelete(ctx, orgID, time.Now())
+	if err == mongo.ErrNoDocuments {
+		// Someone else deleted it first, which is the outcome we wanted
+		return progress, nil
+	}
+	if err != nil {
+		return progress, err
+	}
+
+	progress.Projects = int64(len(res.ProjectIDs))
+	progress.Tasks = int64(len(res.TaskIDs))
+	h.trashOrganizationAsync(orgID, res)
+
+	return progress, nil
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"task-manager/cache"
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// checkTaskLabels tells whether ids are distinct labels of the task's
+// organization.
+func checkTaskLabels(ids []string, labels []models.Label) bool {
+	for i, id := range ids {
+		if slices.Contains(ids[:i], id) {
+			return false
+		}
+		if !slices.ContainsFunc(labels, func(label models.Label) bool { return label.ID == id }) {
+			return false
+		}
+	}
+	return true
+}
+
+// projectLabels returns the labels tasks of the project may carry.
+func (h *Handler) projectLabels(ctx context.Context, project *models.Project) ([]models.Label, error) {
+	return h.Labels.ListByOrganization(ctx, project.OrganizationID)
+}
+
+func (h *Handler) ListLabelsHandler(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("orgId")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Organizations.GetByID(ctx, orgID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	labels, err := h.Labels.ListByOrganization(ctx, orgID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": labels})
+}
+
+func (h *Handler) GetLabelByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	label, err := h.Labels.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(label)
+}
+
+type CreateLabelRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// CreateLabelHandler answers 409 when the organization already has a label
+// with that name in any case.
+func (h *Handler) CreateLabelHandler(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("orgId")
+
+	var req CreateLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" || !models.IsValidLabelColor(req.Color) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Organizations.GetByID(ctx, orgID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	label := models.Label{
+		ID:             primitive.NewObjectID().Hex(),
+		OrganizationID: orgID,
+		Name:           req.Name,
+		Color:          req.Color,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	err := h.Labels.Create(ctx, label)
+	var conflict *repositories.ConflictError
+	if errors.As(err, &conflict) {
+		writeConflict(w, conflict)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(label)
+}
+
+type UpdateLabelRequest struct {
+	Name  *string `json:"name,omitempty"`
+	Color *string `json:"color,omitempty"`
+}
+
+func (h *Handler) UpdateLabelHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req UpdateLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	update := bson.M{
+		"updatedAt": time.Now(),
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		update["name"] = *req.Name
+	}
+	if req.Color != nil {
+		if !models.IsValidLabelColor(*req.Color) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		update["color"] = *req.Color
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	err := h.Labels.Update(ctx, id, update)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var conflict *repositories.ConflictError
+	if errors.As(err, &conflict) {
+		writeConflict(w, conflict)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteLabelHandler removes the label from every task that carries it.
+func (h *Handler) DeleteLabelHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	res, err := h.Labels.Delete(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// The cached copies of the tasks still carry the label
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.DeleteTasks(cacheCtx, res.TaskIDs)
+	}()
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+
+	// acceptPatch is announced with 415 answers to PATCH requests.
+	acceptPatch = mergePatchType + ", " + jsonPatchType
+
+	maxPatchSize = 1 << 20
+)
+
+var (
+	// errPatchMalformed means the patch itself is not valid, answered with
+	// 400.
+	errPatchMalformed = errors.New("malformed patch")
+	// errPatchTestFailed means a JSON Patch test operation did not hold,
+	// answered with 409.
+	errPatchTestFailed = errors.New("patch test failed")
+	// errPatchUnprocessable means the patch cannot be applied to the
+	// document, or the result breaks the model rules. Answered with 422.
+	errPatchUnprocessable = errors.New("patch cannot be applied")
+)
+
+// patchField is a field clients may patch. Fields are strings unless they
+// have Value or Members.
+type patchField struct {
+	// Required fields cannot be removed or emptied.
+	Required bool
+	// Valid checks a new value, nil accepts any string.
+	Valid func(string) bool
+
+	// Value checks a value that need not be a string and returns it the
+	// way it is stored, nil removes the field.
+	Value func(interface{}) (interface{}, error)
+	// Members makes the field an object whose members are set and unset
+	// one by one as field.member. It checks a member's new value and
+	// returns it the way it is stored.
+	Members func(member string, value interface{}) (interface{}, error)
+}
+
+// readPatch applies the request's merge patch (RFC 7396) or JSON Patch
+// (RFC 6902) to the JSON document of a resource, and translates the result
+// into the fields to $set and $unset. An empty patch means nothing changed.
+// It returns the HTTP status to answer with on failure.
+func readPatch(r *http.Request, current map[string]interface{}, fields map[string]patchField) (repositories.Patch, int) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != mergePatchType && mediaType != jsonPatchType {
+		return repositories.Patch{}, http.StatusUnsupportedMediaType
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPatchSize+1))
+	if err != nil || len(body) > maxPatchSize {
+		return repositories.Patch{}, http.StatusBadRequest
+	}
+
+	var patched interface{}
+	if mediaType == mergePatchType {
+		patched, err = applyMergePatch(copyJSON(current), body)
+	} else {
+		patched, err = applyJSONPatch(copyJSON(current), body)
+	}
+	if err == nil {
+		var patch repositories.Patch
+		patch, err = diffPatch(current, patched, fields)
+		if err == nil {
+			return patch, 0
+		}
+	}
+
+	switch {
+	case errors.Is(err, errPatchMalformed):
+		return repositories.Patch{}, http.StatusBadRequest
+	case errors.Is(err, errPatchTestFailed):
+		return repositories.Patch{}, http.StatusConflict
+	default:
+		return repositories.Patch{}, http.StatusUnprocessableEntity
+	}
+}
+
+// writePatchError answers with the status from readPatch, telling the
+// client which patch formats work on 415.
+func writePatchError(w http.ResponseWriter, status int) {
+	if status == http.StatusUnsupportedMediaType {
+		w.Header().Set("Accept-Patch", acceptPatch)
+	}
+	w.WriteHeader(status)
+}
+
+// patchDocument turns a model into the JSON document patches apply to.
+func patchDocument(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	return doc, json.Unmarshal(data, &doc)
+}
+
+// diffPatch validates the patched document and compares it with current.
+// Members outside fields are read-only and must come out unchanged.
+func diffPatch(current map[string]interface{}, patched interface{}, fields map[string]patchField) (repositories.Patch, error) {
+	doc, ok := patched.(map[string]interface{})
+	if !ok {
+		return repositories.Patch{}, fmt.Errorf("%w: result is not an object", errPatchUnprocessable)
+	}
+
+	for name, value := range current {
+		if _, ok := fields[name]; !ok && !reflect.DeepEqual(doc[name], value) {
+			return repositories.Patch{}, fmt.Errorf("%w: %s is read-only", errPatchUnprocessable, name)
+		}
+	}
+
+	patch := repositories.Patch{Set: bson.M{}}
+	for name, value := range doc {
+		field, ok := fields[name]
+		if !ok {
+			if _, ok := current[name]; !ok {
+				return repositories.Patch{}, fmt.Errorf("%w: unknown field %s", errPatchUnprocessable, name)
+			}
+			continue
+		}
+
+		if field.Members != nil {
+			members, ok := value.(map[string]interface{})
+			if !ok {
+				return repositories.Patch{}, fmt.Errorf("%w: %s must be an object", errPatchUnprocessable, name)
+			}
+			currentMembers, _ := current[name].(map[string]interface{})
+			for member, value := range members {
+				stored, err := field.Members(member, value)
+				if err != nil {
+					return repositories.Patch{}, fmt.Errorf("%w: %v", errPatchUnprocessable, err)
+				}
+				if !reflect.DeepEqual(currentMembers[member], value) {
+					patch.Set[name+"."+member] = stored
+				}
+			}
+			for member := range currentMembers {
+				if _, ok := members[member]; !ok {
+					patch.Unset = append(patch.Unset, name+"."+member)
+				}
+			}
+			continue
+		}
+		if field.Value != nil {
+			stored, err := field.Value(value)
+			if err != nil {
+				return repositories.Patch{}, fmt.Errorf("%w: %v", errPatchUnprocessable, err)
+			}
+			if reflect.DeepEqual(current[name], value) {
+				continue
+			}
+			if stored != nil {
+				patch.Set[name] = stored
+			} else if _, ok := current[name]; ok {
+				patch.Unset = append(patch.Unset, name)
+			}
+			continue
+		}
+
+		s, ok := value.(string)
+		if !ok || (field.Required && s == "") || (field.Valid != nil && !field.Valid(s)) {
+			return repositories.Patch{}, fmt.Errorf("%w: invalid %s", errPatchUnprocessable, name)
+		}
+		if current[name] != s {
+			patch.Set[name] = s
+		}
+	}
+
+	for name, field := range fields {
+		if _, ok := doc[name]; ok {
+			continue
+		}
+		if field.Required {
+			return repositories.Patch{}, fmt.Errorf("%w: %s is required", errPatchUnprocessable, name)
+		}
+		if _, ok := current[name]; ok {
+			patch.Unset = append(patch.Unset, name)
+		}
+	}
+
+	return patch, nil
+}
+
+// applyMergePatch implements RFC 7396: objects merge recursively, null
+// removes a member and anything else replaces the target.
+func applyMergePatch(target interface{}, body []byte) (interface{}, error) {
+	var patch interface{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", errPatchMalformed, err)
+	}
+	return mergePatch(target, patch), nil
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = mergePatch(targetObject[name], value)
+		}
+	}
+	return targetObject
+}
+
+// applyJSONPatch implements RFC 6902. Operations apply in order and the
+// whole patch fails if any of them does.
+func applyJSONPatch(doc interface{}, body []byte) (interface{}, error) {
+	var ops []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", errPatchMalformed, err)
+	}
+
+	for i, op := range ops {
+		var err error
+		doc, err = applyJSONPatchOp(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyJSONPatchOp(doc interface{}, op map[string]json.RawMessage) (interface{}, error) {
+	var name string
+	if err := json.Unmarshal(op["op"], &name); err != nil {
+		return nil, fmt.Errorf("%w: missing op", errPatchMalformed)
+	}
+
+	pointer := func(member string) ([]string, error) {
+		var s string
+		if err := json.Unmarshal(op[member], &s); err != nil {
+			return nil, fmt.Errorf("%w: missing %s", errPatchMalformed, member)
+		}
+		return parsePointer(s)
+	}
+	value := func() (interface{}, error) {
+		raw, ok := op["value"]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing value", errPatchMalformed)
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("%w: %v", errPatchMalformed, err)
+		}
+		return v, nil
+	}
+
+	path, err := pointer("path")
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "add", "replace", "test":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "add":
+			return addAt(doc, path, v)
+		case "replace":
+			if len(path) == 0 {
+				return v, nil
+			}
+			if doc, err = removeAt(doc, path); err != nil {
+				return nil, err
+			}
+			return addAt(doc, path, v)
+		default:
+			current, err := getAt(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, v) {
+				return nil, errPatchTestFailed
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		return removeAt(doc, path)
+
+	case "move", "copy":
+		from, err := pointer("from")
+		if err != nil {
+			return nil, err
+		}
+		v, err := getAt(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if name == "copy" {
+			return addAt(doc, path, copyJSON(v))
+		}
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, fmt.Errorf("%w: cannot move into itself", errPatchUnprocessable)
+		}
+		if doc, err = removeAt(doc, from); err != nil {
+			return nil, err
+		}
+		return addAt(doc, path, v)
+	}
+
+	return nil, fmt.Errorf("%w: unknown op %q", errPatchMalformed, name)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into reference tokens.
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("%w: bad pointer %q", errPatchMalformed, s)
+	}
+
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func getAt(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			v, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s not found", errPatchUnprocessable, token)
+			}
+			doc = v
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%w: %s not found", errPatchUnprocessable, token)
+		}
+	}
+	return doc, nil
+}
+
+// addAt adds value at path, replacing object members and inserting into
+// arrays. It returns the new document.
+func addAt(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return modifyParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			i := len(node)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		return nil, fmt.Errorf("%w: cannot add to %s", errPatchUnprocessable, token)
+	})
+}
+
+// removeAt removes the value at path, which must exist.
+func removeAt(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", errPatchUnprocessable)
+	}
+
+	return modifyParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := node[token]; !ok {
+				return nil, fmt.Errorf("%w: %s not found", errPatchUnprocessable, token)
+			}
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:i], node[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%w: %s not found", errPatchUnprocessable, token)
+	})
+}
+
+// modifyParent runs fn on the container holding the last token of path and
+// stores the container it returns back into the document, since appending
+// to an array may move it.
+func modifyParent(doc interface{}, path []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	child, err := getAt(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = modifyParent(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		node[path[0]] = child
+	case []interface{}:
+		i, _ := arrayIndex(path[0], len(node)-1)
+		node[i] = child
+	}
+	return doc, nil
+}
+
+// arrayIndex parses an array index token between 0 and max.
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: bad array index %q", errPatchUnprocessable, token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max {
+		return 0, fmt.Errorf("%w: array index %q out of range", errPatchUnprocessable, token)
+	}
+	return i, nil
+}
+
+// copyJSON deep-copies a decoded JSON value.
+func copyJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for name, value := range v {
+			out[name] = copyJSON(value)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = copyJSON(value)
+		}
+		return out
+	}
+	return v
+}
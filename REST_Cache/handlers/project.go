@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"task-manager/cache"
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (h *Handler) GetProjectByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	// Try the cache first, any cache error falls through to the database
+	project, err := cache.GetProject(ctx, id)
+	if err == nil {
+		setETag(w, project.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(project)
+		return
+	}
+
+	project, err = h.Projects.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Store project in cache for future requests (fire-and-forget)
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.SetProject(cacheCtx, *project)
+	}()
+
+	setETag(w, project.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+func (h *Handler) ListProjectsHandler(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("orgId")
+	query := r.URL.Query()
+	page, limit := parsePage(query)
+
+	var status *string
+	if s := query.Get("status"); s != "" {
+		if !models.IsValidProjectStatus(s) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		status = &s
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Organizations.GetByID(ctx, orgID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	projects, total, err := h.Projects.List(ctx, orgID, page, limit, status)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(paginatedResponse(projects, page, limit, total))
+}
+
+type CreateProjectRequest struct {
+	Name        string  `json:"name"`
+	Status      string  `json:"status"`
+	Description *string `json:"description,omitempty"`
+}
+
+// CreateProjectHandler answers 404 when the parent organization does not
+// exist and 422 when it is archived.
+func (h *Handler) CreateProjectHandler(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("orgId")
+
+	var req CreateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if req.Status == "" {
+		req.Status = models.ProjectStatusPlanned
+	}
+	if req.Name == "" || !models.IsValidProjectStatus(req.Status) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	org, err := h.Organizations.GetByID(ctx, orgID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if org.Status == models.OrganizationStatusArchived {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	now := time.Now()
+	project := models.Project{
+		ID:             primitive.NewObjectID().Hex(),
+		Name:           req.Name,
+		OrganizationID: org.ID,
+		Status:         req.Status,
+		Description:    req.Description,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	err = h.Projects.Create(ctx, project)
+	var conflict *repositories.ConflictError
+	if errors.As(err, &conflict) {
+		writeConflict(w, conflict)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Store newly created project in cache (fire-and-forget)
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.SetProject(cacheCtx, project)
+	}()
+	h.invalidateStatsAsync(project.ID, org.ID)
+
+	setETag(w, project.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(project)
+}
+
+type UpdateProjectRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Status      *string `json:"status,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// UpdateProjectHandler answers 412 when the project does not match
+// If-Match, or changes before the update applies.
+func (h *Handler) UpdateProjectHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	update := bson.M{
+		"updatedAt": time.Now(),
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		update["name"] = *req.Name
+	}
+	if req.Status != nil {
+		if !models.IsValidProjectStatus(*req.Status) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		update["status"] = *req.Status
+	}
+	if req.Description != nil {
+		update["description"] = *req.Description
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	var ifVersion *int64
+	if hasIfMatch(r) {
+		project, err := h.Projects.GetByID(ctx, id)
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !ifMatch(r, project.Version) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		ifVersion = &project.Version
+	}
+
+	err := h.Projects.Patch(ctx, id, repositories.Patch{Set: update, IfVersion: ifVersion})
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err == repositories.ErrArchived {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+	if err == repositories.ErrVersionConflict {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	var conflict *repositories.ConflictError
+	if errors.As(err, &conflict) {
+		writeConflict(w, conflict)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Drop the stale copy and re-cache the updated project
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+
+		cache.DeleteProject(cacheCtx, id)
+
+		updatedProject, err := h.Projects.GetByID(cacheCtx, id)
+		if err == nil {
+			cache.SetProject(cacheCtx, *updatedProject)
+			h.invalidateStats(cacheCtx, id, updatedProject.OrganizationID)
+		}
+	}()
+
+	// The update applied to exactly that version
+	if ifVersion != nil {
+		setETag(w, *ifVersion+1)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var projectPatchFields = map[string]patchField{
+	"name":        {Required: true},
+	"status":      {Required: true, Valid: models.IsValidProjectStatus},
+	"description": {},
+}
+
+// PatchProjectHandler applies a merge patch or JSON Patch to the project
+// and answers with the result. See readPatch and patchVersionConflict for
+// the statuses. The workflow has its own endpoint.
+func (h *Handler) PatchProjectHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	project, err := h.Projects.GetByID(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !ifMatch(r, project.Version) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	current, err := patchDocument(project)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	patch, status := readPatch(r, current, projectPatchFields)
+	if status != 0 {
+		writePatchError(w, status)
+		return
+	}
+
+	if !patch.Empty() {
+		patch.Set["updatedAt"] = time.Now()
+		patch.IfVersion = &project.Version
+		err = h.Projects.Patch(ctx, id, patch)
+		if err == nil {
+			project, err = h.Projects.GetByID(ctx, id)
+		}
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err == repositories.ErrArchived {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		if err == repositories.ErrVersionConflict {
+			w.WriteHeader(patchVersionConflict(r))
+			return
+		}
+		var conflict *repositories.ConflictError
+		if errors.As(err, &conflict) {
+			writeConflict(w, conflict)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// Re-cache the patched project. SetProject keeps a newer copy
+		// cached meanwhile, which dropping the old one first would lose.
+		updatedProject := *project
+		_, renamed := patch.Set["name"]
+		_, statusChanged := patch.Set["status"]
+		go func() {
+			cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+			defer cacheCancel()
+
+			cache.SetProject(cacheCtx, updatedProject)
+			if renamed || statusChanged {
+				h.invalidateStats(cacheCtx, id, updatedProject.OrganizationID)
+			}
+		}()
+	}
+
+	setETag(w, project.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// DeleteProjectHandler moves the project with its tasks to the trash.
+func (h *Handler) DeleteProjectHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	// The organization's stats are dropped too, which needs the project
+	project, err := h.Projects.GetByID(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := h.Projects.SoftDelete(ctx, id, time.Now())
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Invalidate cache for deleted project and its tasks (fire-and-forget)
+	h.invalidateProjectAsync(id, project.OrganizationID, res)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// invalidateProjectAsync drops the cached project and its stats along with
+// the tasks that archiving, deleting or restoring it changed. An empty
+// orgID is looked up.
+func (h *Handler) invalidateProjectAsync(id string, orgID string, res *repositories.CascadeResult) {
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.DeleteProject(cacheCtx, id)
+		h.invalidateStats(cacheCtx, id, orgID)
+		cache.DeleteTasks(cacheCtx, res.TaskIDs)
+	}()
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SearchHandler searches one organization, named by organizationId, for the
+// text in q. type limits the hits to a comma-separated list of
+// organization, project and task. Hits come best first.
+func (h *Handler) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	search := repositories.SearchQuery{
+		OrganizationID: query.Get("organizationId"),
+		Text:           strings.TrimSpace(query.Get("q")),
+		Limit:          20,
+	}
+	if search.OrganizationID == "" || search.Text == "" || len(search.Text) > 256 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if l := query.Get("limit"); l != "" {
+		limit, err := strconv.ParseInt(l, 10, 64)
+		if err != nil || limit <= 0 || limit > 100 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		search.Limit = limit
+	}
+
+	if t := query.Get("type"); t != "" {
+		for _, hitType := range strings.Split(t, ",") {
+			if !models.IsValidSearchHitType(hitType) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			search.Types = append(search.Types, hitType)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Organizations.GetByID(ctx, search.OrganizationID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	hits, err := h.Search.Search(ctx, search)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": hits})
+}
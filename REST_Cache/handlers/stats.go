@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"task-manager/cache"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetOrganizationStatsHandler reports task counts by status and priority and
+// overdue tasks per project and for the whole organization, and the
+// assignees' workload. Cached stats are dropped whenever a project or task
+// of the organization changes. Tasks also become overdue as time passes, so
+// the TTL bounds how late the overdue counts may be.
+func (h *Handler) GetOrganizationStatsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	// Any cache error falls through to the database
+	if stats, err := cache.GetOrganizationStats(ctx, id); err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+		return
+	}
+
+	if _, err := h.Organizations.GetByID(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	stats, err := h.Stats.Organization(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.SetOrganizationStats(cacheCtx, *stats)
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *Handler) GetProjectStatsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if stats, err := cache.GetProjectStats(ctx, id); err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+		return
+	}
+
+	stats, err := h.Stats.Project(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.SetProjectStats(cacheCtx, *stats)
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// invalidateStats drops the cached stats of a project and its organization
+// after the project or its tasks changed. An empty orgID is looked up.
+func (h *Handler) invalidateStats(ctx context.Context, projectID string, orgID string) {
+	if orgID == "" {
+		if project, err := h.Projects.GetByID(ctx, projectID); err == nil {
+			orgID = project.OrganizationID
+		}
+	}
+	cache.DeleteStats(ctx, orgID, projectID)
+}
+
+func (h *Handler) invalidateStatsAsync(projectID string, orgID string) {
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		h.invalidateStats(cacheCtx, projectID, orgID)
+	}()
+}
@@ -0,0 +1,955 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"task-manager/cache"
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (h *Handler) GetTaskByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	// Try the cache first, any cache error falls through to the database
+	task, err := cache.GetTask(ctx, id)
+	if err == nil {
+		// Allowed transitions follow the current workflow, not the cached copy
+		if err := h.setTaskDerivedFields(ctx, task); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		setETag(w, task.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(task)
+		return
+	}
+
+	task, err = h.Tasks.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.setTaskDerivedFields(ctx, task); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Store task in cache for future requests (fire-and-forget)
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.SetTask(cacheCtx, *task)
+	}()
+
+	setETag(w, task.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+func (h *Handler) setTaskDerivedFields(ctx context.Context, task *models.Task) error {
+	tasks := []models.Task{*task}
+	if err := h.setDerivedFields(ctx, tasks); err != nil {
+		return err
+	}
+	task.AllowedTransitions = tasks[0].AllowedTransitions
+	task.BlockedBy = tasks[0].BlockedBy
+	task.Blocking = tasks[0].Blocking
+	return nil
+}
+
+// ListTasksHandler lists a project's tasks filtered and sorted as described
+// at parseTaskQuery. A view parameter applies a saved view of the project's
+// organization, other parameters override the view's.
+func (h *Handler) ListTasksHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectId")
+	query := r.URL.Query()
+	page, limit := parsePage(query)
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	project, err := h.Projects.GetByID(ctx, projectID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if viewID := query.Get("view"); viewID != "" {
+		view, err := h.Views.GetByID(ctx, viewID)
+		if err != nil && err != mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err != nil || view.OrganizationID != project.OrganizationID {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		values, err := url.ParseQuery(view.Query)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for key, vals := range query {
+			values[key] = vals
+		}
+		query = values
+	}
+
+	now := time.Now()
+	taskQuery, err := parseTaskQuery(query, now, r.Header.Get("X-User-ID"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := typeCustomFieldQuery(taskQuery, project.CustomFields, now); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workflow := project.TaskWorkflow()
+	if !checkTaskQueryValues(taskQuery, workflow) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	tasks, total, err := h.Tasks.ListByProject(ctx, projectID, taskQuery, page, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	for i := range tasks {
+		tasks[i].AllowedTransitions = workflow.Next(tasks[i].Status)
+	}
+	if err := h.setDependencies(ctx, tasks); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(paginatedResponse(tasks, page, limit, total))
+}
+
+// ListUserTasksHandler lists every task assigned to a user across projects.
+func (h *Handler) ListUserTasksHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+	query := r.URL.Query()
+	page, limit := parsePage(query)
+
+	var status *string
+	if s := query.Get("status"); s != "" {
+		status = &s
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	tasks, total, err := h.Tasks.ListByAssignee(ctx, userID, page, limit, status)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.setDerivedFields(ctx, tasks); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(paginatedResponse(tasks, page, limit, total))
+}
+
+type CreateTaskRequest struct {
+	Title        string                 `json:"title"`
+	Status       string                 `json:"status"`
+	Priority     string                 `json:"priority"`
+	Description  *string                `json:"description,omitempty"`
+	Labels       []string               `json:"labels,omitempty"`
+	CustomFields map[string]interface{} `json:"customFields,omitempty"`
+	DueAt        *time.Time             `json:"dueAt,omitempty"`
+	Reminders    []string               `json:"reminders,omitempty"`
+}
+
+// CreateTaskHandler starts tasks in the workflow's initial state. Asking for
+// any other state is an illegal move and answers 409, an archived project
+// answers 422.
+func (h *Handler) CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectId")
+
+	var req CreateTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if req.Priority == "" {
+		req.Priority = models.TaskPriorityMedium
+	}
+	if req.Title == "" || !models.IsValidTaskPriority(req.Priority) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := models.ValidateReminders(req.Reminders); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	project, err := h.Projects.GetByID(ctx, projectID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if project.ArchivedAt != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	workflow := project.TaskWorkflow()
+	if req.Status == "" {
+		req.Status = workflow.Initial
+	}
+	if !workflow.HasState(req.Status) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if req.Status != workflow.Initial {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	customFields, err := models.CustomFieldValues(project.CustomFields, req.CustomFields)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(req.Labels) > 0 {
+		labels, err := h.projectLabels(ctx, project)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !checkTaskLabels(req.Labels, labels) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	now := time.Now()
+	task := models.Task{
+		ID:           primitive.NewObjectID().Hex(),
+		Title:        req.Title,
+		ProjectID:    project.ID,
+		Status:       req.Status,
+		Priority:     req.Priority,
+		Description:  req.Description,
+		Labels:       req.Labels,
+		CustomFields: customFields,
+		DueAt:        req.DueAt,
+		Reminders:    req.Reminders,
+		NotifyAt:     models.NextNotification(req.DueAt, req.Reminders, now),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	task.AllowedTransitions = workflow.Next(task.Status)
+	task.BlockedBy, task.Blocking = []string{}, []string{}
+
+	if err := h.Tasks.Create(ctx, task); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Store newly created task in cache (fire-and-forget)
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.SetTask(cacheCtx, task)
+	}()
+	h.invalidateStatsAsync(project.ID, project.OrganizationID)
+
+	setETag(w, task.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(task)
+}
+
+// UpdateTaskRequest deliberately has no assignee fields, assignment goes
+// through the assign and unassign endpoints. Labels, CustomFields and
+// Reminders replace the task's when given, empty ones remove them all. A
+// due date is removed with PATCH.
+type UpdateTaskRequest struct {
+	Title        *string                `json:"title,omitempty"`
+	Status       *string                `json:"status,omitempty"`
+	Priority     *string                `json:"priority,omitempty"`
+	Description  *string                `json:"description,omitempty"`
+	Labels       []string               `json:"labels,omitempty"`
+	CustomFields map[string]interface{} `json:"customFields,omitempty"`
+	DueAt        *time.Time             `json:"dueAt,omitempty"`
+	Reminders    []string               `json:"reminders,omitempty"`
+}
+
+// UpdateTaskHandler records status changes as transitions. It answers 409
+// when the workflow does not allow the change, when it would complete a
+// task with open blockers, or when the status changed meanwhile. It answers
+// 412 when the task does not match If-Match or changes before the update
+// applies.
+func (h *Handler) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"updatedAt": now,
+	}
+
+	if req.Title != nil {
+		if *req.Title == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		update["title"] = *req.Title
+	}
+	if req.Status != nil {
+		if *req.Status == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		update["status"] = *req.Status
+	}
+	if req.Priority != nil {
+		if !models.IsValidTaskPriority(*req.Priority) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		update["priority"] = *req.Priority
+	}
+	if req.Description != nil {
+		update["description"] = *req.Description
+	}
+	if err := models.ValidateReminders(req.Reminders); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	var transition *models.StatusTransition
+	var ifVersion *int64
+	var unset []string
+	if req.Status != nil || hasIfMatch(r) || req.Labels != nil || req.CustomFields != nil || req.DueAt != nil || req.Reminders != nil {
+		task, err := h.Tasks.GetByID(ctx, id)
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if hasIfMatch(r) {
+			if !ifMatch(r, task.Version) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			ifVersion = &task.Version
+		}
+
+		if req.Labels != nil || req.CustomFields != nil {
+			project, err := h.Projects.GetByID(ctx, task.ProjectID)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			unset, err = h.taskExtras(ctx, project, req.Labels, req.CustomFields, update)
+			if err == errTaskExtras {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if req.DueAt != nil || req.Reminders != nil {
+			dueAt, reminders := task.DueAt, task.Reminders
+			if req.DueAt != nil {
+				dueAt = req.DueAt
+				update["dueAt"] = *req.DueAt
+			}
+			if req.Reminders != nil {
+				reminders = req.Reminders
+				if len(reminders) > 0 {
+					update["reminders"] = reminders
+				} else {
+					unset = append(unset, "reminders")
+				}
+			}
+			unset = scheduleNotifications(update, unset, dueAt, reminders, now)
+		}
+
+		if req.Status != nil && *req.Status == task.Status {
+			delete(update, "status")
+		} else if req.Status != nil {
+			workflow, err := h.projectWorkflow(ctx, task.ProjectID)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if !workflow.HasState(*req.Status) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if !workflow.CanTransition(task.Status, *req.Status) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			if workflow.IsFinal(*req.Status) {
+				blocked, err := h.isBlocked(ctx, id)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if blocked {
+					w.WriteHeader(http.StatusConflict)
+					return
+				}
+			}
+
+			transition = &models.StatusTransition{
+				TaskID:    id,
+				ProjectID: task.ProjectID,
+				From:      task.Status,
+				To:        *req.Status,
+				Actor:     actorFromRequest(r),
+				At:        now,
+			}
+		}
+	}
+
+	err := h.Tasks.Patch(ctx, id, repositories.Patch{Set: update, Unset: unset, IfVersion: ifVersion, Actor: actorFromRequest(r)}, transition)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err == repositories.ErrArchived {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+	if err == repositories.ErrVersionConflict {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if err == repositories.ErrStatusConflict {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Re-cache the updated task through the versioned write, so a read that
+	// got an older version cannot replace it
+	statsChanged := req.Status != nil || req.Priority != nil || req.DueAt != nil
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+
+		updatedTask, err := h.Tasks.GetByID(cacheCtx, id)
+		if err != nil {
+			// Deleted meanwhile, the delete leaves a tombstone
+			if err != mongo.ErrNoDocuments {
+				cache.DeleteTask(cacheCtx, id)
+			}
+			return
+		}
+		cache.SetTask(cacheCtx, *updatedTask)
+		if statsChanged {
+			h.invalidateStats(cacheCtx, updatedTask.ProjectID, "")
+		}
+	}()
+
+	// The update applied to exactly that version
+	if ifVersion != nil {
+		setETag(w, *ifVersion+1)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var errTaskExtras = errors.New("invalid labels or custom fields")
+
+// taskExtras checks the labels and custom field values a PUT replaces the
+// task's with, either may be nil to keep them. It adds the new values to
+// update and returns the fields to unset.
+func (h *Handler) taskExtras(
+	ctx context.Context,
+	project *models.Project,
+	labelIDs []string,
+	rawFields map[string]interface{},
+	update bson.M,
+) ([]string, error) {
+	var unset []string
+
+	if labelIDs != nil {
+		labels, err := h.projectLabels(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+		if !checkTaskLabels(labelIDs, labels) {
+			return nil, errTaskExtras
+		}
+		if len(labelIDs) > 0 {
+			update["labels"] = labelIDs
+		} else {
+			unset = append(unset, "labels")
+		}
+	}
+
+	if rawFields != nil {
+		values, err := models.CustomFieldValues(project.CustomFields, rawFields)
+		if err != nil {
+			return nil, errTaskExtras
+		}
+		if values != nil {
+			update["customFields"] = values
+		} else {
+			unset = append(unset, "customFields")
+		}
+	}
+
+	return unset, nil
+}
+
+// scheduleNotifications adds when the reminder scheduler next looks at a
+// task with the given due date and reminders to a write at now, to set or
+// to unset. It returns unset.
+func scheduleNotifications(set bson.M, unset []string, dueAt *time.Time, reminders []string, now time.Time) []string {
+	if next := models.NextNotification(dueAt, reminders, now); next != nil {
+		set["notifyAt"] = *next
+		return unset
+	}
+	return append(unset, "notifyAt")
+}
+
+// taskPatchFields lists what clients may patch on tasks of the project.
+// Labels must be among the organization's labels and custom field values
+// fit the project's definitions.
+func taskPatchFields(project *models.Project, labels []models.Label) map[string]patchField {
+	return map[string]patchField{
+		"title":       {Required: true},
+		"status":      {Required: true},
+		"priority":    {Required: true, Valid: models.IsValidTaskPriority},
+		"description": {},
+		"labels": {Value: func(value interface{}) (interface{}, error) {
+			items, ok := value.([]interface{})
+			if !ok {
+				return nil, errTaskExtras
+			}
+			ids := make([]string, 0, len(items))
+			for _, item := range items {
+				id, ok := item.(string)
+				if !ok {
+					return nil, errTaskExtras
+				}
+				ids = append(ids, id)
+			}
+			if !checkTaskLabels(ids, labels) {
+				return nil, errTaskExtras
+			}
+			if len(ids) == 0 {
+				return nil, nil
+			}
+			return ids, nil
+		}},
+		"customFields": {Members: func(key string, value interface{}) (interface{}, error) {
+			field, ok := models.FindCustomField(project.CustomFields, key)
+			if !ok {
+				return nil, errTaskExtras
+			}
+			return field.Value(value)
+		}},
+		"dueAt": {Value: func(value interface{}) (interface{}, error) {
+			s, ok := value.(string)
+			if !ok {
+				return nil, errors.New("invalid dueAt")
+			}
+			return time.Parse(time.RFC3339, s)
+		}},
+		"reminders": {Value: func(value interface{}) (interface{}, error) {
+			items, ok := value.([]interface{})
+			if !ok {
+				return nil, errors.New("invalid reminders")
+			}
+			reminders := make([]string, 0, len(items))
+			for _, item := range items {
+				reminder, ok := item.(string)
+				if !ok {
+					return nil, errors.New("invalid reminders")
+				}
+				reminders = append(reminders, reminder)
+			}
+			if err := models.ValidateReminders(reminders); err != nil {
+				return nil, err
+			}
+			if len(reminders) == 0 {
+				return nil, nil
+			}
+			return reminders, nil
+		}},
+	}
+}
+
+// patchedSchedule returns the due date and reminders of task once patch
+// applies, and whether patch changes either.
+func patchedSchedule(task *models.Task, patch repositories.Patch) (*time.Time, []string, bool) {
+	dueAt, reminders := task.DueAt, task.Reminders
+	changed := true
+	if t, ok := patch.Set["dueAt"].(time.Time); ok {
+		dueAt = &t
+	} else if slices.Contains(patch.Unset, "dueAt") {
+		dueAt = nil
+	} else {
+		changed = false
+	}
+	if r, ok := patch.Set["reminders"].([]string); ok {
+		reminders, changed = r, true
+	} else if slices.Contains(patch.Unset, "reminders") {
+		reminders, changed = nil, true
+	}
+	return dueAt, reminders, changed
+}
+
+// PatchTaskHandler applies a merge patch or JSON Patch to the task and
+// answers with the result. A status outside the project workflow answers
+// 422, a change the workflow does not allow, that would complete a task
+// with open blockers or that raced another one 409.
+// See readPatch and patchVersionConflict for the other statuses.
+// Assignment has its own endpoints.
+func (h *Handler) PatchTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	task, err := h.Tasks.GetByID(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !ifMatch(r, task.Version) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	project, err := h.Projects.GetByID(ctx, task.ProjectID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	labels, err := h.projectLabels(ctx, project)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// The document matches what GET answers, allowedTransitions included
+	if err := h.setTaskDerivedFields(ctx, task); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	current, err := patchDocument(task)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	patch, status := readPatch(r, current, taskPatchFields(project, labels))
+	if status != 0 {
+		writePatchError(w, status)
+		return
+	}
+
+	if !patch.Empty() {
+		now := time.Now()
+		patch.Set["updatedAt"] = now
+		if dueAt, reminders, ok := patchedSchedule(task, patch); ok {
+			patch.Unset = scheduleNotifications(patch.Set, patch.Unset, dueAt, reminders, now)
+		}
+
+		var transition *models.StatusTransition
+		if to, ok := patch.Set["status"].(string); ok {
+			workflow := project.TaskWorkflow()
+			if !workflow.HasState(to) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			if !workflow.CanTransition(task.Status, to) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			if workflow.IsFinal(to) {
+				blocked, err := h.isBlocked(ctx, id)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if blocked {
+					w.WriteHeader(http.StatusConflict)
+					return
+				}
+			}
+
+			transition = &models.StatusTransition{
+				TaskID:    id,
+				ProjectID: task.ProjectID,
+				From:      task.Status,
+				To:        to,
+				Actor:     actorFromRequest(r),
+				At:        now,
+			}
+		}
+
+		patch.IfVersion = &task.Version
+		patch.Actor = actorFromRequest(r)
+		err = h.Tasks.Patch(ctx, id, patch, transition)
+		if err == nil {
+			task, err = h.Tasks.GetByID(ctx, id)
+		}
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err == repositories.ErrArchived {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		if err == repositories.ErrVersionConflict {
+			w.WriteHeader(patchVersionConflict(r))
+			return
+		}
+		if err == repositories.ErrStatusConflict {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// Re-cache the patched task. SetTask keeps a newer copy cached
+		// meanwhile, which dropping the old one first would lose.
+		updatedTask := *task
+		_, priorityChanged := patch.Set["priority"]
+		_, dueAtChanged := patch.Set["dueAt"]
+		statsChanged := transition != nil || priorityChanged || dueAtChanged || slices.Contains(patch.Unset, "dueAt")
+		go func() {
+			cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+			defer cacheCancel()
+
+			cache.SetTask(cacheCtx, updatedTask)
+			if statsChanged {
+				h.invalidateStats(cacheCtx, updatedTask.ProjectID, "")
+			}
+		}()
+
+		if err := h.setTaskDerivedFields(ctx, task); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	setETag(w, task.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// DeleteTaskHandler moves the task to the trash.
+func (h *Handler) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	// The task's project is needed to drop the stats it counted in
+	task, err := h.Tasks.GetByID(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = h.Tasks.SoftDelete(ctx, id, time.Now())
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Leave a tombstone so a read racing the delete cannot cache the task
+	// again (fire-and-forget)
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.TombstoneTask(cacheCtx, id)
+		h.invalidateStats(cacheCtx, task.ProjectID, "")
+	}()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type AssignTaskRequest struct {
+	UserID string `json:"userId"`
+}
+
+func (h *Handler) AssignTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req AssignTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if id == "" || req.UserID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	task, err := h.Tasks.Assign(ctx, id, req.UserID, actorFromRequest(r))
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err == repositories.ErrArchived {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.setTaskDerivedFields(ctx, task); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// The update returned the new document, cache it as is
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.SetTask(cacheCtx, *task)
+	}()
+	h.invalidateStatsAsync(task.ProjectID, "")
+
+	setETag(w, task.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+func (h *Handler) UnassignTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	task, err := h.Tasks.Unassign(ctx, id, actorFromRequest(r))
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err == repositories.ErrArchived {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.setTaskDerivedFields(ctx, task); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// The update returned the new document, cache it as is
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.SetTask(cacheCtx, *task)
+	}()
+	h.invalidateStatsAsync(task.ProjectID, "")
+
+	setETag(w, task.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"task-manager/cache"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ListDeletedOrganizationsHandler pages the organizations in the trash,
+// latest deleted first.
+func (h *Handler) ListDeletedOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePage(r.URL.Query())
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	orgs, total, err := h.Trash.ListOrganizations(ctx, page, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(paginatedResponse(orgs, page, limit, total))
+}
+
+// ListTrashHandler pages the projects and tasks of an organization that are
+// in the trash, latest deleted first. What was deleted along with a project
+// is restored with it and not listed.
+func (h *Handler) ListTrashHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	page, limit := parsePage(r.URL.Query())
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Organizations.GetByID(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	items, total, err := h.Trash.List(ctx, id, page, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(paginatedResponse(items, page, limit, total))
+}
+
+// RestoreOrganizationHandler takes the organization out of the trash along
+// with what was deleted with it, and answers with the organization. See
+// writeLifecycleError for the failures.
+func (h *Handler) RestoreOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	res, err := h.Organizations.Restore(ctx, id)
+	if err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+	h.invalidateOrganizationAsync(id, res)
+
+	h.writeOrganization(ctx, w, id)
+}
+
+// RestoreProjectHandler answers 409 while the organization is in the trash.
+func (h *Handler) RestoreProjectHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	res, err := h.Projects.Restore(ctx, id)
+	if err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+	h.invalidateProjectAsync(id, "", res)
+
+	h.writeProject(ctx, w, id)
+}
+
+// RestoreTaskHandler answers 409 while the project is in the trash.
+func (h *Handler) RestoreTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if err := h.Tasks.Restore(ctx, id); err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.DeleteTask(cacheCtx, id)
+		if task, err := h.Tasks.GetByID(cacheCtx, id); err == nil {
+			h.invalidateStats(cacheCtx, task.ProjectID, "")
+		}
+	}()
+
+	h.writeTask(ctx, w, id)
+}
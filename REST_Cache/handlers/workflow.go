@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"task-manager/cache"
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// actorFromRequest identifies who made a change. There is no authentication
+// in front of the task manager yet, so callers pass their user ID in the
+// X-User-ID header.
+func actorFromRequest(r *http.Request) string {
+	if actor := r.Header.Get("X-User-ID"); actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
+
+func (h *Handler) projectWorkflow(ctx context.Context, projectID string) (models.Workflow, error) {
+	// Try the cache first, any cache error falls through to the database
+	project, err := cache.GetProject(ctx, projectID)
+	if err == nil {
+		return project.TaskWorkflow(), nil
+	}
+
+	project, err = h.Projects.GetByID(ctx, projectID)
+	if err != nil {
+		return models.Workflow{}, err
+	}
+
+	// Store project in cache for future requests (fire-and-forget)
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+		cache.SetProject(cacheCtx, *project)
+	}()
+
+	return project.TaskWorkflow(), nil
+}
+
+// setDerivedFields fills AllowedTransitions, BlockedBy and Blocking on
+// tasks that may belong to different projects, loading each project's
+// workflow once.
+func (h *Handler) setDerivedFields(ctx context.Context, tasks []models.Task) error {
+	workflows := map[string]models.Workflow{}
+
+	for i := range tasks {
+		workflow, ok := workflows[tasks[i].ProjectID]
+		if !ok {
+			var err error
+			workflow, err = h.projectWorkflow(ctx, tasks[i].ProjectID)
+			if err == mongo.ErrNoDocuments {
+				// Orphaned task, nothing is allowed until it has a project
+				workflow = models.Workflow{}
+			} else if err != nil {
+				return err
+			}
+			workflows[tasks[i].ProjectID] = workflow
+		}
+
+		tasks[i].AllowedTransitions = workflow.Next(tasks[i].Status)
+	}
+
+	return h.setDependencies(ctx, tasks)
+}
+
+func (h *Handler) GetProjectWorkflowHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	workflow, err := h.projectWorkflow(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// UpdateProjectWorkflowHandler replaces a project's workflow. It answers 409
+// when existing tasks sit in a state the new workflow drops.
+func (h *Handler) UpdateProjectWorkflowHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var workflow models.Workflow
+	if err := json.NewDecoder(r.Body).Decode(&workflow); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := workflow.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Projects.GetByID(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	stranded, err := h.Tasks.CountOutsideStates(ctx, id, workflow.States)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if stranded > 0 {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	err = h.Projects.SetWorkflow(ctx, id, workflow)
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err == repositories.ErrArchived {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Drop the stale copy and re-cache the project with its new workflow
+	go func() {
+		cacheCtx, cacheCancel := context.WithTimeout(context.Background(), h.CacheTimeout)
+		defer cacheCancel()
+
+		cache.DeleteProject(cacheCtx, id)
+
+		updatedProject, err := h.Projects.GetByID(cacheCtx, id)
+		if err == nil {
+			cache.SetProject(cacheCtx, *updatedProject)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+func (h *Handler) ListTaskTransitionsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+	defer cancel()
+
+	if _, err := h.Tasks.GetByID(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	transitions, err := h.Tasks.ListTransitions(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": transitions})
+}
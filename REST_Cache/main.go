@@ -1,50 +1,141 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
 	"task-manager/cache"
+	"task-manager/config"
 	"task-manager/db"
 	"task-manager/handlers"
+	"task-manager/purge"
+	"task-manager/reminders"
+	"task-manager/repositories"
 )
 
 func main() {
+	// Load config from env and the optional config file
+	cfg := config.MustLoad()
+	log.Printf("effective config:\n%s", cfg)
+
 	// Connect to MongoDB
-	if err := db.Connect(); err != nil {
+	if err := db.Connect(cfg.Mongo); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := db.CreateIndexes(); err != nil {
 		log.Fatal(err)
 	}
 
+	// Everything but the change feeds works without change streams
+	changeFeeds := true
+	if err := db.EnableChangeStreams(); err != nil {
+		log.Printf("warning: change feeds are disabled: %v", err)
+		changeFeeds = false
+	}
+
 	// Connect to Redis
-	if err := cache.Connect(); err != nil {
+	if err := cache.Connect(cfg.Redis, cfg.Cache); err != nil {
 		log.Fatal(err)
 	}
 
 	log.Println("MongoDB & Redis connected")
 
-	http.HandleFunc("/organizations", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			handlers.ListOrganizationsHandler(w, r)
-		case http.MethodPost:
-			handlers.CreateOrganizationHandler(w, r)
-		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
-		}
-	})
-	http.HandleFunc("/organizations/", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			handlers.GetOrganizationByIDHandler(w, r)
-		case http.MethodPut:
-			handlers.UpdateOrganizationHandler(w, r)
-		case http.MethodDelete:
-			handlers.DeleteOrganizationHandler(w, r)
-		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
-		}
-	})
-
-	log.Println("Server running on :8080")
-	http.ListenAndServe(":8080", nil)
+	// Jobs left running by a previous process will never finish
+	repos := repositories.NewMongo(db.Database)
+	if err := repos.Jobs.FailInterrupted(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+	go purge.Run(context.Background(), repos, cfg.Trash)
+
+	notifier, err := reminders.NewNotifier(cfg.Reminders, cfg.SMTP)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go reminders.Run(context.Background(), repos, cfg.Reminders, notifier)
+
+	h := handlers.New(repos, cfg)
+	h.ChangeFeedsDisabled = !changeFeeds
+
+	http.HandleFunc("GET /organizations", h.ListOrganizationsHandler)
+	http.HandleFunc("POST /organizations", h.CreateOrganizationHandler)
+	http.HandleFunc("GET /organizations/{id}", h.GetOrganizationByIDHandler)
+	http.HandleFunc("PUT /organizations/{id}", h.UpdateOrganizationHandler)
+	http.HandleFunc("PATCH /organizations/{id}", h.PatchOrganizationHandler)
+	http.HandleFunc("DELETE /organizations/{id}", h.DeleteOrganizationHandler)
+	http.HandleFunc("GET /organizations/{id}/stats", h.GetOrganizationStatsHandler)
+	http.HandleFunc("GET /organizations/{id}/changes", h.OrganizationChangesHandler)
+	http.HandleFunc("POST /organizations/{id}/archive", h.ArchiveOrganizationHandler)
+	http.HandleFunc("POST /organizations/{id}/unarchive", h.UnarchiveOrganizationHandler)
+	http.HandleFunc("POST /organizations/{id}/restore", h.RestoreOrganizationHandler)
+	http.HandleFunc("GET /organizations/{id}/trash", h.ListTrashHandler)
+	http.HandleFunc("GET /organizations/trash", h.ListDeletedOrganizationsHandler)
+
+	http.HandleFunc("GET /organizations/{orgId}/projects", h.ListProjectsHandler)
+	http.HandleFunc("POST /organizations/{orgId}/projects", h.CreateProjectHandler)
+	http.HandleFunc("GET /projects/{id}", h.GetProjectByIDHandler)
+	http.HandleFunc("PUT /projects/{id}", h.UpdateProjectHandler)
+	http.HandleFunc("PATCH /projects/{id}", h.PatchProjectHandler)
+	http.HandleFunc("DELETE /projects/{id}", h.DeleteProjectHandler)
+	http.HandleFunc("GET /projects/{id}/stats", h.GetProjectStatsHandler)
+	http.HandleFunc("GET /projects/{id}/changes", h.ProjectChangesHandler)
+	http.HandleFunc("GET /projects/{id}/workflow", h.GetProjectWorkflowHandler)
+	http.HandleFunc("PUT /projects/{id}/workflow", h.UpdateProjectWorkflowHandler)
+	http.HandleFunc("GET /projects/{id}/critical-path", h.ProjectCriticalPathHandler)
+	http.HandleFunc("GET /projects/{id}/fields", h.GetProjectFieldsHandler)
+	http.HandleFunc("PUT /projects/{id}/fields", h.UpdateProjectFieldsHandler)
+	http.HandleFunc("POST /projects/{id}/archive", h.ArchiveProjectHandler)
+	http.HandleFunc("POST /projects/{id}/unarchive", h.UnarchiveProjectHandler)
+	http.HandleFunc("POST /projects/{id}/restore", h.RestoreProjectHandler)
+
+	http.HandleFunc("GET /projects/{projectId}/tasks", h.ListTasksHandler)
+	http.HandleFunc("POST /projects/{projectId}/tasks", h.CreateTaskHandler)
+	http.HandleFunc("POST /projects/{projectId}/tasks/bulk", h.BulkTaskHandler)
+	http.HandleFunc("GET /tasks/{id}", h.GetTaskByIDHandler)
+	http.HandleFunc("PUT /tasks/{id}", h.UpdateTaskHandler)
+	http.HandleFunc("PATCH /tasks/{id}", h.PatchTaskHandler)
+	http.HandleFunc("DELETE /tasks/{id}", h.DeleteTaskHandler)
+	http.HandleFunc("GET /tasks/{id}/transitions", h.ListTaskTransitionsHandler)
+	http.HandleFunc("GET /tasks/{id}/activity", h.ListTaskActivityHandler)
+	http.HandleFunc("GET /tasks/{id}/timeline", h.TaskTimelineHandler)
+	http.HandleFunc("GET /tasks/{id}/notifications", h.ListTaskNotificationsHandler)
+	http.HandleFunc("GET /tasks/{id}/blockers", h.ListTaskBlockersHandler)
+	http.HandleFunc("PUT /tasks/{id}/blockers/{blockerId}", h.AddTaskBlockerHandler)
+	http.HandleFunc("DELETE /tasks/{id}/blockers/{blockerId}", h.RemoveTaskBlockerHandler)
+	http.HandleFunc("GET /tasks/{id}/comments", h.ListCommentsHandler)
+	http.HandleFunc("POST /tasks/{id}/comments", h.CreateCommentHandler)
+	http.HandleFunc("PUT /tasks/{id}/comments/{commentId}", h.UpdateCommentHandler)
+	http.HandleFunc("DELETE /tasks/{id}/comments/{commentId}", h.DeleteCommentHandler)
+	http.HandleFunc("POST /tasks/{id}/assign", h.AssignTaskHandler)
+	http.HandleFunc("POST /tasks/{id}/unassign", h.UnassignTaskHandler)
+	http.HandleFunc("POST /tasks/{id}/restore", h.RestoreTaskHandler)
+	http.HandleFunc("GET /users/{id}/tasks", h.ListUserTasksHandler)
+
+	http.HandleFunc("GET /organizations/{orgId}/views", h.ListViewsHandler)
+	http.HandleFunc("POST /organizations/{orgId}/views", h.CreateViewHandler)
+	http.HandleFunc("GET /views/{id}", h.GetViewByIDHandler)
+	http.HandleFunc("PUT /views/{id}", h.UpdateViewHandler)
+	http.HandleFunc("DELETE /views/{id}", h.DeleteViewHandler)
+
+	http.HandleFunc("GET /organizations/{orgId}/labels", h.ListLabelsHandler)
+	http.HandleFunc("POST /organizations/{orgId}/labels", h.CreateLabelHandler)
+	http.HandleFunc("GET /labels/{id}", h.GetLabelByIDHandler)
+	http.HandleFunc("PUT /labels/{id}", h.UpdateLabelHandler)
+	http.HandleFunc("DELETE /labels/{id}", h.DeleteLabelHandler)
+
+	http.HandleFunc("GET /jobs/{id}", h.GetJobByIDHandler)
+
+	http.HandleFunc("GET /search", h.SearchHandler)
+
+	server := &http.Server{
+		Addr:         cfg.HTTP.Addr,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
+	}
+
+	log.Println("Server running on " + cfg.HTTP.Addr)
+	log.Fatal(server.ListenAndServe())
 }
@@ -0,0 +1,81 @@
+// Package purge removes for good what has been in the trash for longer
+// than the retention period.
+package purge
+
+import (
+	"context"
+	"log"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/config"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// batchSize bounds how many organizations, projects and tasks each run
+// purges, the rest waits for the next run.
+const batchSize = 100
+
+// Run purges every cfg.PurgeInterval until ctx is done.
+func Run(ctx context.Context, repos repositories.Repositories, cfg config.Trash) {
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, cfg.PurgeInterval)
+		purged, err := Purge(runCtx, repos, time.Now().Add(-cfg.Retention))
+		cancel()
+		if err != nil {
+			log.Printf("purge: %v", err)
+		}
+		if purged > 0 {
+			log.Printf("purge: removed %d items from the trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes up to batchSize organizations, projects and tasks each that
+// were deleted before before, along with everything deleted with them, and
+// returns how many it removed. Each item is removed only while it is still
+// in the trash since it expired, so items restored meanwhile are skipped.
+// An organization is removed on its own, its projects stay in the trash as
+// items of their own which later runs remove one transaction each, so large
+// organizations never need one huge transaction.
+func Purge(ctx context.Context, repos repositories.Repositories, before time.Time) (int, error) {
+	items, err := repos.Trash.Expired(ctx, before, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, item := range items {
+		var err error
+		switch item.Type {
+		case models.TrashItemOrganization:
+			_, err = repos.Organizations.Purge(ctx, item.ID, item.DeletedAt)
+		case models.TrashItemProject:
+			_, err = repos.Projects.Purge(ctx, item.ID, item.DeletedAt)
+		case models.TrashItemTask:
+			err = repos.Tasks.Purge(ctx, item.ID, item.DeletedAt)
+		}
+
+		if err == mongo.ErrNoDocuments {
+			// Restored, or someone else purged it first
+			continue
+		}
+		if err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,96 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/config"
+)
+
+// Notifier delivers notifications.
+type Notifier interface {
+	Notify(ctx context.Context, notification models.Notification) error
+}
+
+// NewNotifier returns the notifier cfg names.
+func NewNotifier(cfg config.Reminders, smtpCfg config.SMTP) (Notifier, error) {
+	switch cfg.Notifier {
+	case "log":
+		return LogNotifier{}, nil
+	case "smtp":
+		return NewSMTPNotifier(smtpCfg), nil
+	}
+	return nil, fmt.Errorf("unknown notifier %q", cfg.Notifier)
+}
+
+// LogNotifier writes notifications to the log.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, notification models.Notification) error {
+	log.Printf("notification: %s", describe(notification))
+	return nil
+}
+
+// SMTPNotifier mails notifications to a fixed list of recipients.
+type SMTPNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewSMTPNotifier returns a notifier sending through the server at cfg.Addr.
+// PLAIN auth is only used over TLS or to localhost, which suits local SMTP
+// stand-ins such as MailHog or Mailpit.
+func NewSMTPNotifier(cfg config.SMTP) *SMTPNotifier {
+	n := &SMTPNotifier{addr: cfg.Addr, from: cfg.From, to: cfg.To}
+	if cfg.Username != "" {
+		host, _, _ := strings.Cut(cfg.Addr, ":")
+		n.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	return n
+}
+
+// Notify sends one mail. net/smtp takes no context, a stuck server holds
+// up the scheduler until the connection times out.
+func (n *SMTPNotifier) Notify(ctx context.Context, notification models.Notification) error {
+	subject := fmt.Sprintf("Task %q is overdue", notification.Title)
+	if notification.Type == models.NotificationReminder {
+		subject = fmt.Sprintf("Task %q is due %s", notification.Title, notification.DueAt.UTC().Format(time.RFC1123))
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", notification.At.UTC().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@task-manager>\r\n", strings.Trim(strings.ReplaceAll(notification.ID, ":", "."), "."))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n", describe(notification))
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return smtp.SendMail(n.addr, n.auth, n.from, n.to, []byte(msg.String()))
+}
+
+// describe sums a notification up in a sentence.
+func describe(notification models.Notification) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "task %s %q in project %s", notification.TaskID, notification.Title, notification.ProjectID)
+	if notification.AssignedTo != nil {
+		fmt.Fprintf(&s, ", assigned to %s,", *notification.AssignedTo)
+	}
+	due := notification.DueAt.UTC().Format(time.RFC3339)
+	if notification.Type == models.NotificationReminder {
+		fmt.Fprintf(&s, " is due at %s (reminder %s before)", due, notification.Reminder)
+	} else {
+		fmt.Fprintf(&s, " was due at %s and is overdue", due)
+	}
+	return s.String()
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// untrackedTaskFields change along with the fields they keep track of and
+// are left out of the activity.
+var untrackedTaskFields = map[string]bool{
+	"updatedAt":  true,
+	"assignedAt": true,
+	"notifyAt":   true,
+}
+
+// TaskActivity lists the changes patch makes to a task, given the task as
+// stored before, keyed by BSON field names. Fields set to the value they
+// hold already are left out. Documents like customFields are compared key
+// by key, so each changed key is an entry of its own named by its path.
+// Both backends record activity this way.
+func TaskActivity(before bson.M, patch Patch, at time.Time) []models.Activity {
+	taskID, _ := before["_id"].(string)
+	projectID, _ := before["projectId"].(string)
+
+	written := map[string]interface{}{}
+	for field, value := range patch.Set {
+		written[field] = value
+	}
+	for _, field := range patch.Unset {
+		written[field] = nil
+	}
+
+	changes := map[string]interface{}{}
+	for field, value := range written {
+		old, _ := fieldValue(before, field)
+		oldFields, oldIsDoc := documentFields(old)
+		newFields, newIsDoc := documentFields(value)
+		if !oldIsDoc && !newIsDoc {
+			changes[field] = value
+			continue
+		}
+
+		for key := range oldFields {
+			changes[field+"."+key] = nil
+		}
+		for key, value := range newFields {
+			changes[field+"."+key] = value
+		}
+	}
+
+	activity := []models.Activity{}
+	for field, value := range changes {
+		old, _ := fieldValue(before, field)
+		if untrackedTaskFields[field] || sameValue(old, value) {
+			continue
+		}
+		activity = append(activity, models.Activity{
+			TaskID:    taskID,
+			ProjectID: projectID,
+			Actor:     patch.Actor,
+			Field:     field,
+			Old:       old,
+			New:       value,
+			At:        at,
+		})
+	}
+
+	// IDs follow the field order, which keeps one write's changes in order
+	sort.Slice(activity, func(i, j int) bool {
+		return activity[i].Field < activity[j].Field
+	})
+	for i := range activity {
+		activity[i].ID = primitive.NewObjectID().Hex()
+	}
+
+	return activity
+}
+
+// fieldValue looks up a dotted path like customFields.estimate in a
+// document, and returns false when it is not there.
+func fieldValue(doc bson.M, path string) (interface{}, bool) {
+	var value interface{} = doc
+	for _, key := range strings.Split(path, ".") {
+		fields, ok := documentFields(value)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = fields[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// documentFields returns the fields of an embedded document in any of the
+// forms it comes in, and false for other values.
+func documentFields(value interface{}) (map[string]interface{}, bool) {
+	switch value := value.(type) {
+	case bson.M:
+		return value, true
+	case map[string]interface{}:
+		return value, true
+	case primitive.D:
+		fields := make(map[string]interface{}, len(value))
+		for _, e := range value {
+			fields[e.Key] = e.Value
+		}
+		return fields, true
+	}
+	return nil, false
+}
+
+// sameValue compares values as BSON, so a time.Time equals the
+// primitive.DateTime it was stored as, and a []string the primitive.A.
+func sameValue(a interface{}, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	typeA, dataA, err := bson.MarshalValue(a)
+	if err != nil {
+		return false
+	}
+	typeB, dataB, err := bson.MarshalValue(b)
+	if err != nil {
+		return false
+	}
+
+	return typeA == typeB && bytes.Equal(dataA, dataB)
+}
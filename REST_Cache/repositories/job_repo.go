@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoJobRepo struct {
+	db *mongo.Database
+}
+
+func (r *mongoJobRepo) GetByID(ctx context.Context, id string) (*models.Job, error) {
+	var job models.Job
+
+	err := r.db.
+		Collection("jobs").
+		FindOne(ctx, bson.M{"_id": id}).
+		Decode(&job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (r *mongoJobRepo) Create(ctx context.Context, job models.Job) error {
+	_, err := r.db.
+		Collection("jobs").
+		InsertOne(ctx, job)
+	return err
+}
+
+func (r *mongoJobRepo) Update(ctx context.Context, id string, update bson.M) error {
+	res, err := r.db.
+		Collection("jobs").
+		UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoJobRepo) FailInterrupted(ctx context.Context) error {
+	now := time.Now()
+	_, err := r.db.
+		Collection("jobs").
+		UpdateMany(ctx,
+			bson.M{"status": bson.M{"$in": []string{models.JobStatusPending, models.JobStatusRunning}}},
+			bson.M{"$set": bson.M{
+				"status":     models.JobStatusFailed,
+				"error":      "interrupted by server restart",
+				"finishedAt": now,
+			}},
+		)
+	return err
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// lifecycle is a state that cascades from organizations to projects and
+// tasks: archival or deletion. at holds since when a document is in the
+// state, with the organization or project it cascaded from, if any.
+type lifecycle struct {
+	at   string
+	with string
+}
+
+var (
+	archival = lifecycle{at: "archivedAt", with: "archivedWith"}
+	deletion = lifecycle{at: "deletedAt", with: "deletedWith"}
+)
+
+// set puts a document into the state, on its own when with is empty.
+func (l lifecycle) set(at time.Time, with string) bson.M {
+	set := bson.M{l.at: at}
+	if with != "" {
+		set[l.with] = with
+	}
+	return bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+}
+
+// unset takes a document out of the state.
+func (l lifecycle) unset() bson.M {
+	return bson.M{"$unset": bson.M{l.at: "", l.with: ""}, "$inc": bson.M{"version": 1}}
+}
+
+// cascade puts the documents matching filter that are not in the state yet
+// into it on behalf of root, and returns their IDs.
+func (l lifecycle) cascade(ctx context.Context, collection *mongo.Collection, filter bson.M, at time.Time, root string) ([]string, error) {
+	filter[l.at] = nil
+	values, err := collection.Distinct(ctx, "_id", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := stringValues(values)
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	_, err = collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, l.set(at, root))
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// revert takes the projects and tasks that the cascade from root put into
+// the state out of it again.
+func (l lifecycle) revert(ctx context.Context, database *mongo.Database, root string) (*CascadeResult, error) {
+	result := &CascadeResult{}
+
+	for collection, ids := range map[string]*[]string{
+		"projects": &result.ProjectIDs,
+		"tasks":    &result.TaskIDs,
+	} {
+		values, err := database.
+			Collection(collection).
+			Distinct(ctx, "_id", bson.M{l.with: root})
+		if err != nil {
+			return nil, err
+		}
+		*ids = stringValues(values)
+
+		if len(*ids) == 0 {
+			continue
+		}
+		_, err = database.
+			Collection(collection).
+			UpdateMany(ctx, bson.M{"_id": bson.M{"$in": *ids}}, l.unset())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// cascadeOrganization puts the organization's projects and their tasks into
+// the state.
+func (l lifecycle) cascadeOrganization(ctx context.Context, database *mongo.Database, orgID string, at time.Time) (*CascadeResult, error) {
+	projects := &mongoProjectRepo{db: database}
+	projectIDs, err := projects.ListIDs(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CascadeResult{}
+	result.ProjectIDs, err = l.cascade(ctx, database.Collection("projects"), bson.M{"organizationId": orgID}, at, orgID)
+	if err != nil {
+		return nil, err
+	}
+	result.TaskIDs, err = l.cascade(ctx, database.Collection("tasks"), bson.M{"projectId": bson.M{"$in": projectIDs}}, at, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// cascadeProject puts the project's tasks into the state.
+func (l lifecycle) cascadeProject(ctx context.Context, database *mongo.Database, projectID string, at time.Time) (*CascadeResult, error) {
+	taskIDs, err := l.cascade(ctx, database.Collection("tasks"), bson.M{"projectId": projectID}, at, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CascadeResult{ProjectIDs: []string{}, TaskIDs: taskIDs}, nil
+}
+
+// lifecycleState is what the lifecycle decisions need of a document,
+// whether or not it is in the trash.
+type lifecycleState struct {
+	Version        int64      `bson:"version"`
+	ArchivedAt     *time.Time `bson:"archivedAt"`
+	DeletedAt      *time.Time `bson:"deletedAt"`
+	OrganizationID string     `bson:"organizationId"`
+	ProjectID      string     `bson:"projectId"`
+}
+
+func readLifecycle(ctx context.Context, collection *mongo.Collection, id string) (*lifecycleState, error) {
+	var state lifecycleState
+
+	err := collection.
+		FindOne(ctx, bson.M{"_id": id}).
+		Decode(&state)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// inTransaction runs fn in a transaction, which needs a replica set.
+func inTransaction(
+	ctx context.Context,
+	database *mongo.Database,
+	fn func(sc mongo.SessionContext) (*CascadeResult, error),
+) (*CascadeResult, error) {
+
+	session, err := database.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	res, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return fn(sc)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*CascadeResult), nil
+}
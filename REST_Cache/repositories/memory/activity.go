@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	models "task-manager/collections"
+)
+
+type activityRepo struct {
+	*store
+}
+
+func (r *activityRepo) List(ctx context.Context, taskID string, p int64, limit int64) ([]models.Activity, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	activity := []models.Activity{}
+	for _, change := range r.activity {
+		if change.TaskID != taskID {
+			continue
+		}
+		change, err := clone(change)
+		if err != nil {
+			return nil, 0, err
+		}
+		activity = append(activity, change)
+	}
+
+	sort.Slice(activity, func(i, j int) bool {
+		return earlier(activity[i].At, activity[i].ID, activity[j].At, activity[j].ID)
+	})
+
+	return cut(activity, p, limit), int64(len(activity)), nil
+}
+
+func (r *activityRepo) Timeline(ctx context.Context, taskID string, p int64, limit int64) ([]models.TimelineItem, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := []models.TimelineItem{}
+	for _, change := range r.activity {
+		if change.TaskID != taskID {
+			continue
+		}
+		change, err := clone(change)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, models.TimelineItem{Type: models.TimelineActivity, At: change.At, Activity: &change})
+	}
+	for _, comment := range r.comments {
+		if comment.TaskID != taskID {
+			continue
+		}
+		comment, err := clone(comment)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, models.TimelineItem{Type: models.TimelineComment, At: comment.CreatedAt, Comment: &comment})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return earlier(items[i].At, timelineID(items[i]), items[j].At, timelineID(items[j]))
+	})
+
+	return cut(items, p, limit), int64(len(items)), nil
+}
+
+func timelineID(item models.TimelineItem) string {
+	if item.Activity != nil {
+		return item.Activity.ID
+	}
+	return item.Comment.ID
+}
+
+// deleteHistory removes the transitions, activity, comments, notifications
+// and dependencies of the tasks that match. The caller must hold the write
+// lock.
+func (s *store) deleteHistory(match func(taskID string, projectID string) bool) {
+	transitions := s.transitions[:0]
+	for _, transition := range s.transitions {
+		if !match(transition.TaskID, transition.ProjectID) {
+			transitions = append(transitions, transition)
+		}
+	}
+	s.transitions = transitions
+
+	activity := s.activity[:0]
+	for _, change := range s.activity {
+		if !match(change.TaskID, change.ProjectID) {
+			activity = append(activity, change)
+		}
+	}
+	s.activity = activity
+
+	for id, comment := range s.comments {
+		if match(comment.TaskID, comment.ProjectID) {
+			delete(s.comments, id)
+		}
+	}
+
+	for id, notification := range s.notifications {
+		if match(notification.TaskID, notification.ProjectID) {
+			delete(s.notifications, id)
+		}
+	}
+
+	for id, dependency := range s.dependencies {
+		if match(dependency.TaskID, dependency.ProjectID) || match(dependency.BlockerID, dependency.BlockerProjectID) {
+			delete(s.dependencies, id)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type labelRepo struct {
+	*store
+}
+
+func (r *labelRepo) GetByID(ctx context.Context, id string) (*models.Label, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	label, ok := r.labels[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	label, err := clone(label)
+	if err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
+
+func (r *labelRepo) ListByOrganization(ctx context.Context, orgID string) ([]models.Label, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	labels := []models.Label{}
+	for _, label := range r.labels {
+		if label.OrganizationID != orgID {
+			continue
+		}
+		label, err := clone(label)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+
+	return labels, nil
+}
+
+func (r *labelRepo) Create(ctx context.Context, label models.Label) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.labels[label.ID]; ok {
+		return duplicateID(label.ID)
+	}
+	if r.nameTaken(label) {
+		return &repositories.ConflictError{Field: "name"}
+	}
+
+	label, err := clone(label)
+	if err != nil {
+		return err
+	}
+	r.labels[label.ID] = label
+	return nil
+}
+
+func (r *labelRepo) Update(ctx context.Context, id string, update bson.M) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	label, ok := r.labels[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+
+	label, err := applySet(label, update)
+	if err != nil {
+		return err
+	}
+	if r.nameTaken(label) {
+		return &repositories.ConflictError{Field: "name"}
+	}
+
+	r.labels[id] = label
+	return nil
+}
+
+func (r *labelRepo) Delete(ctx context.Context, id string) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.labels[id]; !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	delete(r.labels, id)
+
+	result := &repositories.CascadeResult{ProjectIDs: []string{}, TaskIDs: []string{}}
+	now := time.Now()
+	for taskID, task := range r.tasks {
+		if !slices.Contains(task.Labels, id) {
+			continue
+		}
+		task.Labels = slices.DeleteFunc(slices.Clone(task.Labels), func(label string) bool {
+			return label == id
+		})
+		task.UpdatedAt = *timePtr(now)
+		task.Version++
+		r.tasks[taskID] = task
+		r.recordTask(models.ChangeUpdate, task)
+		result.TaskIDs = append(result.TaskIDs, taskID)
+	}
+
+	return result, nil
+}
+
+// nameTaken tells whether another label of the organization has the
+// label's name regardless of case, like the unique index in MongoDB. The
+// caller must hold the lock.
+func (r *labelRepo) nameTaken(label models.Label) bool {
+	for _, other := range r.labels {
+		if other.ID != label.ID && other.OrganizationID == label.OrganizationID && strings.EqualFold(other.Name, label.Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// lifecycle is a state that cascades from organizations to projects and
+// tasks, like in the Mongo repositories.
+type lifecycle int
+
+const (
+	archival lifecycle = iota
+	deletion
+)
+
+// project returns the project's fields for the state: since when it is in
+// it, and the organization it cascaded from.
+func (l lifecycle) project(project *models.Project) (**time.Time, *string) {
+	if l == archival {
+		return &project.ArchivedAt, &project.ArchivedWith
+	}
+	return &project.DeletedAt, &project.DeletedWith
+}
+
+func (l lifecycle) task(task *models.Task) (**time.Time, *string) {
+	if l == archival {
+		return &task.ArchivedAt, &task.ArchivedWith
+	}
+	return &task.DeletedAt, &task.DeletedWith
+}
+
+// cascadeOrganization puts the organization's projects and their tasks that
+// are not in the state yet into it on behalf of the organization. The
+// caller must hold the write lock.
+func (s *store) cascadeOrganization(l lifecycle, orgID string, at time.Time) *repositories.CascadeResult {
+	result := &repositories.CascadeResult{ProjectIDs: []string{}}
+
+	inProjects := map[string]bool{}
+	for id, project := range s.projects {
+		if project.OrganizationID != orgID {
+			continue
+		}
+		inProjects[id] = true
+
+		since, with := l.project(&project)
+		if *since != nil {
+			continue
+		}
+		*since, *with = timePtr(at), orgID
+		project.Version++
+		s.projects[id] = project
+		s.recordProject(models.ChangeUpdate, project)
+		result.ProjectIDs = append(result.ProjectIDs, id)
+	}
+
+	result.TaskIDs = s.cascadeTasks(l, inProjects, at, orgID)
+	return result
+}
+
+// cascadeProject puts the project's tasks into the state. The caller must
+// hold the write lock.
+func (s *store) cascadeProject(l lifecycle, projectID string, at time.Time) *repositories.CascadeResult {
+	return &repositories.CascadeResult{
+		ProjectIDs: []string{},
+		TaskIDs:    s.cascadeTasks(l, map[string]bool{projectID: true}, at, projectID),
+	}
+}
+
+func (s *store) cascadeTasks(l lifecycle, inProjects map[string]bool, at time.Time, root string) []string {
+	ids := []string{}
+	for id, task := range s.tasks {
+		if !inProjects[task.ProjectID] {
+			continue
+		}
+
+		since, with := l.task(&task)
+		if *since != nil {
+			continue
+		}
+		*since, *with = timePtr(at), root
+		task.Version++
+		s.tasks[id] = task
+		s.recordTask(models.ChangeUpdate, task)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// revert takes the projects and tasks that the cascade from root put into
+// the state out of it again. The caller must hold the write lock.
+func (s *store) revert(l lifecycle, root string) *repositories.CascadeResult {
+	result := &repositories.CascadeResult{ProjectIDs: []string{}, TaskIDs: []string{}}
+
+	for id, project := range s.projects {
+		since, with := l.project(&project)
+		if *with != root {
+			continue
+		}
+		*since, *with = nil, ""
+		project.Version++
+		s.projects[id] = project
+		s.recordProject(models.ChangeUpdate, project)
+		result.ProjectIDs = append(result.ProjectIDs, id)
+	}
+
+	for id, task := range s.tasks {
+		since, with := l.task(&task)
+		if *with != root {
+			continue
+		}
+		*since, *with = nil, ""
+		task.Version++
+		s.tasks[id] = task
+		s.recordTask(models.ChangeUpdate, task)
+		result.TaskIDs = append(result.TaskIDs, id)
+	}
+
+	return result
+}
+
+// writable tells why a document cannot be written: it is deleted, which
+// reads as missing, or archived.
+func writable(archivedAt, deletedAt *time.Time) error {
+	if deletedAt != nil {
+		return mongo.ErrNoDocuments
+	}
+	if archivedAt != nil {
+		return repositories.ErrArchived
+	}
+	return nil
+}
+
+// timePtr returns t the way it comes back from BSON, like the stored
+// documents keep their times.
+func timePtr(t time.Time) *time.Time {
+	t = t.Truncate(time.Millisecond).UTC()
+	return &t
+}
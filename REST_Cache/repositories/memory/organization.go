@@ -0,0 +1,278 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type organizationRepo struct {
+	*store
+}
+
+func (r *organizationRepo) GetByID(ctx context.Context, id string) (*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	org, ok := r.organizations[id]
+	if !ok || org.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	org, err := clone(org)
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepo) List(ctx context.Context, p int64, limit int64, status *string) ([]models.Organization, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orgs := []models.Organization{}
+	for _, org := range r.organizations {
+		if org.DeletedAt != nil || (status != nil && org.Status != *status) {
+			continue
+		}
+		org, err := clone(org)
+		if err != nil {
+			return nil, 0, err
+		}
+		orgs = append(orgs, org)
+	}
+
+	total := int64(len(orgs))
+	orgs = page(orgs, func(org models.Organization) (time.Time, string) {
+		return org.CreatedAt, org.ID
+	}, p, limit)
+
+	return orgs, total, nil
+}
+
+func (r *organizationRepo) ListKeyset(ctx context.Context, query repositories.KeysetQuery) ([]models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orgs := []models.Organization{}
+	for _, org := range r.organizations {
+		if org.DeletedAt != nil || (query.Status != nil && org.Status != *query.Status) {
+			continue
+		}
+		if query.After != nil && !olderThan(org.CreatedAt, org.ID, *query.After) {
+			continue
+		}
+		if query.Before != nil && !newerThan(org.CreatedAt, org.ID, *query.Before) {
+			continue
+		}
+		org, err := clone(org)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+
+	key := func(org models.Organization) (time.Time, string) {
+		return org.CreatedAt, org.ID
+	}
+	if query.Before != nil {
+		// The page closest to Before is the oldest end of what is newer
+		orgs = page(orgs, key, 1, int64(len(orgs)))
+		if int64(len(orgs)) > query.Limit {
+			orgs = orgs[int64(len(orgs))-query.Limit:]
+		}
+		return orgs, nil
+	}
+
+	return page(orgs, key, 1, query.Limit), nil
+}
+
+func (r *organizationRepo) Count(ctx context.Context, status *string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, org := range r.organizations {
+		if org.DeletedAt == nil && (status == nil || org.Status == *status) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *organizationRepo) Create(ctx context.Context, org models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.organizations[org.ID]; ok {
+		return duplicateID(org.ID)
+	}
+	if r.nameTaken(org) {
+		return &repositories.ConflictError{Field: "name"}
+	}
+
+	org, err := clone(org)
+	if err != nil {
+		return err
+	}
+	r.organizations[org.ID] = org
+	return nil
+}
+
+func (r *organizationRepo) Update(ctx context.Context, id string, update bson.M) error {
+	return r.Patch(ctx, id, repositories.Patch{Set: update})
+}
+
+func (r *organizationRepo) Patch(ctx context.Context, id string, patch repositories.Patch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, ok := r.organizations[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	if err := writable(org.ArchivedAt, org.DeletedAt); err != nil {
+		return err
+	}
+
+	if patch.IfVersion != nil && org.Version != *patch.IfVersion {
+		return repositories.ErrVersionConflict
+	}
+	org, err := applyPatch(org, patch)
+	if err != nil {
+		return err
+	}
+	org.Version++
+	if r.nameTaken(org) {
+		return &repositories.ConflictError{Field: "name"}
+	}
+	r.organizations[id] = org
+	return nil
+}
+
+// nameTaken tells whether another organization has org's name regardless
+// of case, like the unique index in MongoDB. The caller must hold the lock.
+func (r *organizationRepo) nameTaken(org models.Organization) bool {
+	for _, other := range r.organizations {
+		if other.ID != org.ID && strings.EqualFold(other.Name, org.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *organizationRepo) Archive(ctx context.Context, id string, at time.Time) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, ok := r.organizations[id]
+	if !ok || org.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	if org.ArchivedAt != nil {
+		return nil, repositories.ErrArchived
+	}
+
+	org.Status = models.OrganizationStatusArchived
+	org.ArchivedAt = timePtr(at)
+	org.UpdatedAt = at
+	org.Version++
+	r.organizations[id] = org
+
+	return r.cascadeOrganization(archival, id, at), nil
+}
+
+func (r *organizationRepo) Unarchive(ctx context.Context, id string) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, ok := r.organizations[id]
+	if !ok || org.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	if org.ArchivedAt == nil {
+		return nil, repositories.ErrNotArchived
+	}
+
+	org.Status = models.OrganizationStatusActive
+	org.ArchivedAt = nil
+	org.UpdatedAt = time.Now()
+	org.Version++
+	r.organizations[id] = org
+
+	return r.revert(archival, id), nil
+}
+
+func (r *organizationRepo) SoftDelete(ctx context.Context, id string, at time.Time) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, ok := r.organizations[id]
+	if !ok || org.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	org.DeletedAt = timePtr(at)
+	org.Version++
+	r.organizations[id] = org
+
+	return r.cascadeOrganization(deletion, id, at), nil
+}
+
+func (r *organizationRepo) Restore(ctx context.Context, id string) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, ok := r.organizations[id]
+	if !ok || org.DeletedAt == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	org.DeletedAt = nil
+	org.Version++
+	r.organizations[id] = org
+
+	return r.revert(deletion, id), nil
+}
+
+func (r *organizationRepo) Purge(ctx context.Context, id string, deletedAt time.Time) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, ok := r.organizations[id]
+	if !ok || org.DeletedAt == nil || !org.DeletedAt.Equal(deletedAt) {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	result := &repositories.CascadeResult{ProjectIDs: []string{}, TaskIDs: []string{}}
+	for projectID, project := range r.projects {
+		if project.OrganizationID != id {
+			continue
+		}
+		result.ProjectIDs = append(result.ProjectIDs, projectID)
+		if project.DeletedWith == id {
+			project.DeletedWith = ""
+			r.projects[projectID] = project
+		}
+	}
+	for viewID, view := range r.views {
+		if view.OrganizationID == id {
+			delete(r.views, viewID)
+		}
+	}
+	for labelID, label := range r.labels {
+		if label.OrganizationID == id {
+			delete(r.labels, labelID)
+		}
+	}
+	delete(r.organizations, id)
+
+	return result, nil
+}
@@ -0,0 +1,304 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type projectRepo struct {
+	*store
+}
+
+func (r *projectRepo) GetByID(ctx context.Context, id string) (*models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[id]
+	if !ok || project.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	project, err := clone(project)
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *projectRepo) List(ctx context.Context, orgID string, p int64, limit int64, status *string) ([]models.Project, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	projects := []models.Project{}
+	for _, project := range r.projects {
+		if project.OrganizationID != orgID || project.DeletedAt != nil {
+			continue
+		}
+		if status != nil && project.Status != *status {
+			continue
+		}
+		project, err := clone(project)
+		if err != nil {
+			return nil, 0, err
+		}
+		projects = append(projects, project)
+	}
+
+	total := int64(len(projects))
+	projects = page(projects, func(project models.Project) (time.Time, string) {
+		return project.CreatedAt, project.ID
+	}, p, limit)
+
+	return projects, total, nil
+}
+
+func (r *projectRepo) ListIDs(ctx context.Context, orgID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := []string{}
+	for _, project := range r.projects {
+		if project.OrganizationID == orgID {
+			ids = append(ids, project.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+func (r *projectRepo) Create(ctx context.Context, project models.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.projects[project.ID]; ok {
+		return duplicateID(project.ID)
+	}
+	if r.nameTaken(project) {
+		return &repositories.ConflictError{Field: "name"}
+	}
+
+	project, err := clone(project)
+	if err != nil {
+		return err
+	}
+	r.projects[project.ID] = project
+	r.recordProject(models.ChangeInsert, project)
+	return nil
+}
+
+func (r *projectRepo) Update(ctx context.Context, id string, update bson.M) error {
+	return r.Patch(ctx, id, repositories.Patch{Set: update})
+}
+
+func (r *projectRepo) Patch(ctx context.Context, id string, patch repositories.Patch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.projects[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	if err := writable(project.ArchivedAt, project.DeletedAt); err != nil {
+		return err
+	}
+
+	if patch.IfVersion != nil && project.Version != *patch.IfVersion {
+		return repositories.ErrVersionConflict
+	}
+	project, err := applyPatch(project, patch)
+	if err != nil {
+		return err
+	}
+	project.Version++
+	if r.nameTaken(project) {
+		return &repositories.ConflictError{Field: "name"}
+	}
+	r.projects[id] = project
+	r.recordProject(models.ChangeUpdate, project)
+	return nil
+}
+
+// nameTaken tells whether another project of the organization has the
+// project's name regardless of case, like the unique index in MongoDB. The
+// caller must hold the lock.
+func (r *projectRepo) nameTaken(project models.Project) bool {
+	for _, other := range r.projects {
+		if other.ID != project.ID && other.OrganizationID == project.OrganizationID &&
+			strings.EqualFold(other.Name, project.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *projectRepo) SetWorkflow(ctx context.Context, id string, workflow models.Workflow) error {
+	return r.Update(ctx, id, bson.M{
+		"workflow":  workflow,
+		"updatedAt": time.Now(),
+	})
+}
+
+func (r *projectRepo) SetCustomFields(ctx context.Context, id string, fields []models.CustomField) error {
+	return r.Update(ctx, id, bson.M{
+		"customFields": fields,
+		"updatedAt":    time.Now(),
+	})
+}
+
+func (r *projectRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.projects[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+
+	delete(r.projects, id)
+	r.recordProject(models.ChangeDelete, project)
+	return nil
+}
+
+func (r *projectRepo) Archive(ctx context.Context, id string, at time.Time) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.projects[id]
+	if !ok || project.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	if project.ArchivedAt != nil {
+		return nil, repositories.ErrArchived
+	}
+
+	project.ArchivedAt = timePtr(at)
+	project.UpdatedAt = at
+	project.Version++
+	r.projects[id] = project
+	r.recordProject(models.ChangeUpdate, project)
+
+	return r.cascadeProject(archival, id, at), nil
+}
+
+func (r *projectRepo) Unarchive(ctx context.Context, id string) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.projects[id]
+	if !ok || project.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	if project.ArchivedAt == nil {
+		return nil, repositories.ErrNotArchived
+	}
+	if org, ok := r.organizations[project.OrganizationID]; ok && org.ArchivedAt != nil {
+		return nil, repositories.ErrParentArchived
+	}
+
+	project.ArchivedAt, project.ArchivedWith = nil, ""
+	project.UpdatedAt = time.Now()
+	project.Version++
+	r.projects[id] = project
+	r.recordProject(models.ChangeUpdate, project)
+
+	return r.revert(archival, id), nil
+}
+
+func (r *projectRepo) SoftDelete(ctx context.Context, id string, at time.Time) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.projects[id]
+	if !ok || project.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	project.DeletedAt = timePtr(at)
+	project.Version++
+	r.projects[id] = project
+	r.recordProject(models.ChangeUpdate, project)
+
+	return r.cascadeProject(deletion, id, at), nil
+}
+
+func (r *projectRepo) Restore(ctx context.Context, id string) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.projects[id]
+	if !ok || project.DeletedAt == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	// An organization that is gone has been purged
+	if org, ok := r.organizations[project.OrganizationID]; !ok || org.DeletedAt != nil {
+		return nil, repositories.ErrParentDeleted
+	}
+
+	project.DeletedAt, project.DeletedWith = nil, ""
+	project.Version++
+	r.projects[id] = project
+	r.recordProject(models.ChangeUpdate, project)
+
+	return r.revert(deletion, id), nil
+}
+
+func (r *projectRepo) DeleteCascade(ctx context.Context, id string) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.deleteProjects([]string{id}), nil
+}
+
+func (r *projectRepo) Purge(ctx context.Context, id string, deletedAt time.Time) (*repositories.CascadeResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.projects[id]
+	if !ok || project.DeletedAt == nil || !project.DeletedAt.Equal(deletedAt) {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return r.deleteProjects([]string{id}), nil
+}
+
+// deleteProjects removes the projects with their tasks and what is recorded
+// about them. The caller must hold the write lock.
+func (s *store) deleteProjects(projectIDs []string) *repositories.CascadeResult {
+	result := &repositories.CascadeResult{ProjectIDs: []string{}, TaskIDs: []string{}}
+
+	// Tasks of a project that is already gone are still removed
+	inProjects := map[string]bool{}
+	for _, id := range projectIDs {
+		inProjects[id] = true
+	}
+
+	// Tasks go first, the change feed finds their organization by project
+	for id, task := range s.tasks {
+		if inProjects[task.ProjectID] {
+			result.TaskIDs = append(result.TaskIDs, id)
+			delete(s.tasks, id)
+			s.recordTask(models.ChangeDelete, task)
+		}
+	}
+
+	for _, id := range projectIDs {
+		if project, ok := s.projects[id]; ok {
+			result.ProjectIDs = append(result.ProjectIDs, id)
+			delete(s.projects, id)
+			s.recordProject(models.ChangeDelete, project)
+		}
+	}
+
+	s.deleteHistory(func(taskID string, projectID string) bool {
+		return inProjects[projectID]
+	})
+
+	return result
+}
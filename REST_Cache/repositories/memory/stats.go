@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type statsRepo struct {
+	*store
+}
+
+func (r *statsRepo) Project(ctx context.Context, projectID string) (*models.ProjectStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[projectID]
+	if !ok || project.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	now := time.Now()
+	stats := repositories.BuildProjectStats(project, r.taskGroups(project, now), now)
+	return &stats, nil
+}
+
+func (r *statsRepo) Organization(ctx context.Context, orgID string) (*models.OrganizationStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	projects := []models.ProjectStats{}
+	for _, project := range r.projects {
+		if project.OrganizationID == orgID && project.DeletedAt == nil {
+			projects = append(projects, repositories.BuildProjectStats(project, r.taskGroups(project, now), now))
+		}
+	}
+
+	stats := repositories.BuildOrganizationStats(orgID, projects, now)
+	return &stats, nil
+}
+
+// taskGroups groups a project's tasks like the Mongo pipeline does. The
+// caller must hold the lock.
+func (r *statsRepo) taskGroups(project models.Project, now time.Time) []repositories.TaskGroup {
+	type key struct {
+		status, priority, assignedTo string
+		assigned, overdue            bool
+	}
+
+	workflow := project.TaskWorkflow()
+	counts := map[key]int64{}
+	for _, task := range r.tasks {
+		if task.ProjectID != project.ID || task.DeletedAt != nil {
+			continue
+		}
+		k := key{
+			status:   task.Status,
+			priority: task.Priority,
+			overdue:  repositories.IsOverdue(workflow, task.Status, task.DueAt, now),
+		}
+		if task.AssignedTo != nil {
+			k.assignedTo, k.assigned = *task.AssignedTo, true
+		}
+		counts[k]++
+	}
+
+	groups := make([]repositories.TaskGroup, 0, len(counts))
+	for k, n := range counts {
+		g := repositories.TaskGroup{Status: k.status, Priority: k.priority, Overdue: k.overdue, Count: n}
+		if k.assigned {
+			assignedTo := k.assignedTo
+			g.AssignedTo = &assignedTo
+		}
+		groups = append(groups, g)
+	}
+
+	return groups
+}
@@ -0,0 +1,438 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type taskRepo struct {
+	*store
+}
+
+func (r *taskRepo) GetByID(ctx context.Context, id string) (*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.get(id)
+}
+
+// get returns a copy of the task. The caller must hold the lock.
+func (r *taskRepo) get(id string) (*models.Task, error) {
+	task, ok := r.tasks[id]
+	if !ok || task.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	task, err := clone(task)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *taskRepo) ListByProject(
+	ctx context.Context,
+	projectID string,
+	query repositories.TaskQuery,
+	p int64,
+	limit int64,
+) ([]models.Task, int64, error) {
+
+	if err := query.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	return r.list(func(task models.Task) bool {
+		return task.ProjectID == projectID && task.DeletedAt == nil && matchTaskQuery(task, query)
+	}, query, p, limit)
+}
+
+func (r *taskRepo) ListByAssignee(
+	ctx context.Context,
+	userID string,
+	p int64,
+	limit int64,
+	status *string,
+) ([]models.Task, int64, error) {
+
+	return r.list(func(task models.Task) bool {
+		return task.AssignedTo != nil && *task.AssignedTo == userID && task.DeletedAt == nil &&
+			(status == nil || task.Status == *status)
+	}, repositories.TaskQuery{}, p, limit)
+}
+
+func (r *taskRepo) list(match func(models.Task) bool, query repositories.TaskQuery, p int64, limit int64) ([]models.Task, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []models.Task{}
+	for _, task := range r.tasks {
+		if !match(task) {
+			continue
+		}
+		task, err := clone(task)
+		if err != nil {
+			return nil, 0, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	sortTasks(tasks, query)
+
+	return cut(tasks, p, limit), int64(len(tasks)), nil
+}
+
+func (r *taskRepo) Create(ctx context.Context, task models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tasks[task.ID]; ok {
+		return duplicateID(task.ID)
+	}
+
+	task, err := clone(task)
+	if err != nil {
+		return err
+	}
+	r.tasks[task.ID] = task
+	r.recordTask(models.ChangeInsert, task)
+	return nil
+}
+
+func (r *taskRepo) Update(ctx context.Context, id string, update bson.M) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.update(id, update)
+}
+
+// update applies a $set to the task. The caller must hold the write lock.
+func (r *taskRepo) update(id string, update bson.M) error {
+	return r.patch(id, repositories.Patch{Set: update})
+}
+
+func (r *taskRepo) patch(id string, patch repositories.Patch) error {
+	task, ok := r.tasks[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	if err := writable(task.ArchivedAt, task.DeletedAt); err != nil {
+		return err
+	}
+
+	if patch.IfVersion != nil && task.Version != *patch.IfVersion {
+		return repositories.ErrVersionConflict
+	}
+
+	before, err := document(task)
+	if err != nil {
+		return err
+	}
+	task, err = applyPatch(task, patch)
+	if err != nil {
+		return err
+	}
+	task.Version++
+	r.tasks[id] = task
+	r.recordTask(models.ChangeUpdate, task)
+
+	for _, activity := range repositories.TaskActivity(before, patch, time.Now()) {
+		activity, err := clone(activity)
+		if err != nil {
+			return err
+		}
+		r.activity = append(r.activity, activity)
+	}
+	return nil
+}
+
+func (r *taskRepo) Assign(ctx context.Context, id string, userID string, actor string) (*models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	err := r.patch(id, repositories.Patch{
+		Set: bson.M{
+			"assignedTo": userID,
+			"assignedAt": now,
+			"updatedAt":  now,
+		},
+		Actor: actor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.get(id)
+}
+
+func (r *taskRepo) Unassign(ctx context.Context, id string, actor string) (*models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.patch(id, repositories.Patch{
+		Set:   bson.M{"updatedAt": time.Now()},
+		Unset: []string{"assignedTo", "assignedAt"},
+		Actor: actor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.get(id)
+}
+
+func (r *taskRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+
+	delete(r.tasks, id)
+	r.recordTask(models.ChangeDelete, task)
+
+	r.deleteHistory(func(taskID string, projectID string) bool {
+		return taskID == id
+	})
+	return nil
+}
+
+func (r *taskRepo) Purge(ctx context.Context, id string, deletedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok || task.DeletedAt == nil || !task.DeletedAt.Equal(deletedAt) {
+		return mongo.ErrNoDocuments
+	}
+
+	delete(r.tasks, id)
+	r.recordTask(models.ChangeDelete, task)
+
+	r.deleteHistory(func(taskID string, projectID string) bool {
+		return taskID == id
+	})
+	return nil
+}
+
+func (r *taskRepo) SoftDelete(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok || task.DeletedAt != nil {
+		return mongo.ErrNoDocuments
+	}
+
+	task.DeletedAt = timePtr(at)
+	task.Version++
+	r.tasks[id] = task
+	r.recordTask(models.ChangeUpdate, task)
+	return nil
+}
+
+func (r *taskRepo) Restore(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok || task.DeletedAt == nil {
+		return mongo.ErrNoDocuments
+	}
+	if project, ok := r.projects[task.ProjectID]; ok && project.DeletedAt != nil {
+		return repositories.ErrParentDeleted
+	}
+
+	task.DeletedAt, task.DeletedWith = nil, ""
+	task.Version++
+	r.tasks[id] = task
+	r.recordTask(models.ChangeUpdate, task)
+	return nil
+}
+
+func (r *taskRepo) Transition(
+	ctx context.Context,
+	id string,
+	from string,
+	update bson.M,
+	transition models.StatusTransition,
+) error {
+	transition.From = from
+	return r.Patch(ctx, id, repositories.Patch{Set: update, Actor: transition.Actor}, &transition)
+}
+
+func (r *taskRepo) Patch(
+	ctx context.Context,
+	id string,
+	patch repositories.Patch,
+	transition *models.StatusTransition,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.transition(id, patch, transition)
+}
+
+// BulkPatch applies the patches one by one under the lock, which makes
+// them one write as far as readers can tell.
+func (r *taskRepo) BulkPatch(ctx context.Context, patches []repositories.TaskPatch) ([]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	errs := make([]error, len(patches))
+	for i, item := range patches {
+		err := r.transition(item.ID, item.Patch, item.Transition)
+		switch err {
+		case nil, mongo.ErrNoDocuments, repositories.ErrArchived,
+			repositories.ErrVersionConflict, repositories.ErrStatusConflict:
+			errs[i] = err
+		default:
+			return nil, err
+		}
+	}
+	return errs, nil
+}
+
+// transition applies patch while the task is in transition.From, if any,
+// and records the transition. The caller holds the lock.
+func (r *taskRepo) transition(id string, patch repositories.Patch, transition *models.StatusTransition) error {
+	task, ok := r.tasks[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	if err := writable(task.ArchivedAt, task.DeletedAt); err != nil {
+		return err
+	}
+	if patch.IfVersion != nil && task.Version != *patch.IfVersion {
+		return repositories.ErrVersionConflict
+	}
+	if transition != nil && task.Status != transition.From {
+		return repositories.ErrStatusConflict
+	}
+
+	if err := r.patch(id, patch); err != nil {
+		return err
+	}
+	if transition == nil {
+		return nil
+	}
+
+	recorded, err := clone(*transition)
+	if err != nil {
+		return err
+	}
+	recorded.ID = primitive.NewObjectID().Hex()
+	r.transitions = append(r.transitions, recorded)
+	return nil
+}
+
+func (r *taskRepo) ListTransitions(ctx context.Context, taskID string) ([]models.StatusTransition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	transitions := []models.StatusTransition{}
+	for _, transition := range r.transitions {
+		if transition.TaskID == taskID {
+			transitions = append(transitions, transition)
+		}
+	}
+
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].At.Before(transitions[j].At)
+	})
+
+	return transitions, nil
+}
+
+func (r *taskRepo) CountOutsideStates(ctx context.Context, projectID string, states []string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	allowed := map[string]bool{}
+	for _, state := range states {
+		allowed[state] = true
+	}
+
+	var count int64
+	for _, task := range r.tasks {
+		if task.ProjectID == projectID && !allowed[task.Status] {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *taskRepo) CountCustomFieldValues(ctx context.Context, projectID string, key string, except []string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, task := range r.tasks {
+		if task.ProjectID != projectID {
+			continue
+		}
+		value, ok := task.CustomFields[key]
+		if !ok {
+			continue
+		}
+		if s, isString := value.(string); isString && slices.Contains(except, s) {
+			continue
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func (r *taskRepo) ListNotifiable(ctx context.Context, at time.Time, limit int64) ([]models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []models.Task{}
+	for _, task := range r.tasks {
+		if task.NotifyAt == nil || task.NotifyAt.After(at) || task.ArchivedAt != nil || task.DeletedAt != nil {
+			continue
+		}
+		task, err := clone(task)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return earlier(*tasks[i].NotifyAt, tasks[i].ID, *tasks[j].NotifyAt, tasks[j].ID)
+	})
+
+	return cut(tasks, 1, limit), nil
+}
+
+func (r *taskRepo) SetNotifyAt(ctx context.Context, id string, from time.Time, next *time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok || task.NotifyAt == nil || !task.NotifyAt.Equal(from) {
+		return false, nil
+	}
+
+	task.NotifyAt = nil
+	if next != nil {
+		task.NotifyAt = timePtr(*next)
+	}
+	r.tasks[id] = task
+	return true, nil
+}
@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+)
+
+// taskField returns the value of a whitelisted task field, and false when the
+// task does not have it. Labels come as []string.
+func taskField(task models.Task, field string) (interface{}, bool) {
+	if key, ok := repositories.CustomFieldKey(field); ok {
+		value, ok := task.CustomFields[key]
+		return value, ok
+	}
+
+	switch field {
+	case "status":
+		return task.Status, true
+	case "priority":
+		return task.Priority, true
+	case "assignedTo":
+		if task.AssignedTo == nil {
+			return nil, false
+		}
+		return *task.AssignedTo, true
+	case "labels":
+		return task.Labels, len(task.Labels) > 0
+	case "dueAt":
+		if task.DueAt == nil {
+			return nil, false
+		}
+		return *task.DueAt, true
+	case "createdAt":
+		return task.CreatedAt, true
+	case "updatedAt":
+		return task.UpdatedAt, true
+	}
+	return nil, false
+}
+
+// compareValues orders two strings, numbers or times. A missing value sorts
+// first, as in MongoDB.
+func compareValues(a interface{}, b interface{}) int {
+	switch a := a.(type) {
+	case nil:
+		if b == nil {
+			return 0
+		}
+		return -1
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b)
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return cmp.Compare(a, b)
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b)
+		}
+	}
+	return 1
+}
+
+// comparableValues tells whether a range operator can match, which MongoDB
+// only lets values of the same type do.
+func comparableValues(a interface{}, b interface{}) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b)
+}
+
+// matchTaskQuery follows MongoDB's semantics: ne and nin match tasks without
+// the field, every other operator needs it.
+func matchTaskQuery(task models.Task, q repositories.TaskQuery) bool {
+	for _, c := range q.Conditions {
+		value, ok := taskField(task, c.Field)
+		contains := func() bool {
+			return ok && slices.ContainsFunc(c.Values, func(v interface{}) bool {
+				if labels, isLabels := value.([]string); isLabels {
+					return slices.Contains(labels, v.(string))
+				}
+				return compareValues(value, v) == 0
+			})
+		}
+		if c.Op == repositories.OpGt || c.Op == repositories.OpGte || c.Op == repositories.OpLt || c.Op == repositories.OpLte {
+			ok = ok && comparableValues(value, c.Values[0])
+		}
+
+		var match bool
+		switch c.Op {
+		case repositories.OpEq, repositories.OpIn:
+			match = contains()
+		case repositories.OpNe, repositories.OpNin:
+			match = !contains()
+		case repositories.OpGt:
+			match = ok && compareValues(value, c.Values[0]) > 0
+		case repositories.OpGte:
+			match = ok && compareValues(value, c.Values[0]) >= 0
+		case repositories.OpLt:
+			match = ok && compareValues(value, c.Values[0]) < 0
+		case repositories.OpLte:
+			match = ok && compareValues(value, c.Values[0]) <= 0
+		case repositories.OpExists:
+			match = ok == c.Exists
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// sortValue is the value tasks sort by on field: the stored one, except
+// for priority which sorts by rank.
+func sortValue(task models.Task, field string) interface{} {
+	if field == "priority" {
+		return float64(models.TaskPriorityRank(task.Priority))
+	}
+	value, _ := taskField(task, field)
+	return value
+}
+
+// sortTasks orders tasks by the query's sort keys, ties broken by ID
+// descending. Without sort keys tasks come newest first.
+func sortTasks(tasks []models.Task, q repositories.TaskQuery) {
+	keys := q.Sort
+	if len(keys) == 0 {
+		keys = []repositories.TaskSort{{Field: "createdAt", Desc: true}}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		for _, key := range keys {
+			a, b := sortValue(tasks[i], key.Field), sortValue(tasks[j], key.Field)
+			if c := compareValues(a, b); c != 0 {
+				return c < 0 != key.Desc
+			}
+		}
+		return tasks[i].ID > tasks[j].ID
+	})
+}
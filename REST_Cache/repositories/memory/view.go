@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	models "task-manager/collections"
+	"task-manager/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type viewRepo struct {
+	*store
+}
+
+func (r *viewRepo) GetByID(ctx context.Context, id string) (*models.View, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	view, ok := r.views[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	view, err := clone(view)
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+func (r *viewRepo) ListByOrganization(ctx context.Context, orgID string) ([]models.View, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	views := []models.View{}
+	for _, view := range r.views {
+		if view.OrganizationID != orgID {
+			continue
+		}
+		view, err := clone(view)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+
+	sort.Slice(views, func(i, j int) bool {
+		return views[i].Name < views[j].Name
+	})
+
+	return views, nil
+}
+
+func (r *viewRepo) Create(ctx context.Context, view models.View) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.views[view.ID]; ok {
+		return duplicateID(view.ID)
+	}
+	if r.nameTaken(view) {
+		return repositories.ErrViewNameTaken
+	}
+
+	view, err := clone(view)
+	if err != nil {
+		return err
+	}
+	r.views[view.ID] = view
+	return nil
+}
+
+func (r *viewRepo) Update(ctx context.Context, id string, update bson.M) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	view, ok := r.views[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+
+	view, err := applySet(view, update)
+	if err != nil {
+		return err
+	}
+	if r.nameTaken(view) {
+		return repositories.ErrViewNameTaken
+	}
+
+	r.views[id] = view
+	return nil
+}
+
+func (r *viewRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.views[id]; !ok {
+		return mongo.ErrNoDocuments
+	}
+
+	delete(r.views, id)
+	return nil
+}
+
+// nameTaken tells whether another view of the organization has the view's
+// name, like the unique index in MongoDB. The caller must hold the lock.
+func (r *viewRepo) nameTaken(view models.View) bool {
+	for _, other := range r.views {
+		if other.ID != view.ID && other.OrganizationID == view.OrganizationID && other.Name == view.Name {
+			return true
+		}
+	}
+	return false
+}
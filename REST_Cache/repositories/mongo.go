@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NewMongo returns repositories backed by the given MongoDB database.
+// Cascading deletes, archival and soft deletion use transactions, which
+// need a replica set.
+func NewMongo(database *mongo.Database) Repositories {
+	return Repositories{
+		Organizations: &mongoOrganizationRepo{db: database},
+		Projects:      &mongoProjectRepo{db: database},
+		Tasks:         &mongoTaskRepo{db: database},
+		Jobs:          &mongoJobRepo{db: database},
+		Views:         &mongoViewRepo{db: database},
+		Labels:        &mongoLabelRepo{db: database},
+		Activity:      &mongoActivityRepo{db: database},
+		Comments:      &mongoCommentRepo{db: database},
+		Dependencies:  &mongoDependencyRepo{db: database},
+		Notifications: &mongoNotificationRepo{db: database},
+		Leases:        &mongoLeaseRepo{db: database},
+		Trash:         &mongoTrashRepo{db: database},
+		Search:        &mongoSearchRepo{db: database},
+		Stats:         &mongoStatsRepo{db: database},
+		Changes:       &mongoChangeFeed{db: database},
+	}
+}
+
+// aggregatePage runs pipeline, which has to sort, and returns one page of
+// its results along with their total, counted in the same round trip.
+func aggregatePage[T any](
+	ctx context.Context,
+	collection *mongo.Collection,
+	pipeline mongo.Pipeline,
+	page int64,
+	limit int64,
+) ([]T, int64, error) {
+
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: bson.M{
+		"items": bson.A{
+			bson.M{"$skip": (page - 1) * limit},
+			bson.M{"$limit": limit},
+		},
+		"total": bson.A{
+			bson.M{"$count": "count"},
+		},
+	}}})
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		Items []T `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, 0, err
+	}
+
+	items := []T{}
+	var total int64
+	if len(docs) > 0 {
+		items = append(items, docs[0].Items...)
+		if len(docs[0].Total) > 0 {
+			total = docs[0].Total[0].Count
+		}
+	}
+
+	return items, total, nil
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoNotificationRepo struct {
+	db *mongo.Database
+}
+
+func (r *mongoNotificationRepo) Record(ctx context.Context, notification models.Notification) error {
+	_, err := r.db.
+		Collection("task_notifications").
+		InsertOne(ctx, notification)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAlreadySent
+	}
+	return err
+}
+
+func (r *mongoNotificationRepo) Forget(ctx context.Context, id string) error {
+	_, err := r.db.
+		Collection("task_notifications").
+		DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *mongoNotificationRepo) ListByTask(ctx context.Context, taskID string) ([]models.Notification, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.db.
+		Collection("task_notifications").
+		Find(ctx, bson.M{"taskId": taskID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+type mongoLeaseRepo struct {
+	db *mongo.Database
+}
+
+// Acquire upserts the lease unless another holder's is still running, in
+// which case the upsert collides with the existing lease on _id. Holders
+// compare their own clocks with expiresAt, so replicas' clocks should not
+// drift apart by more than a fraction of the ttl.
+func (r *mongoLeaseRepo) Acquire(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"holder": holder},
+			bson.M{"expiresAt": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"holder": holder, "expiresAt": now.Add(ttl)}}
+
+	_, err := r.db.
+		Collection("leases").
+		UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *mongoLeaseRepo) Release(ctx context.Context, name string, holder string) error {
+	_, err := r.db.
+		Collection("leases").
+		DeleteOne(ctx, bson.M{"_id": name, "holder": holder})
+	return err
+}
@@ -2,21 +2,26 @@ package repositories
 
 import (
 	"context"
+	"slices"
+	"time"
 
 	models "task-manager/collections"
-	"task-manager/db"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func GetOrganizationByID(ctx context.Context, id string) (*models.Organization, error) {
+type mongoOrganizationRepo struct {
+	db *mongo.Database
+}
+
+func (r *mongoOrganizationRepo) GetByID(ctx context.Context, id string) (*models.Organization, error) {
 	var org models.Organization
 
-	err := db.Database.
+	err := r.db.
 		Collection("organizations").
-		FindOne(ctx, bson.M{"_id": id}).
+		FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}).
 		Decode(&org)
 
 	if err != nil {
@@ -26,14 +31,14 @@ func GetOrganizationByID(ctx context.Context, id string) (*models.Organization,
 	return &org, nil
 }
 
-func ListOrganizations(
+func (r *mongoOrganizationRepo) List(
 	ctx context.Context,
 	page int64,
 	limit int64,
 	status *string,
 ) ([]models.Organization, int64, error) {
 
-	filter := bson.M{}
+	filter := bson.M{"deletedAt": nil}
 	if status != nil {
 		filter["status"] = *status
 	}
@@ -43,7 +48,7 @@ func ListOrganizations(
 		SetLimit(limit).
 		SetSort(bson.M{"createdAt": -1})
 
-	cursor, err := db.Database.
+	cursor, err := r.db.
 		Collection("organizations").
 		Find(ctx, filter, opts)
 	if err != nil {
@@ -56,7 +61,7 @@ func ListOrganizations(
 		return nil, 0, err
 	}
 
-	total, err := db.Database.
+	total, err := r.db.
 		Collection("organizations").
 		CountDocuments(ctx, filter)
 	if err != nil {
@@ -66,41 +71,222 @@ func ListOrganizations(
 	return orgs, total, nil
 }
 
-func CreateOrganization(ctx context.Context, org models.Organization) error {
-	_, err := db.Database.
+func (r *mongoOrganizationRepo) ListKeyset(ctx context.Context, query KeysetQuery) ([]models.Organization, error) {
+	filter := bson.M{"deletedAt": nil}
+	if query.Status != nil {
+		filter["status"] = *query.Status
+	}
+
+	order := -1
+	if query.After != nil {
+		filter["$or"] = bson.A{
+			bson.M{"createdAt": bson.M{"$lt": query.After.CreatedAt}},
+			bson.M{"createdAt": query.After.CreatedAt, "_id": bson.M{"$lt": query.After.ID}},
+		}
+	}
+	if query.Before != nil {
+		// Walk towards newer documents, then flip the page back around
+		order = 1
+		filter["$or"] = bson.A{
+			bson.M{"createdAt": bson.M{"$gt": query.Before.CreatedAt}},
+			bson.M{"createdAt": query.Before.CreatedAt, "_id": bson.M{"$gt": query.Before.ID}},
+		}
+	}
+
+	opts := options.Find().
+		SetLimit(query.Limit).
+		SetSort(bson.D{{Key: "createdAt", Value: order}, {Key: "_id", Value: order}})
+
+	cursor, err := r.db.
+		Collection("organizations").
+		Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orgs := []models.Organization{}
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+
+	if query.Before != nil {
+		slices.Reverse(orgs)
+	}
+
+	return orgs, nil
+}
+
+func (r *mongoOrganizationRepo) Count(ctx context.Context, status *string) (int64, error) {
+	filter := bson.M{"deletedAt": nil}
+	if status != nil {
+		filter["status"] = *status
+	}
+
+	return r.db.
+		Collection("organizations").
+		CountDocuments(ctx, filter)
+}
+
+func (r *mongoOrganizationRepo) Create(ctx context.Context, org models.Organization) error {
+	_, err := r.db.
 		Collection("organizations").
 		InsertOne(ctx, org)
-	return err
+	return nameConflict(err)
 }
 
-func UpdateOrganization(
+func (r *mongoOrganizationRepo) Update(
 	ctx context.Context,
 	id string,
 	update bson.M,
 ) error {
-	res, err := db.Database.
-		Collection("organizations").
-		UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	return r.Patch(ctx, id, Patch{Set: update})
+}
+
+func (r *mongoOrganizationRepo) Patch(ctx context.Context, id string, patch Patch) error {
+	collection := r.db.Collection("organizations")
+	res, err := collection.UpdateOne(ctx, patch.filter(id), patch.update())
 
 	if err != nil {
-		return err
+		return nameConflict(err)
 	}
 	if res.MatchedCount == 0 {
-		return mongo.ErrNoDocuments
+		if err := patchMiss(ctx, collection, id, patch); err != nil {
+			return err
+		}
+		return ErrVersionConflict
 	}
 	return nil
 }
 
-func DeleteOrganization(ctx context.Context, id string) error {
-	res, err := db.Database.
-		Collection("organizations").
-		DeleteOne(ctx, bson.M{"_id": id})
+func (r *mongoOrganizationRepo) Archive(ctx context.Context, id string, at time.Time) (*CascadeResult, error) {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		collection := r.db.Collection("organizations")
+		org, err := readLifecycle(sc, collection, id)
+		if err != nil {
+			return nil, err
+		}
+		if org.DeletedAt != nil {
+			return nil, mongo.ErrNoDocuments
+		}
+		if org.ArchivedAt != nil {
+			return nil, ErrArchived
+		}
 
-	if err != nil {
-		return err
-	}
-	if res.DeletedCount == 0 {
-		return mongo.ErrNoDocuments
-	}
-	return nil
+		_, err = collection.UpdateOne(sc, bson.M{"_id": id}, bson.M{
+			"$set": bson.M{
+				"status":     models.OrganizationStatusArchived,
+				"archivedAt": at,
+				"updatedAt":  at,
+			},
+			"$inc": bson.M{"version": 1},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return archival.cascadeOrganization(sc, r.db, id, at)
+	})
+}
+
+func (r *mongoOrganizationRepo) Unarchive(ctx context.Context, id string) (*CascadeResult, error) {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		collection := r.db.Collection("organizations")
+		org, err := readLifecycle(sc, collection, id)
+		if err != nil {
+			return nil, err
+		}
+		if org.DeletedAt != nil {
+			return nil, mongo.ErrNoDocuments
+		}
+		if org.ArchivedAt == nil {
+			return nil, ErrNotArchived
+		}
+
+		_, err = collection.UpdateOne(sc, bson.M{"_id": id}, bson.M{
+			"$set": bson.M{
+				"status":    models.OrganizationStatusActive,
+				"updatedAt": time.Now(),
+			},
+			"$unset": bson.M{"archivedAt": ""},
+			"$inc":   bson.M{"version": 1},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return archival.revert(sc, r.db, id)
+	})
+}
+
+func (r *mongoOrganizationRepo) SoftDelete(ctx context.Context, id string, at time.Time) (*CascadeResult, error) {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		res, err := r.db.
+			Collection("organizations").
+			UpdateOne(sc, bson.M{"_id": id, "deletedAt": nil}, deletion.set(at, ""))
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		return deletion.cascadeOrganization(sc, r.db, id, at)
+	})
+}
+
+func (r *mongoOrganizationRepo) Restore(ctx context.Context, id string) (*CascadeResult, error) {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		res, err := r.db.
+			Collection("organizations").
+			UpdateOne(sc, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}}, deletion.unset())
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		return deletion.revert(sc, r.db, id)
+	})
+}
+
+func (r *mongoOrganizationRepo) Purge(ctx context.Context, id string, deletedAt time.Time) (*CascadeResult, error) {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) (*CascadeResult, error) {
+		del, err := r.db.
+			Collection("organizations").
+			DeleteOne(sc, bson.M{"_id": id, "deletedAt": deletedAt})
+		if err != nil {
+			return nil, err
+		}
+		if del.DeletedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		for _, collection := range []string{"views", "labels"} {
+			_, err = r.db.
+				Collection(collection).
+				DeleteMany(sc, bson.M{"organizationId": id})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if _, err := r.db.Collection("dependency_graphs").DeleteOne(sc, bson.M{"_id": id}); err != nil {
+			return nil, err
+		}
+
+		projects := &mongoProjectRepo{db: r.db}
+		projectIDs, err := projects.ListIDs(sc, id)
+		if err != nil {
+			return nil, err
+		}
+		_, err = r.db.
+			Collection("projects").
+			UpdateMany(sc, bson.M{"organizationId": id, "deletedWith": id}, bson.M{"$unset": bson.M{"deletedWith": ""}})
+		if err != nil {
+			return nil, err
+		}
+
+		return &CascadeResult{ProjectIDs: projectIDs, TaskIDs: []string{}}, nil
+	})
 }
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"sort"
+	"time"
+
+	models "task-manager/collections"
+)
+
+// TaskGroup counts a project's tasks that share a status, priority,
+// assignee and whether they are overdue. Every breakdown of the stats can be
+// summed up from these groups.
+type TaskGroup struct {
+	Status     string  `bson:"status"`
+	Priority   string  `bson:"priority"`
+	AssignedTo *string `bson:"assignedTo"`
+	Overdue    bool    `bson:"overdue"`
+	Count      int64   `bson:"count"`
+}
+
+// IsOverdue tells whether a task with the given status and due date counts
+// as overdue at now in a project with the given workflow.
+func IsOverdue(workflow models.Workflow, status string, dueAt *time.Time, now time.Time) bool {
+	return dueAt != nil && dueAt.Before(now) && !workflow.IsFinal(status)
+}
+
+// BuildProjectStats sums up the groups of one project.
+func BuildProjectStats(project models.Project, groups []TaskGroup, now time.Time) models.ProjectStats {
+	stats := models.ProjectStats{
+		ProjectID:   project.ID,
+		Name:        project.Name,
+		Status:      project.Status,
+		Tasks:       newTaskCounts(),
+		GeneratedAt: now,
+	}
+
+	workload := map[string]*models.AssigneeWorkload{}
+	for _, g := range groups {
+		addTaskCounts(&stats.Tasks, g)
+		if g.AssignedTo != nil {
+			addWorkload(workload, *g.AssignedTo, g.Status, g.Count)
+		}
+	}
+	stats.Workload = sortedWorkload(workload)
+
+	return stats
+}
+
+// BuildOrganizationStats combines the stats of an organization's projects.
+func BuildOrganizationStats(orgID string, projects []models.ProjectStats, now time.Time) models.OrganizationStats {
+	stats := models.OrganizationStats{
+		OrganizationID:   orgID,
+		ProjectsByStatus: map[string]int64{},
+		Tasks:            newTaskCounts(),
+		Projects:         projects,
+		GeneratedAt:      now,
+	}
+
+	workload := map[string]*models.AssigneeWorkload{}
+	for _, project := range projects {
+		stats.ProjectsByStatus[project.Status]++
+
+		stats.Tasks.Total += project.Tasks.Total
+		stats.Tasks.Unassigned += project.Tasks.Unassigned
+		stats.Tasks.Overdue += project.Tasks.Overdue
+		for status, n := range project.Tasks.ByStatus {
+			stats.Tasks.ByStatus[status] += n
+		}
+		for priority, n := range project.Tasks.ByPriority {
+			stats.Tasks.ByPriority[priority] += n
+		}
+
+		for _, w := range project.Workload {
+			for status, n := range w.ByStatus {
+				addWorkload(workload, w.UserID, status, n)
+			}
+		}
+	}
+	stats.Workload = sortedWorkload(workload)
+
+	sort.Slice(stats.Projects, func(i, j int) bool {
+		if stats.Projects[i].Name != stats.Projects[j].Name {
+			return stats.Projects[i].Name < stats.Projects[j].Name
+		}
+		return stats.Projects[i].ProjectID < stats.Projects[j].ProjectID
+	})
+
+	return stats
+}
+
+func newTaskCounts() models.TaskCounts {
+	return models.TaskCounts{
+		ByStatus:   map[string]int64{},
+		ByPriority: map[string]int64{},
+	}
+}
+
+func addTaskCounts(counts *models.TaskCounts, g TaskGroup) {
+	counts.Total += g.Count
+	counts.ByStatus[g.Status] += g.Count
+	counts.ByPriority[g.Priority] += g.Count
+	if g.AssignedTo == nil {
+		counts.Unassigned += g.Count
+	}
+	if g.Overdue {
+		counts.Overdue += g.Count
+	}
+}
+
+func addWorkload(workload map[string]*models.AssigneeWorkload, userID string, status string, n int64) {
+	w, ok := workload[userID]
+	if !ok {
+		w = &models.AssigneeWorkload{UserID: userID, ByStatus: map[string]int64{}}
+		workload[userID] = w
+	}
+	w.Total += n
+	w.ByStatus[status] += n
+}
+
+// sortedWorkload puts the busiest assignees first.
+func sortedWorkload(workload map[string]*models.AssigneeWorkload) []models.AssigneeWorkload {
+	out := make([]models.AssigneeWorkload, 0, len(workload))
+	for _, w := range workload {
+		out = append(out, *w)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].UserID < out[j].UserID
+	})
+
+	return out
+}
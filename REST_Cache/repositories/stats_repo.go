@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoStatsRepo struct {
+	db *mongo.Database
+}
+
+// projectWithGroups is a project as the stats pipeline returns it.
+type projectWithGroups struct {
+	models.Project `bson:",inline"`
+	Groups         []TaskGroup `bson:"groups"`
+}
+
+func (r *mongoStatsRepo) Project(ctx context.Context, projectID string) (*models.ProjectStats, error) {
+	projects, err := r.projectStats(ctx, bson.M{"_id": projectID, "deletedAt": nil})
+	if err != nil {
+		return nil, err
+	}
+	if len(projects) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return &projects[0], nil
+}
+
+func (r *mongoStatsRepo) Organization(ctx context.Context, orgID string) (*models.OrganizationStats, error) {
+	projects, err := r.projectStats(ctx, bson.M{"organizationId": orgID, "deletedAt": nil})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := BuildOrganizationStats(orgID, projects, time.Now())
+	return &stats, nil
+}
+
+// projectStats runs one pipeline over the matching projects that joins each
+// project's tasks grouped by status, priority, assignee and whether they are
+// overdue. The groups are small, whatever the number of tasks. Tasks in the
+// trash do not count.
+func (r *mongoStatsRepo) projectStats(ctx context.Context, match bson.M) ([]models.ProjectStats, error) {
+	now := time.Now()
+
+	// A task is overdue while its due date has passed and it has not reached
+	// the last state of its project's workflow
+	overdue := bson.M{"$and": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": "$dueAt"}, "date"}},
+		bson.M{"$lt": bson.A{"$dueAt", now}},
+		bson.M{"$ne": bson.A{"$status", "$$final"}},
+	}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "tasks",
+			"localField":   "_id",
+			"foreignField": "projectId",
+			"let": bson.M{"final": bson.M{"$ifNull": bson.A{
+				bson.M{"$arrayElemAt": bson.A{"$workflow.states", -1}},
+				models.DefaultWorkflow().Final(),
+			}}},
+			"as": "groups",
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"deletedAt": nil}},
+				bson.M{"$group": bson.M{
+					"_id": bson.M{
+						"status":     "$status",
+						"priority":   "$priority",
+						"assignedTo": "$assignedTo",
+						"overdue":    overdue,
+					},
+					"count": bson.M{"$sum": 1},
+				}},
+				bson.M{"$project": bson.M{
+					"_id":        0,
+					"status":     "$_id.status",
+					"priority":   "$_id.priority",
+					"assignedTo": "$_id.assignedTo",
+					"overdue":    "$_id.overdue",
+					"count":      1,
+				}},
+			},
+		}}},
+		{{Key: "$project", Value: bson.M{"workflow": 0}}},
+	}
+
+	cursor, err := r.db.
+		Collection("projects").
+		Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []projectWithGroups
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	projects := make([]models.ProjectStats, 0, len(docs))
+	for _, doc := range docs {
+		projects = append(projects, BuildProjectStats(doc.Project, doc.Groups, now))
+	}
+
+	return projects, nil
+}
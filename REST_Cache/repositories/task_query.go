@@ -0,0 +1,229 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Operators of task query conditions.
+const (
+	OpEq     = "eq"
+	OpNe     = "ne"
+	OpIn     = "in"
+	OpNin    = "nin"
+	OpGt     = "gt"
+	OpGte    = "gte"
+	OpLt     = "lt"
+	OpLte    = "lte"
+	OpExists = "exists"
+)
+
+// taskQueryFields whitelists the task fields a query may filter and sort by,
+// and tells whether the field holds a time. Labels can only be filtered by,
+// a condition on them holds when any of the task's labels satisfies it.
+// Custom fields are queried as customFields.<key>.
+var taskQueryFields = map[string]bool{
+	"status":     false,
+	"priority":   false,
+	"assignedTo": false,
+	"labels":     false,
+	"dueAt":      true,
+	"createdAt":  true,
+	"updatedAt":  true,
+}
+
+const customFieldPrefix = "customFields."
+
+var ErrInvalidTaskQuery = errors.New("invalid task query")
+
+// TaskQuery filters and sorts a task listing. All conditions must hold.
+// Without Sort tasks come newest first, ties are always broken by ID.
+type TaskQuery struct {
+	Conditions []TaskCondition
+	Sort       []TaskSort
+}
+
+// TaskCondition compares a task field with Values: one value, several for
+// in and nin, none for exists. Values are strings, time.Time for dueAt,
+// createdAt and updatedAt, and strings or float64 for custom fields.
+type TaskCondition struct {
+	Field  string
+	Op     string
+	Values []interface{}
+	Exists bool
+}
+
+type TaskSort struct {
+	Field string
+	Desc  bool
+}
+
+// IsTaskQueryField tells whether tasks can be filtered by field.
+func IsTaskQueryField(field string) bool {
+	if _, ok := CustomFieldKey(field); ok {
+		return true
+	}
+	_, ok := taskQueryFields[field]
+	return ok
+}
+
+// IsTaskSortField tells whether tasks can be sorted by field.
+func IsTaskSortField(field string) bool {
+	return field != "labels" && IsTaskQueryField(field)
+}
+
+// IsTaskTimeField tells whether field holds a time.
+func IsTaskTimeField(field string) bool {
+	return taskQueryFields[field]
+}
+
+// CustomFieldKey returns the key of a customFields.<key> field, and false
+// for other fields.
+func CustomFieldKey(field string) (string, bool) {
+	key, ok := strings.CutPrefix(field, customFieldPrefix)
+	return key, ok && models.IsValidCustomFieldKey(key)
+}
+
+// Validate checks the query against the field whitelist and the value types,
+// so it can be turned into a filter safely.
+func (q TaskQuery) Validate() error {
+	conditions := map[[2]string]bool{}
+	for _, c := range q.Conditions {
+		if !IsTaskQueryField(c.Field) {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidTaskQuery, c.Field)
+		}
+		if conditions[[2]string{c.Field, c.Op}] {
+			return fmt.Errorf("%w: %s %s given twice", ErrInvalidTaskQuery, c.Field, c.Op)
+		}
+		conditions[[2]string{c.Field, c.Op}] = true
+
+		want := 1
+		switch c.Op {
+		case OpEq, OpNe:
+		case OpGt, OpGte, OpLt, OpLte:
+			if _, custom := CustomFieldKey(c.Field); !custom && !IsTaskTimeField(c.Field) {
+				return fmt.Errorf("%w: %s is not a range field", ErrInvalidTaskQuery, c.Field)
+			}
+		case OpIn, OpNin:
+			want = -1
+			if len(c.Values) == 0 {
+				return fmt.Errorf("%w: %s %s needs values", ErrInvalidTaskQuery, c.Field, c.Op)
+			}
+		case OpExists:
+			want = 0
+		default:
+			return fmt.Errorf("%w: unknown operator %q", ErrInvalidTaskQuery, c.Op)
+		}
+		if want >= 0 && len(c.Values) != want {
+			return fmt.Errorf("%w: %s %s takes %d values", ErrInvalidTaskQuery, c.Field, c.Op, want)
+		}
+
+		for _, value := range c.Values {
+			var ok bool
+			if _, custom := CustomFieldKey(c.Field); custom {
+				switch value.(type) {
+				case string, float64:
+					ok = true
+				}
+			} else if IsTaskTimeField(c.Field) {
+				_, ok = value.(time.Time)
+			} else {
+				_, ok = value.(string)
+			}
+			if !ok {
+				return fmt.Errorf("%w: bad value for %s", ErrInvalidTaskQuery, c.Field)
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, s := range q.Sort {
+		if !IsTaskSortField(s.Field) {
+			return fmt.Errorf("%w: unknown sort field %q", ErrInvalidTaskQuery, s.Field)
+		}
+		if seen[s.Field] {
+			return fmt.Errorf("%w: %s sorted twice", ErrInvalidTaskQuery, s.Field)
+		}
+		seen[s.Field] = true
+	}
+
+	return nil
+}
+
+// taskQueryFilter adds the query's conditions to filter. Field names only
+// come from the whitelist and values are never interpreted as operators.
+func taskQueryFilter(filter bson.M, q TaskQuery) (bson.M, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range q.Conditions {
+		field := c.Field
+		if field == "labels" && c.Op == OpExists {
+			// Removing a task's last label leaves an empty array
+			field = "labels.0"
+		}
+
+		ops, ok := filter[field].(bson.M)
+		if !ok {
+			ops = bson.M{}
+			filter[field] = ops
+		}
+
+		switch c.Op {
+		case OpIn, OpNin:
+			ops["$"+c.Op] = bson.A(c.Values)
+		case OpExists:
+			ops["$exists"] = c.Exists
+		default:
+			ops["$"+c.Op] = c.Values[0]
+		}
+	}
+
+	return filter, nil
+}
+
+// priorityRankField holds the rank a listing sorted by priority adds to
+// each task, so priorities sort from low to urgent rather than by name.
+const priorityRankField = "priorityRank"
+
+// priorityRank computes models.TaskPriorityRank in an aggregation.
+func priorityRank() bson.M {
+	branches := bson.A{}
+	for _, priority := range models.TaskPriorities {
+		branches = append(branches, bson.M{
+			"case": bson.M{"$eq": bson.A{"$priority", priority}},
+			"then": models.TaskPriorityRank(priority),
+		})
+	}
+	return bson.M{"$switch": bson.M{"branches": branches, "default": 0}}
+}
+
+// taskQuerySort orders by the query's sort keys, or newest first. Priority
+// sorts by priorityRankField, which the listing has to add.
+func taskQuerySort(q TaskQuery) bson.D {
+	if len(q.Sort) == 0 {
+		return bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}
+	}
+
+	sort := bson.D{}
+	for _, s := range q.Sort {
+		order := 1
+		if s.Desc {
+			order = -1
+		}
+		field := s.Field
+		if field == "priority" {
+			field = priorityRankField
+		}
+		sort = append(sort, bson.E{Key: field, Value: order})
+	}
+
+	return append(sort, bson.E{Key: "_id", Value: -1})
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoTrashRepo struct {
+	db *mongo.Database
+}
+
+// inTrash matches what was deleted on its own rather than along with its
+// organization or project.
+var inTrash = bson.M{"deletedAt": bson.M{"$ne": nil}, "deletedWith": nil}
+
+func (r *mongoTrashRepo) ListOrganizations(ctx context.Context, page int64, limit int64) ([]models.Organization, int64, error) {
+	filter := bson.M{"deletedAt": bson.M{"$ne": nil}}
+
+	opts := options.Find().
+		SetSkip((page - 1) * limit).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "deletedAt", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.db.
+		Collection("organizations").
+		Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	orgs := []models.Organization{}
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.db.
+		Collection("organizations").
+		CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orgs, total, nil
+}
+
+// List pages the projects and tasks in one pipeline, tasks joined in with
+// $unionWith, which needs MongoDB 4.4.
+func (r *mongoTrashRepo) List(ctx context.Context, orgID string, page int64, limit int64) ([]models.TrashItem, int64, error) {
+	values, err := r.db.
+		Collection("projects").
+		Distinct(ctx, "_id", bson.M{"organizationId": orgID, "deletedAt": nil})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	projects := bson.M{"organizationId": orgID}
+	tasks := bson.M{"projectId": bson.M{"$in": stringValues(values)}}
+	for key, value := range inTrash {
+		projects[key] = value
+		tasks[key] = value
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: projects}},
+		{{Key: "$project", Value: trashItemProjection(models.TrashItemProject, "$name")}},
+		{{Key: "$unionWith", Value: bson.M{
+			"coll": "tasks",
+			"pipeline": bson.A{
+				bson.M{"$match": tasks},
+				bson.M{"$project": trashItemProjection(models.TrashItemTask, "$title")},
+			},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "deletedAt", Value: -1}, {Key: "_id", Value: -1}}}},
+	}
+
+	return aggregatePage[models.TrashItem](ctx, r.db.Collection("projects"), pipeline, page, limit)
+}
+
+func (r *mongoTrashRepo) Expired(ctx context.Context, before time.Time, limit int64) ([]models.TrashItem, error) {
+	items := []models.TrashItem{}
+
+	for _, kind := range []struct {
+		itemType, collection, title string
+	}{
+		{models.TrashItemOrganization, "organizations", "$name"},
+		{models.TrashItemProject, "projects", "$name"},
+		{models.TrashItemTask, "tasks", "$title"},
+	} {
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"deletedAt": bson.M{"$lt": before}, "deletedWith": nil}}},
+			{{Key: "$sort", Value: bson.D{{Key: "deletedAt", Value: 1}, {Key: "_id", Value: 1}}}},
+			{{Key: "$limit", Value: limit}},
+			{{Key: "$project", Value: trashItemProjection(kind.itemType, kind.title)}},
+		}
+
+		cursor, err := r.db.
+			Collection(kind.collection).
+			Aggregate(ctx, pipeline)
+		if err != nil {
+			return nil, err
+		}
+
+		var expired []models.TrashItem
+		err = cursor.All(ctx, &expired)
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, expired...)
+	}
+
+	return items, nil
+}
+
+func trashItemProjection(itemType string, title string) bson.M {
+	return bson.M{
+		"type":      bson.M{"$literal": itemType},
+		"title":     title,
+		"projectId": 1,
+		"deletedAt": 1,
+	}
+}
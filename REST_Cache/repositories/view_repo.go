@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+
+	models "task-manager/collections"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoViewRepo struct {
+	db *mongo.Database
+}
+
+func (r *mongoViewRepo) GetByID(ctx context.Context, id string) (*models.View, error) {
+	var view models.View
+
+	err := r.db.
+		Collection("views").
+		FindOne(ctx, bson.M{"_id": id}).
+		Decode(&view)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &view, nil
+}
+
+func (r *mongoViewRepo) ListByOrganization(ctx context.Context, orgID string) ([]models.View, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.db.
+		Collection("views").
+		Find(ctx, bson.M{"organizationId": orgID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	views := []models.View{}
+	if err := cursor.All(ctx, &views); err != nil {
+		return nil, err
+	}
+
+	return views, nil
+}
+
+// Create and Update rely on the unique index on organizationId and name.
+func (r *mongoViewRepo) Create(ctx context.Context, view models.View) error {
+	_, err := r.db.
+		Collection("views").
+		InsertOne(ctx, view)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrViewNameTaken
+	}
+	return err
+}
+
+func (r *mongoViewRepo) Update(
+	ctx context.Context,
+	id string,
+	update bson.M,
+) error {
+	res, err := r.db.
+		Collection("views").
+		UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrViewNameTaken
+	}
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoViewRepo) Delete(ctx context.Context, id string) error {
+	res, err := r.db.
+		Collection("views").
+		DeleteOne(ctx, bson.M{"_id": id})
+
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}